@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"go-dev/internal/database"
+	"go-dev/internal/rates"
+	"go-dev/internal/repository"
+
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Загрузка курсов валют из локального файла в таблицу exchange_rates.
+//
+//	go run ./cmd/import-rates -file eurofxref-hist.xml
+//	go run ./cmd/import-rates -file rates.csv
+func main() {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: true,
+	})
+
+	path := flag.String("file", "", "CSV or ECB XML file with exchange rates")
+	flag.Parse()
+
+	if *path == "" {
+		logger.Fatal("Flag -file is required")
+	}
+
+	loaded, err := rates.LoadFile(*path)
+	if err != nil {
+		logger.WithError(err).WithField("file", *path).Fatal("Failed to read exchange rates")
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	db, err := database.Connect(dbURL)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to connect to database")
+	}
+	defer db.Close()
+
+	if err := database.RunMigrations(dbURL); err != nil {
+		logger.WithError(err).Fatal("Failed to run migrations")
+	}
+
+	if err := repository.NewExchangeRateRepository(db).Upsert(loaded); err != nil {
+		logger.WithError(err).Fatal("Failed to save exchange rates")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"file":  *path,
+		"rates": len(loaded),
+	}).Info("Exchange rates imported")
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"go-dev/internal/database"
+	"go-dev/internal/models"
+	"go-dev/internal/repository"
+	"go-dev/internal/service"
+	"go-dev/internal/statement"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+// Загрузка банковской выписки в формате CSV в платежи пользователя и поиск
+// повторяющихся списаний, которые можно подтвердить как подписки.
+//
+//	go run ./cmd/import-statement -user 60601fee-2bf1-4721-ae6f-7636e79a0cba -file statement.csv
+//	go run ./cmd/import-statement -user ... -file bank.csv -delimiter ";" -date-format DD.MM.YYYY -decimal-comma
+func main() {
+	logger := logrus.New()
+	logger.SetFormatter(&logrus.TextFormatter{
+		FullTimestamp: true,
+	})
+
+	path := flag.String("file", "", "CSV bank statement")
+	user := flag.String("user", "", "UUID of the user the statement belongs to")
+	var mapping models.StatementMapping
+	flag.StringVar(&mapping.DateColumn, "date-column", "date", "date column name")
+	flag.StringVar(&mapping.DescriptionColumn, "description-column", "description", "description column name")
+	flag.StringVar(&mapping.AmountColumn, "amount-column", "amount", "amount column name")
+	flag.StringVar(&mapping.CurrencyColumn, "currency-column", "", "currency column name (default: currency, if present)")
+	flag.StringVar(&mapping.ReferenceColumn, "reference-column", "", "transaction ID column name")
+	flag.StringVar(&mapping.DateFormat, "date-format", "YYYY-MM-DD", "date format built from YYYY, YY, MM, DD")
+	flag.StringVar(&mapping.Delimiter, "delimiter", ",", "column delimiter: a single character or tab")
+	flag.BoolVar(&mapping.DecimalComma, "decimal-comma", false, "comma is the decimal separator")
+	flag.BoolVar(&mapping.DebitsPositive, "debits-positive", false, "charges are positive amounts")
+	flag.StringVar(&mapping.Currency, "currency", models.DefaultCurrency, "currency when there is no currency column")
+	flag.Parse()
+
+	if *path == "" || *user == "" {
+		logger.Fatal("Flags -file and -user are required")
+	}
+	userID, err := uuid.Parse(*user)
+	if err != nil {
+		logger.WithField("user", *user).Fatal("Invalid user UUID")
+	}
+
+	file, err := os.Open(*path)
+	if err != nil {
+		logger.WithError(err).WithField("file", *path).Fatal("Failed to open bank statement")
+	}
+	defer file.Close()
+
+	transactions, err := statement.ParseCSV(file, mapping)
+	if err != nil {
+		logger.WithError(err).WithField("file", *path).Fatal("Failed to read bank statement")
+	}
+
+	dbURL := os.Getenv("DATABASE_URL")
+	db, err := database.Connect(dbURL)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to connect to database")
+	}
+	defer db.Close()
+
+	if err := database.RunMigrations(dbURL); err != nil {
+		logger.WithError(err).Fatal("Failed to run migrations")
+	}
+
+	subscriptionService := service.NewSubscriptionService(
+		repository.NewSubscriptionRepository(db),
+		repository.NewExchangeRateRepository(db),
+		repository.NewCatalogRepository(db),
+	)
+
+	paymentService := service.NewPaymentService(subscriptionService, repository.NewPaymentRepository(db))
+	statementService := service.NewStatementService(subscriptionService, paymentService, repository.NewCandidateRepository(db))
+
+	result, err := statementService.ImportStatement(userID, transactions)
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to import bank statement")
+	}
+
+	logger.WithFields(logrus.Fields{
+		"file":       *path,
+		"imported":   result.Imported,
+		"duplicates": result.Duplicates,
+		"linked":     result.Linked,
+	}).Info("Bank statement imported")
+
+	for _, candidate := range result.Candidates {
+		logger.WithFields(logrus.Fields{
+			"candidate_id": candidate.ID,
+			"service_name": candidate.ServiceName,
+			"amount":       candidate.Amount,
+			"currency":     candidate.Currency,
+			"period":       candidate.BillingPeriod,
+			"interval":     candidate.BillingInterval,
+			"charges":      candidate.Charges,
+			"confidence":   candidate.Confidence,
+		}).Info("Recurring charge found, confirm it via /users/{id}/subscription-candidates/{candidate_id}/confirm")
+	}
+}
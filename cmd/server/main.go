@@ -2,15 +2,20 @@ package main
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"go-dev/docs"
 	"go-dev/internal/database"
 	"go-dev/internal/handlers"
 	"go-dev/internal/middleware"
+	"go-dev/internal/models"
+	"go-dev/internal/receipt"
 	"go-dev/internal/repository"
 	"go-dev/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 	swaggerFiles "github.com/swaggo/files"
@@ -60,14 +65,118 @@ func main() {
 	// Репозитории
 	subscriptionRepo := repository.NewSubscriptionRepository(db)
 	userRepo := repository.NewUserRepository(db)
+	exchangeRateRepo := repository.NewExchangeRateRepository(db)
+	catalogRepo := repository.NewCatalogRepository(db)
+	budgetRepo := repository.NewBudgetRepository(db)
+	insightRepo := repository.NewInsightRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	reconciliationRepo := repository.NewReconciliationRepository(db)
+	candidateRepo := repository.NewCandidateRepository(db)
+	ledgerRepo := repository.NewLedgerRepository(db)
+	calendarRepo := repository.NewCalendarRepository(db)
 
 	// Сервисы
-	subscriptionService := service.NewSubscriptionService(subscriptionRepo)
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, exchangeRateRepo, catalogRepo)
 	userService := service.NewUserService(userRepo)
+	catalogService := service.NewCatalogService(catalogRepo)
+	budgetService := service.NewBudgetService(subscriptionService, budgetRepo, logger)
+	insightService := service.NewInsightService(subscriptionService, insightRepo)
+	paymentService := service.NewPaymentService(subscriptionService, paymentRepo)
+	reconciliationService := service.NewReconciliationService(subscriptionService, paymentService, reconciliationRepo)
+	statementService := service.NewStatementService(subscriptionService, paymentService, candidateRepo)
+	receiptService := service.NewReceiptService(subscriptionService, paymentService)
+	ledgerService := service.NewLedgerService(subscriptionService, ledgerRepo)
+	calendarService := service.NewCalendarService(subscriptionService, calendarRepo)
+
+	// Бюджеты оцениваются при создании подписки и изменении ее цены
+	subscriptionService.OnCostChange(budgetService.CheckSubscription)
+
+	// Фоновые задачи
+	go runPeriodically(time.Hour, func() {
+		changed, err := subscriptionService.SyncStatuses()
+		if err != nil {
+			logger.WithError(err).Error("Failed to sync subscription statuses")
+			return
+		}
+		logger.WithField("changed", changed).Info("Subscription statuses synced")
+	})
+	go runPeriodically(time.Hour, func() {
+		// Ошибки отдельных пользователей не мешают оценить бюджеты остальных
+		alerts, err := budgetService.EvaluateAllBudgets()
+		if err != nil {
+			logger.WithError(err).Error("Failed to evaluate budgets")
+		}
+		logger.WithField("alerts", alerts).Info("Budgets evaluated")
+	})
+	insightOptions := service.DefaultInsightOptions()
+	insightOptions.PriceJumpPercent = envFloat(logger, "INSIGHT_PRICE_JUMP_PERCENT", insightOptions.PriceJumpPercent)
+	insightOptions.HighCostFactor = envFloat(logger, "INSIGHT_HIGH_COST_FACTOR", insightOptions.HighCostFactor)
+	insightOptions.SpendDeviationPercent = envFloat(logger, "INSIGHT_SPEND_DEVIATION_PERCENT", insightOptions.SpendDeviationPercent)
+	go runPeriodically(time.Hour, func() {
+		// Ошибки отдельных пользователей не мешают проанализировать расходы остальных
+		insights, err := insightService.AnalyzeAllSpending(insightOptions)
+		if err != nil {
+			logger.WithError(err).Error("Failed to analyze spending")
+		}
+		logger.WithField("insights", insights).Info("Spending analyzed")
+	})
+	go runPeriodically(time.Hour, func() {
+		// Ошибки отдельных пользователей не мешают сверить платежи остальных
+		users, err := reconciliationService.ReconcileAll()
+		if err != nil {
+			logger.WithError(err).Error("Failed to reconcile payments")
+		}
+		logger.WithField("users", users).Info("Payments reconciled")
+	})
+
+	// Чеки из писем: встроенные правила поставщиков дополняются правилами из
+	// RECEIPT_RULES_FILE, которые проверяются первыми
+	receiptRules := receipt.DefaultRules()
+	if path := os.Getenv("RECEIPT_RULES_FILE"); path != "" {
+		rules, err := receipt.LoadRules(path)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to load receipt rules")
+		}
+		receiptRules = append(rules, receiptRules...)
+	}
+	receiptExtractor := receipt.NewExtractor(receiptRules...)
+	if dir := os.Getenv("RECEIPTS_DIR"); dir != "" {
+		// Письмо разбирается не раньше, чем через интервал обхода после последнего изменения
+		const receiptScanInterval = time.Minute
+		go runPeriodically(receiptScanInterval, func() {
+			results, err := receipt.ScanDir(dir, receiptScanInterval, receiptExtractor, func(userID uuid.UUID, r *models.Receipt) error {
+				_, err := receiptService.ImportReceipt(userID, r)
+				return err
+			})
+			if err != nil {
+				logger.WithError(err).WithField("dir", dir).Error("Failed to scan receipts directory")
+			}
+			for _, result := range results {
+				if result.Retry {
+					logger.WithError(result.Err).WithField("file", result.Path).Warn("Failed to import receipt, will retry")
+					continue
+				}
+				if result.Err != nil {
+					logger.WithError(result.Err).WithField("file", result.Path).Warn("Failed to import receipt")
+					continue
+				}
+				logger.WithField("file", result.Path).Info("Receipt imported")
+			}
+		})
+	}
 
 	// Обработчики
 	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionService, logger)
 	userHandler := handlers.NewUserHandler(userService, logger)
+	catalogHandler := handlers.NewCatalogHandler(catalogService, logger)
+	budgetHandler := handlers.NewBudgetHandler(budgetService, logger)
+	insightHandler := handlers.NewInsightHandler(insightService, logger)
+	paymentHandler := handlers.NewPaymentHandler(paymentService, logger)
+	reconciliationHandler := handlers.NewReconciliationHandler(reconciliationService, logger)
+	statementHandler := handlers.NewStatementHandler(statementService, logger)
+	receiptHandler := handlers.NewReceiptHandler(receiptService, receiptExtractor, logger)
+	ledgerHandler := handlers.NewLedgerHandler(ledgerService, logger)
+	calendarHandler := handlers.NewCalendarHandler(calendarService, logger)
 
 	// Роутер
 	router := gin.New()
@@ -97,6 +206,30 @@ func main() {
 			users.GET("/:id", userHandler.GetByID)
 			users.PUT("/:id", userHandler.Update)
 			users.DELETE("/:id", userHandler.Delete)
+			users.GET("/:id/upcoming-charges", subscriptionHandler.GetUpcomingCharges)
+			users.GET("/:id/duplicates", subscriptionHandler.ListDuplicates)
+			users.POST("/:id/budgets", budgetHandler.CreateBudget)
+			users.GET("/:id/budgets", budgetHandler.ListBudgets)
+			users.GET("/:id/budgets/:budget_id", budgetHandler.GetBudget)
+			users.PUT("/:id/budgets/:budget_id", budgetHandler.UpdateBudget)
+			users.DELETE("/:id/budgets/:budget_id", budgetHandler.DeleteBudget)
+			users.GET("/:id/budget-alerts", budgetHandler.ListBudgetAlerts)
+			users.GET("/:id/insights", insightHandler.ListInsights)
+			users.POST("/:id/payments", paymentHandler.RecordUserPayment)
+			users.GET("/:id/payments", paymentHandler.ListUserPayments)
+			users.GET("/:id/reconciliation", reconciliationHandler.GetReconciliation)
+			users.POST("/:id/reconciliation", reconciliationHandler.RunReconciliation)
+			users.POST("/:id/reconciliation/items/:item_id/resolve", reconciliationHandler.ResolveReconciliationItem)
+			users.POST("/:id/statements", statementHandler.ImportStatement)
+			users.POST("/:id/receipts", receiptHandler.ImportReceipt)
+			users.GET("/:id/ledger", ledgerHandler.ExportLedger)
+			users.GET("/:id/ledger-accounts", ledgerHandler.GetLedgerAccounts)
+			users.PUT("/:id/ledger-accounts", ledgerHandler.UpdateLedgerAccounts)
+			users.POST("/:id/calendar-feed", calendarHandler.CreateCalendarFeed)
+			users.DELETE("/:id/calendar-feed", calendarHandler.DeleteCalendarFeed)
+			users.GET("/:id/subscription-candidates", statementHandler.ListCandidates)
+			users.POST("/:id/subscription-candidates/:candidate_id/confirm", statementHandler.ConfirmCandidate)
+			users.POST("/:id/subscription-candidates/:candidate_id/dismiss", statementHandler.DismissCandidate)
 		}
 
 		// Subscriptions endpoints
@@ -104,10 +237,50 @@ func main() {
 		{
 			subscriptions.POST("", subscriptionHandler.Create)
 			subscriptions.GET("", subscriptionHandler.List)
+			subscriptions.GET("/export", subscriptionHandler.ExportSubscriptions)
 			subscriptions.GET("/:id", subscriptionHandler.GetByID)
 			subscriptions.PUT("/:id", subscriptionHandler.Update)
 			subscriptions.DELETE("/:id", subscriptionHandler.Delete)
+			subscriptions.GET("/:id/price-history", subscriptionHandler.GetPriceHistory)
+			subscriptions.GET("/:id/members", subscriptionHandler.ListMembers)
+			subscriptions.POST("/:id/members", subscriptionHandler.AddMember)
+			subscriptions.DELETE("/:id/members/:user_id", subscriptionHandler.RemoveMember)
+			subscriptions.POST("/:id/pause", subscriptionHandler.Pause)
+			subscriptions.POST("/:id/resume", subscriptionHandler.Resume)
+			subscriptions.POST("/:id/status", subscriptionHandler.ChangeStatus)
+			subscriptions.GET("/:id/status-history", subscriptionHandler.GetStatusHistory)
+			subscriptions.POST("/:id/payments", paymentHandler.RecordPayment)
+			subscriptions.GET("/:id/payments", paymentHandler.ListPayments)
+			subscriptions.DELETE("/:id/payments/:payment_id", paymentHandler.DeletePayment)
+			subscriptions.POST("/:id/cancel", subscriptionHandler.Cancel)
+			subscriptions.POST("/:id/cancel/undo", subscriptionHandler.UndoCancel)
 			subscriptions.GET("/total-cost", subscriptionHandler.GetTotalCost)
+			subscriptions.GET("/cost-breakdown", subscriptionHandler.GetCostBreakdown)
+			subscriptions.GET("/cost-breakdown/export", subscriptionHandler.ExportCostBreakdown)
+			subscriptions.GET("/forecast", subscriptionHandler.GetForecast)
+			subscriptions.GET("/expected-vs-paid", paymentHandler.GetExpectedVsPaid)
+			subscriptions.GET("/trial-ending", subscriptionHandler.ListTrialEnding)
+			subscriptions.GET("/cancellation-reasons", subscriptionHandler.GetCancellationReasons)
+		}
+
+		// Tags endpoints
+		api.GET("/tags", subscriptionHandler.ListTags)
+
+		// Calendar feed endpoint (access by the secret token from the link)
+		api.GET("/calendar/:token", calendarHandler.GetCalendar)
+
+		// Service catalog endpoints
+		services := api.Group("/services")
+		{
+			services.POST("", catalogHandler.CreateService)
+			services.GET("", catalogHandler.ListServices)
+			services.GET("/:id", catalogHandler.GetService)
+			services.PUT("/:id", catalogHandler.UpdateService)
+			services.DELETE("/:id", catalogHandler.DeleteService)
+			services.POST("/:id/plans", catalogHandler.CreatePlan)
+			services.GET("/:id/plans", catalogHandler.ListPlans)
+			services.PUT("/:id/plans/:plan_id", catalogHandler.UpdatePlan)
+			services.DELETE("/:id/plans/:plan_id", catalogHandler.DeletePlan)
 		}
 	}
 
@@ -118,3 +291,29 @@ func main() {
 		logger.WithError(err).Fatal("Failed to start server")
 	}
 }
+
+// runPeriodically выполняет job сразу и затем с интервалом interval
+func runPeriodically(interval time.Duration, job func()) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		job()
+		<-ticker.C
+	}
+}
+
+// envFloat читает положительное число из переменной окружения name;
+// если переменная не задана или некорректна, возвращает def
+func envFloat(logger *logrus.Logger, name string, def float64) float64 {
+	s := os.Getenv(name)
+	if s == "" {
+		return def
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value <= 0 {
+		logger.WithField(name, s).Warn("Invalid value, using default")
+		return def
+	}
+	return value
+}
@@ -0,0 +1,6 @@
+-- Удаление периодичности списаний
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS billing_interval,
+    DROP COLUMN IF EXISTS billing_period;
+
+COMMENT ON COLUMN subscriptions.price IS 'Цена подписки в копейках/центах';
@@ -0,0 +1,11 @@
+-- Периодичность списаний по подписке
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS billing_period VARCHAR(16) NOT NULL DEFAULT 'month'
+        CHECK (billing_period IN ('week', 'month', 'quarter', 'year')),
+    ADD COLUMN IF NOT EXISTS billing_interval INTEGER NOT NULL DEFAULT 1
+        CHECK (billing_interval > 0);
+
+-- Комментарии для документации
+COMMENT ON COLUMN subscriptions.price IS 'Цена за один расчетный период в копейках/центах';
+COMMENT ON COLUMN subscriptions.billing_period IS 'Единица расчетного периода: week, month, quarter, year';
+COMMENT ON COLUMN subscriptions.billing_interval IS 'Количество единиц в расчетном периоде (например, 6 month = раз в полгода)';
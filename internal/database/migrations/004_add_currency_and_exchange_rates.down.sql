@@ -0,0 +1,4 @@
+-- Удаление курсов валют и валюты подписок
+DROP TABLE IF EXISTS exchange_rates CASCADE;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS currency;
@@ -0,0 +1,23 @@
+-- Валюта цены подписки (ISO 4217)
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'RUB'
+        CHECK (currency ~ '^[A-Z]{3}$');
+
+-- Создание таблицы курсов валют
+CREATE TABLE IF NOT EXISTS exchange_rates (
+    base_currency CHAR(3) NOT NULL CHECK (base_currency ~ '^[A-Z]{3}$'),
+    quote_currency CHAR(3) NOT NULL CHECK (quote_currency ~ '^[A-Z]{3}$'),
+    month DATE NOT NULL CHECK (EXTRACT(DAY FROM month) = 1),
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    PRIMARY KEY (base_currency, quote_currency, month)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exchange_rates_month ON exchange_rates(month);
+
+-- Комментарии для документации
+COMMENT ON COLUMN subscriptions.currency IS 'Валюта цены подписки (ISO 4217)';
+COMMENT ON TABLE exchange_rates IS 'Курсы валют по месяцам';
+COMMENT ON COLUMN exchange_rates.month IS 'Первый день месяца, к которому относится курс';
+COMMENT ON COLUMN exchange_rates.rate IS 'Количество quote_currency за одну единицу base_currency';
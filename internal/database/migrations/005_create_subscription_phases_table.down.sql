@@ -0,0 +1,2 @@
+-- Удаление таблицы фаз подписки
+DROP TABLE IF EXISTS subscription_phases CASCADE;
@@ -0,0 +1,22 @@
+-- Создание таблицы фаз подписки (пробный период, вводная цена)
+CREATE TABLE IF NOT EXISTS subscription_phases (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL,
+    phase_type VARCHAR(16) NOT NULL CHECK (phase_type IN ('trial', 'intro')),
+    price INTEGER NOT NULL CHECK (price >= 0),
+    start_date VARCHAR(7) NOT NULL CHECK (start_date ~ '^\d{2}-\d{4}$'),
+    end_date VARCHAR(7) NOT NULL CHECK (end_date ~ '^\d{2}-\d{4}$'),
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_subscription_phases_subscription_id
+        FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_subscription_phases_subscription_id ON subscription_phases(subscription_id);
+
+-- Комментарии для документации
+COMMENT ON TABLE subscription_phases IS 'Фазы подписки с особой ценой: бесплатный пробный период и вводная цена';
+COMMENT ON COLUMN subscription_phases.price IS 'Цена за расчетный период в этой фазе (0 для пробного периода)';
+COMMENT ON COLUMN subscription_phases.start_date IS 'Первый месяц фазы в формате MM-YYYY';
+COMMENT ON COLUMN subscription_phases.end_date IS 'Последний месяц фазы в формате MM-YYYY';
@@ -0,0 +1,4 @@
+-- Удаление таблицы истории цен подписок
+DROP TABLE IF EXISTS subscription_prices CASCADE;
+
+COMMENT ON COLUMN subscriptions.price IS 'Цена за один расчетный период в копейках/центах';
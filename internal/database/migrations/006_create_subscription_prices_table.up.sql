@@ -0,0 +1,24 @@
+-- Создание таблицы истории цен подписок
+CREATE TABLE IF NOT EXISTS subscription_prices (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL,
+    price INTEGER NOT NULL CHECK (price > 0),
+    effective_date VARCHAR(7) NOT NULL CHECK (effective_date ~ '^\d{2}-\d{4}$'),
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_subscription_prices_subscription_id
+        FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE,
+    CONSTRAINT uq_subscription_prices_effective_date
+        UNIQUE (subscription_id, effective_date)
+);
+
+-- Начальная цена существующих подписок действует с месяца начала
+INSERT INTO subscription_prices (subscription_id, price, effective_date)
+SELECT id, price, start_date FROM subscriptions
+ON CONFLICT (subscription_id, effective_date) DO NOTHING;
+
+-- Комментарии для документации
+COMMENT ON TABLE subscription_prices IS 'История цен подписок';
+COMMENT ON COLUMN subscription_prices.price IS 'Цена за расчетный период в копейках/центах';
+COMMENT ON COLUMN subscription_prices.effective_date IS 'Месяц, с которого действует цена, в формате MM-YYYY';
+COMMENT ON COLUMN subscriptions.price IS 'Последняя установленная цена за расчетный период; история — в subscription_prices';
@@ -0,0 +1,5 @@
+-- Удаление каталога сервисов
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS plan_id;
+
+DROP TABLE IF EXISTS plans CASCADE;
+DROP TABLE IF EXISTS services CASCADE;
@@ -0,0 +1,100 @@
+-- Создание таблицы сервисов каталога
+CREATE TABLE IF NOT EXISTS services (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    vendor VARCHAR(255),
+    category VARCHAR(100),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_services_name ON services(lower(name));
+CREATE INDEX IF NOT EXISTS idx_services_category ON services(category) WHERE category IS NOT NULL;
+
+-- Создание таблицы тарифных планов
+CREATE TABLE IF NOT EXISTS plans (
+    id SERIAL PRIMARY KEY,
+    service_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    price INTEGER NOT NULL CHECK (price > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$'),
+    billing_period VARCHAR(16) NOT NULL DEFAULT 'month'
+        CHECK (billing_period IN ('week', 'month', 'quarter', 'year')),
+    billing_interval INTEGER NOT NULL DEFAULT 1 CHECK (billing_interval > 0),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_plans_service_id
+        FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_plans_service_name ON plans(service_id, lower(name));
+
+-- Ссылка подписки на тарифный план
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS plan_id INTEGER
+        CONSTRAINT fk_subscriptions_plan_id REFERENCES plans(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_plan_id ON subscriptions(plan_id) WHERE plan_id IS NOT NULL;
+
+-- Наполнение каталога из существующих подписок.
+-- Названия нормализуются (регистр, пробелы); название, которое является началом
+-- другого по целым словам, считается сервисом, а остаток — тарифом:
+-- "Netflix", "NETFLIX" и "netflix premium" -> сервис "Netflix", тарифы "Standard" и "Premium".
+CREATE TEMP TABLE catalog_match AS
+WITH names AS (
+    SELECT id, trim(service_name) AS service_name,
+           lower(regexp_replace(trim(service_name), '\s+', ' ', 'g')) AS norm,
+           price, currency, billing_period, billing_interval, created_at
+    FROM subscriptions
+),
+keys AS (
+    SELECT DISTINCT norm FROM names
+),
+roots AS (
+    SELECT k.norm,
+           (SELECT r.norm FROM keys r
+            WHERE k.norm = r.norm OR left(k.norm, length(r.norm) + 1) = r.norm || ' '
+            ORDER BY length(r.norm)
+            LIMIT 1) AS root
+    FROM keys k
+)
+SELECT n.id AS subscription_id, n.service_name, r.root,
+       COALESCE(NULLIF(trim(substr(n.norm, length(r.root) + 1)), ''), 'standard') AS plan_key,
+       n.norm = r.root AS is_root,
+       n.price, n.currency, n.billing_period, n.billing_interval, n.created_at
+FROM names n
+JOIN roots r ON r.norm = n.norm;
+
+-- Сервис называется так, как его впервые записали без уточнения тарифа
+INSERT INTO services (name)
+SELECT DISTINCT ON (root) service_name
+FROM catalog_match
+WHERE is_root
+ORDER BY root, created_at
+ON CONFLICT DO NOTHING;
+
+-- Цена и периодичность тарифа — по последней подписке на него
+INSERT INTO plans (service_id, name, price, currency, billing_period, billing_interval)
+SELECT DISTINCT ON (m.root, m.plan_key)
+       s.id, initcap(m.plan_key), m.price, m.currency, m.billing_period, m.billing_interval
+FROM catalog_match m
+JOIN services s ON lower(regexp_replace(trim(s.name), '\s+', ' ', 'g')) = m.root
+ORDER BY m.root, m.plan_key, m.created_at DESC
+ON CONFLICT DO NOTHING;
+
+UPDATE subscriptions sub
+SET plan_id = p.id
+FROM catalog_match m
+JOIN services s ON lower(regexp_replace(trim(s.name), '\s+', ' ', 'g')) = m.root
+JOIN plans p ON p.service_id = s.id AND lower(p.name) = m.plan_key
+WHERE sub.id = m.subscription_id AND sub.plan_id IS NULL;
+
+DROP TABLE catalog_match;
+
+-- Комментарии для документации
+COMMENT ON TABLE services IS 'Каталог сервисов';
+COMMENT ON COLUMN services.category IS 'Категория сервиса (например, video, music, cloud)';
+COMMENT ON TABLE plans IS 'Тарифные планы сервисов каталога';
+COMMENT ON COLUMN plans.price IS 'Цена за расчетный период в копейках/центах';
+COMMENT ON COLUMN subscriptions.plan_id IS 'Тарифный план каталога (NULL = подписка без привязки к каталогу)';
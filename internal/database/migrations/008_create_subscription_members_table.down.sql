@@ -0,0 +1,2 @@
+-- Удаление таблицы участников совместных подписок
+DROP TABLE IF EXISTS subscription_members CASCADE;
@@ -0,0 +1,28 @@
+-- Создание таблицы участников совместных подписок
+CREATE TABLE IF NOT EXISTS subscription_members (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL,
+    user_id UUID NOT NULL,
+    share_type VARCHAR(16) NOT NULL CHECK (share_type IN ('percent', 'fixed')),
+    share_percent NUMERIC(5, 2) CHECK (share_percent > 0 AND share_percent <= 100),
+    share_amount INTEGER CHECK (share_amount > 0),
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_subscription_members_subscription_id
+        FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_subscription_members_user_id
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT uq_subscription_members_user UNIQUE (subscription_id, user_id),
+    CONSTRAINT chk_subscription_members_share CHECK (
+        (share_type = 'percent' AND share_percent IS NOT NULL AND share_amount IS NULL) OR
+        (share_type = 'fixed' AND share_amount IS NOT NULL AND share_percent IS NULL)
+    )
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_subscription_members_user_id ON subscription_members(user_id);
+
+-- Комментарии для документации
+COMMENT ON TABLE subscription_members IS 'Участники совместной подписки; остаток цены оплачивает владелец (subscriptions.user_id)';
+COMMENT ON COLUMN subscription_members.share_percent IS 'Доля участника в процентах от каждого списания';
+COMMENT ON COLUMN subscription_members.share_amount IS 'Фиксированная сумма участника с каждого списания в валюте подписки';
@@ -0,0 +1,2 @@
+-- Удаление таблицы пауз подписок
+DROP TABLE IF EXISTS subscription_pauses CASCADE;
@@ -0,0 +1,23 @@
+-- Создание таблицы пауз подписок
+CREATE TABLE IF NOT EXISTS subscription_pauses (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL,
+    start_date VARCHAR(7) NOT NULL CHECK (start_date ~ '^\d{2}-\d{4}$'),
+    end_date VARCHAR(7) CHECK (end_date IS NULL OR end_date ~ '^\d{2}-\d{4}$'),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_subscription_pauses_subscription_id
+        FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_subscription_pauses_subscription_id ON subscription_pauses(subscription_id);
+-- У подписки может быть только одна незавершенная пауза
+CREATE UNIQUE INDEX IF NOT EXISTS uq_subscription_pauses_open
+    ON subscription_pauses(subscription_id) WHERE end_date IS NULL;
+
+-- Комментарии для документации
+COMMENT ON TABLE subscription_pauses IS 'Паузы подписок: в эти месяцы подписка не активна и не оплачивается';
+COMMENT ON COLUMN subscription_pauses.start_date IS 'Первый месяц паузы в формате MM-YYYY';
+COMMENT ON COLUMN subscription_pauses.end_date IS 'Последний месяц паузы в формате MM-YYYY (NULL = подписка еще на паузе)';
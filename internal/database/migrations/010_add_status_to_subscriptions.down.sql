@@ -0,0 +1,6 @@
+-- Удаление статуса подписок
+DROP TABLE IF EXISTS subscription_status_transitions CASCADE;
+DROP INDEX IF EXISTS idx_subscriptions_status;
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS status_changed_at,
+    DROP COLUMN IF EXISTS status;
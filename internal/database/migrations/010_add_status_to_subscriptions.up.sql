@@ -0,0 +1,51 @@
+-- Явный статус подписки
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS status VARCHAR(32) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('trialing', 'active', 'paused', 'pending_cancellation', 'cancelled', 'expired')),
+    ADD COLUMN IF NOT EXISTS status_changed_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+-- Журнал переходов между статусами
+CREATE TABLE IF NOT EXISTS subscription_status_transitions (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL,
+    from_status VARCHAR(32),
+    to_status VARCHAR(32) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_subscription_status_transitions_subscription_id
+        FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+-- Статусы существующих подписок по их датам, паузам и пробным периодам
+UPDATE subscriptions SET status = 'expired'
+WHERE end_date IS NOT NULL AND to_date(end_date, 'MM-YYYY') < date_trunc('month', NOW());
+
+UPDATE subscriptions SET status = 'paused'
+WHERE status = 'active' AND EXISTS (
+    SELECT 1 FROM subscription_pauses ps
+    WHERE ps.subscription_id = subscriptions.id
+        AND to_date(ps.start_date, 'MM-YYYY') <= date_trunc('month', NOW())
+        AND (ps.end_date IS NULL OR to_date(ps.end_date, 'MM-YYYY') >= date_trunc('month', NOW())));
+
+UPDATE subscriptions SET status = 'trialing'
+WHERE status = 'active' AND EXISTS (
+    SELECT 1 FROM subscription_phases ph
+    WHERE ph.subscription_id = subscriptions.id AND ph.phase_type = 'trial'
+        AND to_date(ph.start_date, 'MM-YYYY') <= date_trunc('month', NOW())
+        AND to_date(ph.end_date, 'MM-YYYY') >= date_trunc('month', NOW()));
+
+INSERT INTO subscription_status_transitions (subscription_id, from_status, to_status, reason)
+SELECT id, NULL, status, 'initial status' FROM subscriptions;
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_subscriptions_status ON subscriptions(status);
+CREATE INDEX IF NOT EXISTS idx_subscription_status_transitions_subscription_id
+    ON subscription_status_transitions(subscription_id);
+
+-- Комментарии для документации
+COMMENT ON COLUMN subscriptions.status IS 'Статус: trialing, active, paused, pending_cancellation, cancelled, expired';
+COMMENT ON COLUMN subscriptions.status_changed_at IS 'Время последней смены статуса';
+COMMENT ON TABLE subscription_status_transitions IS 'Журнал переходов подписок между статусами';
+COMMENT ON COLUMN subscription_status_transitions.from_status IS 'Предыдущий статус (NULL — подписка создана)';
+COMMENT ON COLUMN subscription_status_transitions.reason IS 'Причина перехода';
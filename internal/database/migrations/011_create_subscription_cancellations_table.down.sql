@@ -0,0 +1,2 @@
+-- Удаление таблицы отмен подписок
+DROP TABLE IF EXISTS subscription_cancellations CASCADE;
@@ -0,0 +1,31 @@
+-- Создание таблицы отмен подписок
+CREATE TABLE IF NOT EXISTS subscription_cancellations (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL,
+    mode VARCHAR(16) NOT NULL CHECK (mode IN ('immediate', 'at_period_end')),
+    reason_code VARCHAR(32) CHECK (reason_code IN (
+        'too_expensive', 'not_using', 'switched_service', 'technical_issues', 'temporary', 'other')),
+    comment TEXT,
+    end_date VARCHAR(7) NOT NULL CHECK (end_date ~ '^\d{2}-\d{4}$'),
+    previous_end_date VARCHAR(7) CHECK (previous_end_date IS NULL OR previous_end_date ~ '^\d{2}-\d{4}$'),
+    previous_status VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    revoked_at TIMESTAMP,
+
+    CONSTRAINT fk_subscription_cancellations_subscription_id
+        FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_subscription_cancellations_subscription_id
+    ON subscription_cancellations(subscription_id);
+CREATE INDEX IF NOT EXISTS idx_subscription_cancellations_created_at
+    ON subscription_cancellations(created_at);
+
+-- Комментарии для документации
+COMMENT ON TABLE subscription_cancellations IS 'Отмены подписок с причинами; отмену можно отозвать в течение окна отмены';
+COMMENT ON COLUMN subscription_cancellations.mode IS 'immediate — подписка заканчивается текущим месяцем, at_period_end — в конце оплаченного периода';
+COMMENT ON COLUMN subscription_cancellations.end_date IS 'Последний месяц подписки после отмены в формате MM-YYYY';
+COMMENT ON COLUMN subscription_cancellations.previous_end_date IS 'end_date до отмены; восстанавливается при отзыве';
+COMMENT ON COLUMN subscription_cancellations.previous_status IS 'Статус до отмены; восстанавливается при отзыве';
+COMMENT ON COLUMN subscription_cancellations.revoked_at IS 'Время отзыва отмены (NULL — отмена действует)';
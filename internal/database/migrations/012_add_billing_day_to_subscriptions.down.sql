@@ -0,0 +1,3 @@
+-- Удаление дня списания
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS billing_day;
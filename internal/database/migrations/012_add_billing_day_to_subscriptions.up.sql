@@ -0,0 +1,7 @@
+-- День месяца, в который происходят списания по подписке
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS billing_day INTEGER NOT NULL DEFAULT 1
+        CHECK (billing_day BETWEEN 1 AND 31);
+
+-- Комментарии для документации
+COMMENT ON COLUMN subscriptions.billing_day IS 'День месяца первого списания; в коротких месяцах — последний день месяца';
@@ -0,0 +1,3 @@
+-- Удаление бюджетов и оповещений
+DROP TABLE IF EXISTS budget_alerts CASCADE;
+DROP TABLE IF EXISTS budgets CASCADE;
@@ -0,0 +1,46 @@
+-- Создание таблицы бюджетов пользователей
+CREATE TABLE IF NOT EXISTS budgets (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    period VARCHAR(8) NOT NULL CHECK (period IN ('month', 'year')),
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'RUB' CHECK (currency ~ '^[A-Z]{3}$'),
+    scope VARCHAR(16) NOT NULL DEFAULT 'all' CHECK (scope IN ('all', 'category', 'service')),
+    category VARCHAR(100),
+    service_name VARCHAR(255),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_budgets_user_id
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT chk_budgets_category CHECK ((scope = 'category') = (category IS NOT NULL)),
+    CONSTRAINT chk_budgets_service_name CHECK ((scope = 'service') = (service_name IS NOT NULL))
+);
+
+-- Создание таблицы оповещений по бюджетам
+CREATE TABLE IF NOT EXISTS budget_alerts (
+    id SERIAL PRIMARY KEY,
+    budget_id INTEGER NOT NULL,
+    period_start VARCHAR(7) NOT NULL CHECK (period_start ~ '^\d{2}-\d{4}$'),
+    threshold INTEGER NOT NULL CHECK (threshold > 0),
+    spent INTEGER NOT NULL,
+    amount INTEGER NOT NULL,
+    currency CHAR(3) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_budget_alerts_budget_id
+        FOREIGN KEY (budget_id) REFERENCES budgets(id) ON DELETE CASCADE,
+    CONSTRAINT uq_budget_alerts_period_threshold UNIQUE (budget_id, period_start, threshold)
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_budgets_user_id ON budgets(user_id);
+CREATE INDEX IF NOT EXISTS idx_budget_alerts_created_at ON budget_alerts(created_at);
+
+-- Комментарии для документации
+COMMENT ON TABLE budgets IS 'Лимиты расходов пользователей на подписки за месяц или календарный год';
+COMMENT ON COLUMN budgets.amount IS 'Лимит в копейках/центах';
+COMMENT ON COLUMN budgets.scope IS 'all — все расходы, category — подписки одной категории, service — подписки одного сервиса';
+COMMENT ON TABLE budget_alerts IS 'Оповещения о достижении порога бюджета; не более одного на порог за период';
+COMMENT ON COLUMN budget_alerts.period_start IS 'Первый месяц периода бюджета в формате MM-YYYY';
+COMMENT ON COLUMN budget_alerts.threshold IS 'Достигнутый порог в процентах лимита';
@@ -0,0 +1,5 @@
+-- Удаление тегов и категории подписок
+DROP TABLE IF EXISTS subscription_tags CASCADE;
+DROP INDEX IF EXISTS idx_subscriptions_category;
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS category;
@@ -0,0 +1,21 @@
+-- Категория подписки
+ALTER TABLE subscriptions
+    ADD COLUMN IF NOT EXISTS category VARCHAR(100);
+
+-- Создание таблицы тегов подписок
+CREATE TABLE IF NOT EXISTS subscription_tags (
+    subscription_id INTEGER NOT NULL,
+    tag VARCHAR(50) NOT NULL CHECK (tag <> '' AND tag = lower(tag)),
+
+    PRIMARY KEY (subscription_id, tag),
+    CONSTRAINT fk_subscription_tags_subscription_id
+        FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_subscriptions_category ON subscriptions(category) WHERE category IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_subscription_tags_tag ON subscription_tags(tag);
+
+-- Комментарии для документации
+COMMENT ON COLUMN subscriptions.category IS 'Категория подписки; если не задана, используется категория сервиса каталога';
+COMMENT ON TABLE subscription_tags IS 'Теги подписок (например, work, entertainment, tax-deductible) в нижнем регистре';
@@ -0,0 +1,2 @@
+-- Удаление находок анализа расходов
+DROP TABLE IF EXISTS insights CASCADE;
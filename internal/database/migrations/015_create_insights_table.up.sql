@@ -0,0 +1,27 @@
+-- Создание таблицы находок анализа расходов
+CREATE TABLE IF NOT EXISTS insights (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    kind VARCHAR(32) NOT NULL CHECK (kind IN ('price_jump', 'new_high_cost', 'spend_deviation')),
+    severity VARCHAR(16) NOT NULL CHECK (severity IN ('info', 'warning', 'critical')),
+    subscription_id INTEGER,
+    month VARCHAR(7) NOT NULL CHECK (month ~ '^\d{2}-\d{4}$'),
+    explanation TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_insights_user_id
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_insights_subscription_id
+        FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+-- Создание индексов
+CREATE UNIQUE INDEX IF NOT EXISTS uq_insights_finding
+    ON insights(user_id, kind, COALESCE(subscription_id, 0), month);
+CREATE INDEX IF NOT EXISTS idx_insights_user_created_at ON insights(user_id, created_at);
+
+-- Комментарии для документации
+COMMENT ON TABLE insights IS 'Необычные события в расходах: рост цены, новая дорогая подписка, отклонение расходов месяца от среднего';
+COMMENT ON COLUMN insights.subscription_id IS 'Подписка, к которой относится находка (NULL — расходы пользователя в целом)';
+COMMENT ON COLUMN insights.month IS 'Месяц события в формате MM-YYYY';
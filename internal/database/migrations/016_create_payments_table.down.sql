@@ -0,0 +1,2 @@
+-- Удаление таблицы платежей
+DROP TABLE IF EXISTS payments CASCADE;
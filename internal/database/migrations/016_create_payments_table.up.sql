@@ -0,0 +1,30 @@
+-- Создание таблицы фактических платежей по подпискам
+CREATE TABLE IF NOT EXISTS payments (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL,
+    user_id UUID NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    paid_date DATE NOT NULL,
+    method VARCHAR(50),
+    external_reference VARCHAR(255),
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_payments_subscription_id
+        FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_payments_user_id
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_payments_subscription_paid_date ON payments(subscription_id, paid_date);
+CREATE INDEX IF NOT EXISTS idx_payments_user_paid_date ON payments(user_id, paid_date);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_payments_external_reference
+    ON payments(subscription_id, external_reference) WHERE external_reference IS NOT NULL;
+
+-- Комментарии для документации
+COMMENT ON TABLE payments IS 'Фактически уплаченные суммы по подпискам';
+COMMENT ON COLUMN payments.user_id IS 'Плательщик: владелец или участник подписки';
+COMMENT ON COLUMN payments.amount IS 'Сумма в копейках/центах';
+COMMENT ON COLUMN payments.method IS 'Способ оплаты, например card или paypal';
+COMMENT ON COLUMN payments.external_reference IS 'Идентификатор платежа у банка или платежной системы; уникален в пределах подписки';
@@ -0,0 +1,11 @@
+-- Удаление расхождений сверки
+DROP TABLE IF EXISTS reconciliation_items CASCADE;
+
+-- Возврат платежей к обязательной подписке
+DROP INDEX IF EXISTS idx_payments_user_unlinked;
+DROP INDEX IF EXISTS uq_payments_user_external_reference;
+DELETE FROM payments WHERE subscription_id IS NULL;
+ALTER TABLE payments DROP COLUMN IF EXISTS description;
+ALTER TABLE payments ALTER COLUMN subscription_id SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_payments_external_reference
+    ON payments(subscription_id, external_reference) WHERE external_reference IS NOT NULL;
@@ -0,0 +1,48 @@
+-- Платежи без подписки: списания, которые не удалось сопоставить с подпиской
+ALTER TABLE payments ALTER COLUMN subscription_id DROP NOT NULL;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS description VARCHAR(255);
+
+-- Идентификатор платежа уникален в пределах плательщика, в том числе для платежей без подписки
+DROP INDEX IF EXISTS uq_payments_external_reference;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_payments_user_external_reference
+    ON payments(user_id, external_reference) WHERE external_reference IS NOT NULL;
+
+-- Создание таблицы расхождений сверки
+CREATE TABLE IF NOT EXISTS reconciliation_items (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    month VARCHAR(7) NOT NULL CHECK (month ~ '^\d{2}-\d{4}$'),
+    kind VARCHAR(32) NOT NULL
+        CHECK (kind IN ('missing_charge', 'cancelled_charge', 'amount_mismatch', 'unknown_recurring')),
+    status VARCHAR(16) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+    subscription_id INTEGER,
+    payment_id INTEGER,
+    expected INTEGER NOT NULL DEFAULT 0,
+    actual INTEGER NOT NULL DEFAULT 0,
+    currency CHAR(3) NOT NULL,
+    description TEXT NOT NULL,
+    note TEXT,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_reconciliation_items_user_id
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_reconciliation_items_subscription_id
+        FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE,
+    CONSTRAINT fk_reconciliation_items_payment_id
+        FOREIGN KEY (payment_id) REFERENCES payments(id) ON DELETE CASCADE
+);
+
+-- Создание индексов
+CREATE INDEX IF NOT EXISTS idx_payments_user_unlinked ON payments(user_id, paid_date) WHERE subscription_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_reconciliation_items_finding
+    ON reconciliation_items(user_id, month, kind, COALESCE(subscription_id, 0), COALESCE(payment_id, 0));
+
+-- Комментарии для документации
+COMMENT ON COLUMN payments.subscription_id IS 'Подписка платежа (NULL — списание не сопоставлено с подпиской)';
+COMMENT ON COLUMN payments.description IS 'Описание списания из выписки, например название получателя';
+COMMENT ON TABLE reconciliation_items IS 'Расхождения между ожидаемыми списаниями подписок и записанными платежами за месяц';
+COMMENT ON COLUMN reconciliation_items.kind IS 'missing_charge — списание не найдено, cancelled_charge — списание по неактивной подписке, amount_mismatch — сумма не совпадает, unknown_recurring — повторяющееся списание без подписки';
+COMMENT ON COLUMN reconciliation_items.expected IS 'Ожидаемая сумма в копейках/центах';
+COMMENT ON COLUMN reconciliation_items.actual IS 'Уплаченная сумма в копейках/центах';
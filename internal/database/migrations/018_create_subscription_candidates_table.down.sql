@@ -0,0 +1,2 @@
+-- Удаление предложенных подписок
+DROP TABLE IF EXISTS subscription_candidates CASCADE;
@@ -0,0 +1,32 @@
+-- Создание таблицы подписок, предложенных по банковским выпискам
+CREATE TABLE IF NOT EXISTS subscription_candidates (
+    id SERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    merchant VARCHAR(255) NOT NULL,
+    service_name VARCHAR(255) NOT NULL,
+    amount INTEGER NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    billing_period VARCHAR(16) NOT NULL CHECK (billing_period IN ('week', 'month', 'quarter', 'year')),
+    billing_interval INTEGER NOT NULL CHECK (billing_interval > 0),
+    first_charge DATE NOT NULL,
+    last_charge DATE NOT NULL,
+    charges INTEGER NOT NULL CHECK (charges > 0),
+    confidence NUMERIC(3, 2) NOT NULL CHECK (confidence BETWEEN 0 AND 1),
+    status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'dismissed')),
+    subscription_id INTEGER,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_subscription_candidates_user_id
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_subscription_candidates_subscription_id
+        FOREIGN KEY (subscription_id) REFERENCES subscriptions(id) ON DELETE SET NULL,
+    CONSTRAINT uq_subscription_candidates_merchant UNIQUE (user_id, merchant, currency)
+);
+
+-- Комментарии для документации
+COMMENT ON TABLE subscription_candidates IS 'Повторяющиеся списания без подписки, найденные в выписках; пользователь подтверждает или отклоняет их';
+COMMENT ON COLUMN subscription_candidates.merchant IS 'Получатель: описание списания без цифр и знаков препинания';
+COMMENT ON COLUMN subscription_candidates.amount IS 'Сумма последнего списания в копейках/центах';
+COMMENT ON COLUMN subscription_candidates.confidence IS 'Доля списаний, совпавших с кандидатом по сумме и интервалу';
+COMMENT ON COLUMN subscription_candidates.status IS 'pending — ждет решения, confirmed — создана подписка, dismissed — отклонен';
@@ -0,0 +1,3 @@
+-- Удаление счетов журнала
+DROP TABLE IF EXISTS ledger_category_accounts CASCADE;
+DROP TABLE IF EXISTS ledger_settings CASCADE;
@@ -0,0 +1,27 @@
+-- Счета для выгрузки списаний по подпискам в бухгалтерские журналы (beancount, hledger, OFX)
+CREATE TABLE IF NOT EXISTS ledger_settings (
+    user_id UUID PRIMARY KEY,
+    expense_account VARCHAR(255) NOT NULL,
+    payment_account VARCHAR(255) NOT NULL,
+    updated_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_ledger_settings_user_id
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS ledger_category_accounts (
+    user_id UUID NOT NULL,
+    category VARCHAR(100) NOT NULL,
+    account VARCHAR(255) NOT NULL,
+
+    PRIMARY KEY (user_id, category),
+    CONSTRAINT fk_ledger_category_accounts_user_id
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Комментарии для документации
+COMMENT ON TABLE ledger_settings IS 'Счета журнала пользователя по умолчанию';
+COMMENT ON COLUMN ledger_settings.expense_account IS 'Счет расходов для подписок без категории; для категорий без своего счета — родительский счет';
+COMMENT ON COLUMN ledger_settings.payment_account IS 'Счет, с которого оплачиваются подписки';
+COMMENT ON TABLE ledger_category_accounts IS 'Счета расходов по категориям подписок';
+COMMENT ON COLUMN ledger_category_accounts.category IS 'Категория подписки в нижнем регистре';
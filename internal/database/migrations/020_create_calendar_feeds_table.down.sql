@@ -0,0 +1,2 @@
+-- Удаление календарных подписок
+DROP TABLE IF EXISTS calendar_feeds CASCADE;
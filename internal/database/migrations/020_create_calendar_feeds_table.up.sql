@@ -0,0 +1,14 @@
+-- Создание таблицы календарных подписок (iCalendar) пользователей
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id UUID PRIMARY KEY,
+    token_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+
+    CONSTRAINT fk_calendar_feeds_user_id
+        FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT uq_calendar_feeds_token_hash UNIQUE (token_hash)
+);
+
+-- Комментарии для документации
+COMMENT ON TABLE calendar_feeds IS 'Секретные ссылки на календарь списаний пользователя; у пользователя одна действующая ссылка';
+COMMENT ON COLUMN calendar_feeds.token_hash IS 'SHA-256 токена из ссылки в hex; сам токен не хранится';
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+// newCSVWriter начинает файл с BOM, чтобы Excel распознал UTF-8
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+		return nil, err
+	}
+	return &csvWriter{w: csv.NewWriter(w)}, nil
+}
+
+func (c *csvWriter) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			record[i] = escapeFormula(v)
+		case int:
+			record[i] = strconv.Itoa(v)
+		case Amount:
+			record[i] = formatAmount(v)
+		default:
+			return fmt.Errorf("unsupported cell type %T", value)
+		}
+	}
+	return c.w.Write(record)
+}
+
+// escapeFormula не дает электронной таблице выполнить текст ячейки как формулу:
+// строки, начинающиеся с =, +, -, @, табуляции или CR, предваряются апострофом.
+// Числа пишутся без экранирования, поэтому отрицательные суммы остаются числами.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
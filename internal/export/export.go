@@ -0,0 +1,65 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format — формат выгрузки таблицы
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+// ParseFormat разбирает формат выгрузки; пустая строка — CSV
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return CSV, nil
+	case CSV, XLSX:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unsupported format %q, expected csv or xlsx", s)
+	}
+}
+
+func (f Format) ContentType() string {
+	if f == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Amount — денежная сумма в копейках/центах; выгружается числом с двумя знаками
+// после точки
+type Amount int
+
+// Writer построчно пишет таблицу. Значения ячеек — string, int, Amount или nil
+// для пустой ячейки. Close дописывает файл и должен быть вызван после последней строки.
+type Writer interface {
+	WriteRow(values ...interface{}) error
+	Close() error
+}
+
+// NewWriter создает Writer выбранного формата; sheet — название листа XLSX
+func NewWriter(w io.Writer, format Format, sheet string) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w)
+	case XLSX:
+		return newXLSXWriter(w, sheet)
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func formatAmount(a Amount) string {
+	sign := ""
+	if a < 0 {
+		sign, a = "-", -a
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, a/100, a%100)
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"go-dev/internal/models"
+	"go-dev/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type BudgetHandler struct {
+	service *service.BudgetService
+	logger  *logrus.Logger
+}
+
+func NewBudgetHandler(service *service.BudgetService, logger *logrus.Logger) *BudgetHandler {
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	return &BudgetHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateBudget создает бюджет пользователя
+// @Summary Создать бюджет
+// @Description Создает месячный или годовой лимит расходов на все подписки, одну категорию или один сервис. При достижении 80% и 100% лимита создаются оповещения.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param budget body models.CreateBudgetRequest true "Данные бюджета"
+// @Success 201 {object} models.Budget
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/budgets [post]
+func (h *BudgetHandler) CreateBudget(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	var req models.CreateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"period":  req.Period,
+		"amount":  req.Amount,
+		"scope":   req.Scope,
+	}).Info("Creating budget")
+
+	budget, err := h.service.CreateBudget(userID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to create budget")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to create budget"))
+		return
+	}
+
+	h.logger.WithField("budget_id", budget.ID).Info("Budget created successfully")
+	c.JSON(http.StatusCreated, budget)
+}
+
+// ListBudgets возвращает бюджеты пользователя
+// @Summary Получить бюджеты пользователя
+// @Description Возвращает бюджеты пользователя с расходами за текущий период, включая еще не наступившие списания периода
+// @Tags budgets
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Success 200 {array} models.BudgetStatus
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/budgets [get]
+func (h *BudgetHandler) ListBudgets(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	budgets, err := h.service.ListBudgets(userID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to list budgets")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to list budgets"))
+		return
+	}
+
+	c.JSON(http.StatusOK, budgets)
+}
+
+// GetBudget возвращает бюджет пользователя
+// @Summary Получить бюджет
+// @Description Возвращает бюджет с расходами за текущий период
+// @Tags budgets
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param budget_id path int true "ID бюджета"
+// @Success 200 {object} models.BudgetStatus
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/budgets/{budget_id} [get]
+func (h *BudgetHandler) GetBudget(c *gin.Context) {
+	userID, budgetID, ok := parseBudgetPath(c)
+	if !ok {
+		return
+	}
+
+	budget, err := h.service.GetBudget(userID, budgetID)
+	if err != nil {
+		h.logger.WithError(err).WithField("budget_id", budgetID).Error("Failed to get budget")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to get budget"))
+		return
+	}
+
+	c.JSON(http.StatusOK, budget)
+}
+
+// UpdateBudget обновляет бюджет пользователя
+// @Summary Обновить бюджет
+// @Description Изменяет период, лимит или валюту бюджета. Область действия не меняется — для другой области создайте новый бюджет.
+// @Tags budgets
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param budget_id path int true "ID бюджета"
+// @Param budget body models.UpdateBudgetRequest true "Данные для обновления"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/budgets/{budget_id} [put]
+func (h *BudgetHandler) UpdateBudget(c *gin.Context) {
+	userID, budgetID, ok := parseBudgetPath(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateBudgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateBudget(userID, budgetID, &req); err != nil {
+		h.logger.WithError(err).WithField("budget_id", budgetID).Error("Failed to update budget")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to update budget"))
+		return
+	}
+
+	h.logger.WithField("budget_id", budgetID).Info("Budget updated successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Budget updated successfully"})
+}
+
+// DeleteBudget удаляет бюджет пользователя
+// @Summary Удалить бюджет
+// @Description Удаляет бюджет вместе с его оповещениями
+// @Tags budgets
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param budget_id path int true "ID бюджета"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/budgets/{budget_id} [delete]
+func (h *BudgetHandler) DeleteBudget(c *gin.Context) {
+	userID, budgetID, ok := parseBudgetPath(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteBudget(userID, budgetID); err != nil {
+		h.logger.WithError(err).WithField("budget_id", budgetID).Error("Failed to delete budget")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to delete budget"))
+		return
+	}
+
+	h.logger.WithField("budget_id", budgetID).Info("Budget deleted successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Budget deleted successfully"})
+}
+
+// ListBudgetAlerts возвращает оповещения по бюджетам пользователя
+// @Summary Оповещения по бюджетам
+// @Description Возвращает оповещения о достижении 80% и 100% лимитов бюджетов, новые первыми. Каждый порог отмечается один раз за период бюджета.
+// @Tags budgets
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param limit query int false "Лимит записей"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.BudgetAlert
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/budget-alerts [get]
+func (h *BudgetHandler) ListBudgetAlerts(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	alerts, err := h.service.ListBudgetAlerts(userID, limit, offset)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to list budget alerts")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to list budget alerts"))
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+func parseBudgetPath(c *gin.Context) (userID uuid.UUID, budgetID int, ok bool) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return uuid.Nil, 0, false
+	}
+	budgetID, err = strconv.Atoi(c.Param("budget_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid budget ID format"})
+		return uuid.Nil, 0, false
+	}
+	return userID, budgetID, true
+}
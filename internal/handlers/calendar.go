@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"go-dev/internal/ical"
+	"go-dev/internal/service"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type CalendarHandler struct {
+	service *service.CalendarService
+	logger  *logrus.Logger
+}
+
+func NewCalendarHandler(service *service.CalendarService, logger *logrus.Logger) *CalendarHandler {
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	return &CalendarHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateCalendarFeed выдает ссылку на календарь подписок пользователя
+// @Summary Создать ссылку на календарь
+// @Description Выдает секретную ссылку на календарь iCalendar (.ics) со списаниями, окончаниями пробных периодов и окончаниями подписок пользователя; на нее можно подписаться в календарном приложении. Ссылка показывается один раз; повторный вызов выдает новую, а прежняя перестает работать.
+// @Tags calendar
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Success 201 {object} models.CalendarFeed
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/calendar-feed [post]
+func (h *CalendarHandler) CreateCalendarFeed(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	feed, err := h.service.CreateCalendarFeed(userID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to create calendar feed")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to create calendar feed"))
+		return
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	feed.URL = scheme + "://" + c.Request.Host + "/api/v1/calendar/" + feed.Token + ".ics"
+
+	h.logger.WithField("user_id", userID).Info("Calendar feed created")
+	c.JSON(http.StatusCreated, feed)
+}
+
+// DeleteCalendarFeed отзывает ссылку на календарь
+// @Summary Отозвать ссылку на календарь
+// @Description Отзывает ссылку на календарь пользователя; календарные приложения перестанут его получать
+// @Tags calendar
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/calendar-feed [delete]
+func (h *CalendarHandler) DeleteCalendarFeed(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	if err := h.service.DeleteCalendarFeed(userID); err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to delete calendar feed")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to delete calendar feed"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Calendar feed revoked"})
+}
+
+// GetCalendar отдает календарь подписок по секретной ссылке
+// @Summary Календарь подписок (.ics)
+// @Description Календарь iCalendar (RFC 5545) по ссылке из POST /users/{id}/calendar-feed: повторяющееся событие списаний по каждой действующей подписке (без пауз и бесплатного пробного периода, суммой доли пользователя), окончания пробных периодов и окончания подписок. Идентификаторы событий постоянны, поэтому изменения подписок обновляют события, а не дублируют их.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "Токен из ссылки с расширением .ics"
+// @Success 200 {file} file
+// @Failure 404 {object} map[string]string
+// @Router /calendar/{token} [get]
+func (h *CalendarHandler) GetCalendar(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	events, err := h.service.GetCalendar(token)
+	if err != nil {
+		if errorStatus(err) != http.StatusNotFound {
+			h.logger.WithError(err).Error("Failed to build calendar")
+		}
+		c.JSON(errorStatus(err), errorBody(err, "Failed to build calendar"))
+		return
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Content-Disposition", `inline; filename="subscriptions.ics"`)
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.Status(http.StatusOK)
+
+	err = ical.Write(c.Writer, &ical.Calendar{
+		Name:        "Subscriptions",
+		Events:      events,
+		GeneratedAt: time.Now(),
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("Calendar export interrupted")
+		c.Abort()
+		return
+	}
+
+	h.logger.WithField("events", len(events)).Debug("Calendar served")
+}
@@ -0,0 +1,319 @@
+package handlers
+
+import (
+	"go-dev/internal/models"
+	"go-dev/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type CatalogHandler struct {
+	service *service.CatalogService
+	logger  *logrus.Logger
+}
+
+func NewCatalogHandler(service *service.CatalogService, logger *logrus.Logger) *CatalogHandler {
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	return &CatalogHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// CreateService создает сервис каталога
+// @Summary Создать сервис
+// @Description Создает сервис каталога вместе с тарифными планами по умолчанию
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param service body models.CreateServiceRequest true "Данные сервиса"
+// @Success 201 {object} models.Service
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /services [post]
+func (h *CatalogHandler) CreateService(c *gin.Context) {
+	var req models.CreateServiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Failed to bind request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.WithField("name", req.Name).Info("Creating service")
+
+	svc, err := h.service.CreateService(&req)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to create service")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to create service"))
+		return
+	}
+
+	h.logger.WithField("service_id", svc.ID).Info("Service created successfully")
+	c.JSON(http.StatusCreated, svc)
+}
+
+// GetService получает сервис по ID
+// @Summary Получить сервис по ID
+// @Description Возвращает сервис каталога с его тарифными планами
+// @Tags services
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Success 200 {object} models.Service
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /services/{id} [get]
+func (h *CatalogHandler) GetService(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	svc, err := h.service.GetService(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to get service")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to get service"))
+		return
+	}
+
+	c.JSON(http.StatusOK, svc)
+}
+
+// ListServices возвращает каталог сервисов
+// @Summary Получить каталог сервисов
+// @Description Возвращает сервисы каталога с тарифными планами
+// @Tags services
+// @Produce json
+// @Param category query string false "Категория"
+// @Param limit query int false "Лимит записей"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.Service
+// @Failure 500 {object} map[string]string
+// @Router /services [get]
+func (h *CatalogHandler) ListServices(c *gin.Context) {
+	var category *string
+	if categoryStr := c.Query("category"); categoryStr != "" {
+		category = &categoryStr
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	services, err := h.service.ListServices(category, limit, offset)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list services")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve services"})
+		return
+	}
+
+	c.JSON(http.StatusOK, services)
+}
+
+// UpdateService обновляет сервис
+// @Summary Обновить сервис
+// @Description Обновляет название, вендора или категорию сервиса
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Param service body models.UpdateServiceRequest true "Данные для обновления"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /services/{id} [put]
+func (h *CatalogHandler) UpdateService(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.UpdateServiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdateService(id, &req); err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to update service")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to update service"))
+		return
+	}
+
+	h.logger.WithField("service_id", id).Info("Service updated successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Service updated successfully"})
+}
+
+// DeleteService удаляет сервис
+// @Summary Удалить сервис
+// @Description Удаляет сервис и его тарифные планы; подписки теряют ссылку на план
+// @Tags services
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /services/{id} [delete]
+func (h *CatalogHandler) DeleteService(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	if err := h.service.DeleteService(id); err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to delete service")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to delete service"))
+		return
+	}
+
+	h.logger.WithField("service_id", id).Info("Service deleted successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Service deleted successfully"})
+}
+
+// CreatePlan добавляет тарифный план сервису
+// @Summary Создать тарифный план
+// @Description Добавляет тарифный план сервису каталога
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Param plan body models.CreatePlanRequest true "Данные тарифа"
+// @Success 201 {object} models.Plan
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /services/{id}/plans [post]
+func (h *CatalogHandler) CreatePlan(c *gin.Context) {
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.CreatePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	plan, err := h.service.CreatePlan(serviceID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("service_id", serviceID).Error("Failed to create plan")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to create plan"))
+		return
+	}
+
+	h.logger.WithField("plan_id", plan.ID).Info("Plan created successfully")
+	c.JSON(http.StatusCreated, plan)
+}
+
+// ListPlans возвращает тарифные планы сервиса
+// @Summary Получить тарифные планы сервиса
+// @Description Возвращает тарифные планы сервиса каталога
+// @Tags services
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Success 200 {array} models.Plan
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /services/{id}/plans [get]
+func (h *CatalogHandler) ListPlans(c *gin.Context) {
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	plans, err := h.service.ListPlans(serviceID)
+	if err != nil {
+		h.logger.WithError(err).WithField("service_id", serviceID).Error("Failed to list plans")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to list plans"))
+		return
+	}
+
+	c.JSON(http.StatusOK, plans)
+}
+
+// UpdatePlan обновляет тарифный план
+// @Summary Обновить тарифный план
+// @Description Обновляет тарифный план сервиса. Уже оформленные подписки не меняются.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Param plan_id path int true "ID тарифа"
+// @Param plan body models.UpdatePlanRequest true "Данные для обновления"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /services/{id}/plans/{plan_id} [put]
+func (h *CatalogHandler) UpdatePlan(c *gin.Context) {
+	serviceID, planID, ok := parsePlanPath(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdatePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdatePlan(serviceID, planID, &req); err != nil {
+		h.logger.WithError(err).WithField("plan_id", planID).Error("Failed to update plan")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to update plan"))
+		return
+	}
+
+	h.logger.WithField("plan_id", planID).Info("Plan updated successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Plan updated successfully"})
+}
+
+// DeletePlan удаляет тарифный план
+// @Summary Удалить тарифный план
+// @Description Удаляет тарифный план сервиса; подписки теряют ссылку на план
+// @Tags services
+// @Produce json
+// @Param id path int true "ID сервиса"
+// @Param plan_id path int true "ID тарифа"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /services/{id}/plans/{plan_id} [delete]
+func (h *CatalogHandler) DeletePlan(c *gin.Context) {
+	serviceID, planID, ok := parsePlanPath(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeletePlan(serviceID, planID); err != nil {
+		h.logger.WithError(err).WithField("plan_id", planID).Error("Failed to delete plan")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to delete plan"))
+		return
+	}
+
+	h.logger.WithField("plan_id", planID).Info("Plan deleted successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Plan deleted successfully"})
+}
+
+func parsePlanPath(c *gin.Context) (serviceID, planID int, ok bool) {
+	serviceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return 0, 0, false
+	}
+	planID, err = strconv.Atoi(c.Param("plan_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plan ID format"})
+		return 0, 0, false
+	}
+	return serviceID, planID, true
+}
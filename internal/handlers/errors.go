@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"go-dev/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errorStatus подбирает HTTP-статус по ошибке сервиса; неизвестные ошибки — 500
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, service.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// errorBody формирует тело ответа с ошибкой. Текст ошибок сервиса (валидация, не найдено,
+// конфликт) адресован клиенту и возвращается как есть; внутренние ошибки заменяются
+// сообщением message, чтобы не раскрывать детали БД и окружения.
+func errorBody(err error, message string) gin.H {
+	if errorStatus(err) == http.StatusInternalServerError {
+		return gin.H{"error": message}
+	}
+	return gin.H{"error": err.Error()}
+}
+
+// bindOptionalJSON разбирает тело запроса, если оно передано; пустое тело допустимо
+func bindOptionalJSON(c *gin.Context, obj interface{}) error {
+	if c.Request.ContentLength == 0 {
+		return nil
+	}
+	return c.ShouldBindJSON(obj)
+}
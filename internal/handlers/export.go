@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"fmt"
+	"go-dev/internal/export"
+	"go-dev/internal/models"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ExportSubscriptions выгружает подписки таблицей
+// @Summary Выгрузить подписки в CSV или XLSX
+// @Description Выгружает все подписки по тем же фильтрам, что и список подписок, без ограничения количества. Суммы — в единицах валюты с двумя знаками после точки; CSV начинается с BOM, чтобы Excel распознал UTF-8.
+// @Tags export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Формат файла" Enums(csv, xlsx) default(csv)
+// @Param user_id query string false "UUID пользователя"
+// @Param service_name query string false "Название сервиса"
+// @Param tags query string false "Теги через запятую; только подписки со всеми тегами"
+// @Param active query bool false "Только активные в текущем месяце (не закончившиеся и не на паузе)"
+// @Param status query string false "Статусы через запятую: trialing, active, paused, pending_cancellation, cancelled, expired"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/export [get]
+func (h *SubscriptionHandler) ExportSubscriptions(c *gin.Context) {
+	format, ok := h.parseExportFormat(c)
+	if !ok {
+		return
+	}
+	filter, ok := parseSubscriptionFilter(c)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"format":       format,
+		"user_id":      filter.UserID,
+		"service_name": filter.ServiceName,
+		"tags":         filter.Tags,
+		"active_at":    filter.ActiveAt,
+		"statuses":     filter.Statuses,
+	}).Info("Exporting subscriptions")
+
+	// Файл начинается с первой подписки, чтобы ошибку первого запроса можно было вернуть в JSON
+	var w export.Writer
+	start := func() error {
+		var err error
+		if w, err = startExport(c, format, "subscriptions", "Subscriptions"); err != nil {
+			return err
+		}
+		return w.WriteRow("id", "service_name", "user_id", "status", "price", "currency",
+			"billing_period", "billing_interval", "billing_day", "monthly_equivalent", "annualized_price",
+			"start_date", "end_date", "category", "tags", "members", "created_at")
+	}
+
+	rows := 0
+	err := h.service.EachSubscription(filter, func(sub *models.Subscription) error {
+		if w == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		rows++
+		return w.WriteRow(sub.ID, sub.CanonicalServiceName(), sub.UserID.String(), string(sub.Status),
+			export.Amount(sub.Price), sub.Currency, string(sub.BillingPeriod), sub.BillingInterval, sub.BillingDay,
+			export.Amount(sub.MonthlyEquivalent), export.Amount(sub.AnnualizedPrice),
+			sub.StartDate, valueOrNil(sub.EndDate), valueOrNil(sub.EffectiveCategory()),
+			strings.Join(sub.Tags, ", "), len(sub.Members), sub.CreatedAt.Format(time.RFC3339))
+	})
+	if err == nil && w == nil {
+		err = start()
+	}
+	h.finishExport(c, w, err, rows, "subscriptions")
+}
+
+// ExportCostBreakdown выгружает помесячные расходы таблицей
+// @Summary Выгрузить расходы по месяцам в CSV или XLSX
+// @Description Выгружает помесячную разбивку расходов: по строке на месяц с суммой, числом активных подписок, начавшимися и закончившимися подписками, и строкой итога. Суммы — в единицах валюты с двумя знаками после точки.
+// @Tags export
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Формат файла" Enums(csv, xlsx) default(csv)
+// @Param start_period query string true "Начальный период (MM-YYYY)"
+// @Param end_period query string true "Конечный период (MM-YYYY)"
+// @Param user_id query string false "UUID пользователя"
+// @Param service_name query string false "Название сервиса"
+// @Param currency query string false "Валюта отчета (ISO 4217); по умолчанию — валюта подписок"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/cost-breakdown/export [get]
+func (h *SubscriptionHandler) ExportCostBreakdown(c *gin.Context) {
+	format, ok := h.parseExportFormat(c)
+	if !ok {
+		return
+	}
+	from, to, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+	userID, serviceName, ok := parseFilters(c)
+	if !ok {
+		return
+	}
+	currency, ok := parseCurrency(c)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"format":       format,
+		"start_period": from,
+		"end_period":   to,
+		"user_id":      userID,
+		"service_name": serviceName,
+		"currency":     currency,
+	}).Info("Exporting cost breakdown")
+
+	result, err := h.service.GetCostBreakdown(userID, serviceName, from, to, currency)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to calculate cost breakdown")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to calculate cost breakdown"))
+		return
+	}
+
+	w, err := startExport(c, format, "cost-breakdown", "Cost breakdown")
+	if err == nil {
+		err = w.WriteRow("month", "cost", "currency", "active_subscriptions", "started", "ended")
+	}
+	for _, month := range result.Months {
+		if err != nil {
+			break
+		}
+		err = w.WriteRow(month.Month.String(), export.Amount(month.Cost), result.Currency,
+			month.ActiveSubscriptions, refNames(month.Started), refNames(month.Ended))
+	}
+	if err == nil {
+		err = w.WriteRow("total", export.Amount(result.TotalCost), result.Currency, nil, nil, nil)
+	}
+	h.finishExport(c, w, err, len(result.Months), "cost breakdown")
+}
+
+// parseExportFormat разбирает параметр format. При ошибке сам отвечает 400 и возвращает ok = false.
+func (h *SubscriptionHandler) parseExportFormat(c *gin.Context) (export.Format, bool) {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return "", false
+	}
+	return format, true
+}
+
+// startExport отправляет заголовки ответа с файлом name.<формат> и возвращает Writer поверх ответа
+func startExport(c *gin.Context, format export.Format, name, sheet string) (export.Writer, error) {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("2006-01-02"), format)
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+	return export.NewWriter(c.Writer, format, sheet)
+}
+
+// finishExport дописывает файл. Если файл еще не начат, ошибка возвращается в JSON;
+// после начала выгрузки ответ уже отправляется, и ошибку остается только записать в лог.
+func (h *SubscriptionHandler) finishExport(c *gin.Context, w export.Writer, err error, rows int, what string) {
+	if w == nil {
+		message := fmt.Sprintf("Failed to export %s", what)
+		h.logger.WithError(err).Error(message)
+		c.JSON(errorStatus(err), errorBody(err, message))
+		return
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		h.logger.WithError(err).Errorf("Export of %s interrupted", what)
+		c.Abort()
+		return
+	}
+	h.logger.WithField("rows", rows).Infof("Exported %s", what)
+}
+
+func refNames(refs []models.SubscriptionRef) string {
+	names := make([]string, len(refs))
+	for i, ref := range refs {
+		names[i] = ref.ServiceName
+	}
+	return strings.Join(names, ", ")
+}
+
+func valueOrNil(s *string) interface{} {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"go-dev/internal/models"
+	"go-dev/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type InsightHandler struct {
+	service *service.InsightService
+	logger  *logrus.Logger
+}
+
+func NewInsightHandler(service *service.InsightService, logger *logrus.Logger) *InsightHandler {
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	return &InsightHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ListInsights возвращает находки анализа расходов пользователя
+// @Summary Аномалии расходов
+// @Description Возвращает необычные события в расходах пользователя, найденные фоновым анализом: резкий рост цены подписки (price_jump), новую подписку, заметно дороже остальных (new_high_cost), и расходы месяца, сильно отличающиеся от среднего за предыдущие месяцы (spend_deviation). Каждая находка содержит важность и объяснение; новые — первыми.
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param severity query string false "Важность" Enums(info, warning, critical)
+// @Param limit query int false "Лимит записей"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.Insight
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/insights [get]
+func (h *InsightHandler) ListInsights(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	var severity *models.InsightSeverity
+	if s := c.Query("severity"); s != "" {
+		value := models.InsightSeverity(s)
+		if !value.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid severity, expected info, warning or critical"})
+			return
+		}
+		severity = &value
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	insights, err := h.service.ListInsights(userID, severity, limit, offset)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to list insights")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to list insights"))
+		return
+	}
+
+	c.JSON(http.StatusOK, insights)
+}
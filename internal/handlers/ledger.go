@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"fmt"
+	"go-dev/internal/ledger"
+	"go-dev/internal/models"
+	"go-dev/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type LedgerHandler struct {
+	service *service.LedgerService
+	logger  *logrus.Logger
+}
+
+func NewLedgerHandler(service *service.LedgerService, logger *logrus.Logger) *LedgerHandler {
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	return &LedgerHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ExportLedger выгружает списания по подпискам пользователя в бухгалтерский журнал
+// @Summary Выгрузить списания в beancount, hledger или OFX
+// @Description Раскладывает подписки пользователя за период на датированные списания по цене и расчетному периоду (месяцы паузы и бесплатный пробный период пропускаются, в совместных подписках — доля пользователя) и выгружает их проводками beancount или hledger либо выпиской OFX. Счета расходов берутся из настроек счетов журнала по категориям подписок. Суммы — в валюте подписки.
+// @Tags ledger
+// @Produce plain
+// @Produce application/x-ofx
+// @Param id path string true "ID пользователя (UUID)"
+// @Param format query string true "Формат" Enums(beancount, hledger, ofx)
+// @Param start_period query string true "Начальный период (MM-YYYY)"
+// @Param end_period query string true "Конечный период (MM-YYYY)"
+// @Param open_accounts query bool false "Объявить используемые счета (open в beancount, account в hledger)"
+// @Success 200 {file} file
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/ledger [get]
+func (h *LedgerHandler) ExportLedger(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	format := models.LedgerFormat(c.Query("format"))
+	if !format.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format, expected beancount, hledger or ofx"})
+		return
+	}
+
+	from, to, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+	openAccounts, _ := strconv.ParseBool(c.Query("open_accounts"))
+
+	transactions, accounts, err := h.service.GetLedgerTransactions(userID, from, to)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to build ledger transactions")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to build ledger transactions"))
+		return
+	}
+
+	contentType, extension := ledger.ContentType(format)
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="subscriptions-%s-%s.%s"`, from, to, extension))
+	c.Status(http.StatusOK)
+
+	err = ledger.Write(c.Writer, format, &ledger.Journal{
+		UserID:       userID,
+		From:         from,
+		To:           to,
+		Accounts:     accounts,
+		Transactions: transactions,
+		OpenAccounts: openAccounts,
+		GeneratedAt:  time.Now(),
+	})
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Ledger export interrupted")
+		c.Abort()
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id":      userID,
+		"format":       format,
+		"transactions": len(transactions),
+	}).Info("Ledger exported")
+}
+
+// GetLedgerAccounts возвращает счета журнала пользователя
+// @Summary Счета журнала
+// @Description Возвращает счет оплаты, счет расходов по умолчанию и счета расходов по категориям. Подписка без категории относится на счет по умолчанию, подписка категории без своего счета — на его подсчет с названием категории (например, Expenses:Subscriptions:Streaming).
+// @Tags ledger
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Success 200 {object} models.LedgerAccounts
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/ledger-accounts [get]
+func (h *LedgerHandler) GetLedgerAccounts(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	accounts, err := h.service.GetLedgerAccounts(userID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to get ledger accounts")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to get ledger accounts"))
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+// UpdateLedgerAccounts меняет счета журнала пользователя
+// @Summary Изменить счета журнала
+// @Description Меняет счет оплаты и счет расходов по умолчанию; categories (категория → счет) заменяет все счета категорий. Имена счетов — компоненты через двоеточие, начиная с Assets, Liabilities, Equity, Income или Expenses.
+// @Tags ledger
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param request body models.UpdateLedgerAccountsRequest true "Счета журнала"
+// @Success 200 {object} models.LedgerAccounts
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/ledger-accounts [put]
+func (h *LedgerHandler) UpdateLedgerAccounts(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	var req models.UpdateLedgerAccountsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	accounts, err := h.service.UpdateLedgerAccounts(userID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to update ledger accounts")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to update ledger accounts"))
+		return
+	}
+
+	h.logger.WithField("user_id", userID).Info("Ledger accounts updated")
+	c.JSON(http.StatusOK, accounts)
+}
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"go-dev/internal/models"
+	"go-dev/internal/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type PaymentHandler struct {
+	service *service.PaymentService
+	logger  *logrus.Logger
+}
+
+func NewPaymentHandler(service *service.PaymentService, logger *logrus.Logger) *PaymentHandler {
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	return &PaymentHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RecordPayment записывает фактический платеж по подписке
+// @Summary Записать платеж
+// @Description Записывает фактически уплаченную сумму по подписке. Плательщик — владелец (по умолчанию) или участник подписки; валюта по умолчанию — валюта подписки. Повторный платеж плательщика с тем же external_reference отклоняется.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param payment body models.CreatePaymentRequest true "Данные платежа"
+// @Success 201 {object} models.Payment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /subscriptions/{id}/payments [post]
+func (h *PaymentHandler) RecordPayment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"subscription_id": id,
+		"amount":          req.Amount,
+		"paid_date":       req.PaidDate,
+	}).Info("Recording payment")
+
+	payment, err := h.service.RecordPayment(id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to record payment")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to record payment"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// ListPayments возвращает платежи по подписке
+// @Summary Платежи по подписке
+// @Description Возвращает записанные платежи по подписке, новые первыми
+// @Tags payments
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param limit query int false "Лимит записей"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.Payment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /subscriptions/{id}/payments [get]
+func (h *PaymentHandler) ListPayments(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	payments, err := h.service.ListSubscriptionPayments(id, limit, offset)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to list payments")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to list payments"))
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+// DeletePayment удаляет ошибочно записанный платеж
+// @Summary Удалить платеж
+// @Description Удаляет платеж по подписке
+// @Tags payments
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param payment_id path int true "ID платежа"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /subscriptions/{id}/payments/{payment_id} [delete]
+func (h *PaymentHandler) DeletePayment(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+	paymentID, err := strconv.Atoi(c.Param("payment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID format"})
+		return
+	}
+
+	if err := h.service.DeletePayment(id, paymentID); err != nil {
+		h.logger.WithError(err).WithField("payment_id", paymentID).Error("Failed to delete payment")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to delete payment"))
+		return
+	}
+
+	h.logger.WithField("payment_id", paymentID).Info("Payment deleted successfully")
+	c.JSON(http.StatusOK, gin.H{"message": "Payment deleted successfully"})
+}
+
+// RecordUserPayment записывает списание пользователя без подписки
+// @Summary Записать списание без подписки
+// @Description Записывает списание, не сопоставленное с подпиской, например из банковской выписки. Повторяющиеся списания без подписки отмечаются сверкой. Валюта обязательна; user_id в теле не используется.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param payment body models.CreatePaymentRequest true "Данные платежа"
+// @Success 201 {object} models.Payment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /users/{id}/payments [post]
+func (h *PaymentHandler) RecordUserPayment(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	var req models.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id":   userID,
+		"amount":    req.Amount,
+		"paid_date": req.PaidDate,
+	}).Info("Recording payment without subscription")
+
+	payment, err := h.service.RecordUnlinkedPayment(userID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to record payment")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to record payment"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, payment)
+}
+
+// ListUserPayments возвращает платежи пользователя
+// @Summary Платежи пользователя
+// @Description Возвращает платежи, внесенные пользователем по всем подпискам, и его списания без подписки, новые первыми
+// @Tags payments
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param limit query int false "Лимит записей"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} models.Payment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/payments [get]
+func (h *PaymentHandler) ListUserPayments(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	payments, err := h.service.ListUserPayments(userID, limit, offset)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to list user payments")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to list user payments"))
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
+// GetExpectedVsPaid сравнивает ожидаемые списания с записанными платежами
+// @Summary Ожидаемые и уплаченные суммы
+// @Description Для каждого месяца периода сравнивает ожидаемые списания (как в total-cost) с платежами по тем же подпискам. С фильтром user_id учитываются доля пользователя и только его платежи. Платежи в других валютах пересчитываются по курсу месяца оплаты.
+// @Tags payments
+// @Produce json
+// @Param start_period query string true "Начальный период (MM-YYYY)"
+// @Param end_period query string true "Конечный период (MM-YYYY)"
+// @Param user_id query string false "UUID пользователя"
+// @Param service_name query string false "Название сервиса"
+// @Param tags query string false "Теги через запятую; только подписки со всеми тегами"
+// @Param currency query string false "Валюта отчета (ISO 4217); по умолчанию — валюта подписок"
+// @Success 200 {object} models.ExpectedVsPaidResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/expected-vs-paid [get]
+func (h *PaymentHandler) GetExpectedVsPaid(c *gin.Context) {
+	from, to, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	userID, serviceName, ok := parseFilters(c)
+	if !ok {
+		return
+	}
+
+	tags, ok := parseTags(c)
+	if !ok {
+		return
+	}
+
+	currency, ok := parseCurrency(c)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"start_period": from,
+		"end_period":   to,
+		"user_id":      userID,
+		"service_name": serviceName,
+		"tags":         tags,
+		"currency":     currency,
+	}).Info("Comparing expected and paid amounts")
+
+	result, err := h.service.GetExpectedVsPaid(userID, serviceName, tags, from, to, currency)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to compare expected and paid amounts")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to compare expected and paid amounts"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"go-dev/internal/receipt"
+	"go-dev/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// maxReceiptSize — наибольший размер загружаемого письма
+const maxReceiptSize = 5 << 20
+
+type ReceiptHandler struct {
+	service  *service.ReceiptService
+	receipts *receipt.Extractor
+	logger   *logrus.Logger
+}
+
+func NewReceiptHandler(service *service.ReceiptService, receipts *receipt.Extractor, logger *logrus.Logger) *ReceiptHandler {
+	if logger == nil {
+		logger = logrus.New()
+	}
+	// Без своих правил чеки разбираются встроенными
+	if receipts == nil {
+		receipts = receipt.NewExtractor(receipt.DefaultRules()...)
+	}
+
+	return &ReceiptHandler{
+		service:  service,
+		receipts: receipts,
+		logger:   logger,
+	}
+}
+
+// ImportReceipt загружает письмо с чеком об оплате
+// @Summary Загрузить чек из письма
+// @Description Разбирает письмо в формате .eml (RFC 5322) с чеком об оплате: отправитель, сумма, валюта и дата извлекаются правилом поставщика по домену отправителя, а для прочих писем — из строки с итогом. Оплата записывается по подписке пользователя на этот сервис; если такой подписки нет, она создается по сервису и тарифу каталога с той же ценой. Повторная загрузка того же письма пропускается.
+// @Tags receipts
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param file formData file true "Письмо .eml (до 5 МБ)"
+// @Success 200 {object} models.ReceiptImportResult
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/receipts [post]
+func (h *ReceiptHandler) ImportReceipt(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email file is required"})
+		return
+	}
+	if header.Size > maxReceiptSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email file is too large"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read email file"})
+		return
+	}
+	defer file.Close()
+
+	parsed, err := h.receipts.Parse(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email: " + err.Error()})
+		return
+	}
+
+	result, err := h.service.ImportReceipt(userID, parsed)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to import receipt")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to import receipt"))
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id":      userID,
+		"file":         header.Filename,
+		"rule":         result.Rule,
+		"service_name": result.ServiceName,
+		"action":       result.Action,
+	}).Info("Receipt imported")
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"go-dev/internal/models"
+	"go-dev/internal/service"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type ReconciliationHandler struct {
+	service *service.ReconciliationService
+	logger  *logrus.Logger
+}
+
+func NewReconciliationHandler(service *service.ReconciliationService, logger *logrus.Logger) *ReconciliationHandler {
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	return &ReconciliationHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetReconciliation возвращает отчет сверки пользователя за месяц
+// @Summary Отчет сверки за месяц
+// @Description Возвращает расхождения между ожидаемыми списаниями подписок и записанными платежами: пропущенные списания (missing_charge), списания по отмененным, закончившимся или приостановленным подпискам (cancelled_charge), несовпадение суммы (amount_mismatch) и повторяющиеся списания без подписки (unknown_recurring). Сверка текущего и прошлого месяцев выполняется периодически.
+// @Tags reconciliation
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param month query string false "Месяц (MM-YYYY), по умолчанию — текущий"
+// @Success 200 {object} models.ReconciliationReport
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/reconciliation [get]
+func (h *ReconciliationHandler) GetReconciliation(c *gin.Context) {
+	userID, month, ok := parseReconciliationQuery(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetReconciliation(userID, month)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to get reconciliation report")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to get reconciliation report"))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RunReconciliation сверяет месяц пользователя немедленно
+// @Summary Выполнить сверку за месяц
+// @Description Сверяет ожидаемые списания подписок пользователя с платежами за месяц и сохраняет расхождения. Расхождения, которых больше нет, удаляются; разрешенные сохраняют статус.
+// @Tags reconciliation
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param month query string false "Месяц (MM-YYYY), по умолчанию — текущий"
+// @Success 200 {object} models.ReconciliationReport
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/reconciliation [post]
+func (h *ReconciliationHandler) RunReconciliation(c *gin.Context) {
+	userID, month, ok := parseReconciliationQuery(c)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"month":   month,
+	}).Info("Reconciling payments")
+
+	report, err := h.service.ReconcileMonth(userID, month)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to reconcile payments")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to reconcile payments"))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ResolveReconciliationItem отмечает расхождение разрешенным
+// @Summary Разрешить расхождение
+// @Description Отмечает расхождение сверки разрешенным с необязательным комментарием. Повторная сверка не открывает его снова.
+// @Tags reconciliation
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param item_id path int true "ID расхождения"
+// @Param request body models.ResolveReconciliationItemRequest false "Комментарий"
+// @Success 200 {object} models.ReconciliationItem
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /users/{id}/reconciliation/items/{item_id}/resolve [post]
+func (h *ReconciliationHandler) ResolveReconciliationItem(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+	itemID, err := strconv.Atoi(c.Param("item_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID format"})
+		return
+	}
+
+	var req models.ResolveReconciliationItemRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	item, err := h.service.ResolveReconciliationItem(userID, itemID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("item_id", itemID).Error("Failed to resolve reconciliation item")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to resolve reconciliation item"))
+		return
+	}
+
+	h.logger.WithField("item_id", itemID).Info("Reconciliation item resolved")
+	c.JSON(http.StatusOK, item)
+}
+
+// parseReconciliationQuery разбирает ID пользователя и необязательный месяц.
+// При ошибке сам отвечает 400 и возвращает ok = false.
+func parseReconciliationQuery(c *gin.Context) (userID uuid.UUID, month models.Month, ok bool) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return uuid.Nil, 0, false
+	}
+
+	month = models.MonthOf(time.Now())
+	if monthStr := c.Query("month"); monthStr != "" {
+		if month, err = models.ParseMonth(monthStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month: " + err.Error()})
+			return uuid.Nil, 0, false
+		}
+	}
+	return userID, month, true
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"go-dev/internal/models"
+	"go-dev/internal/service"
+	"go-dev/internal/statement"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// maxStatementSize — наибольший размер загружаемой выписки
+const maxStatementSize = 10 << 20
+
+type StatementHandler struct {
+	service *service.StatementService
+	logger  *logrus.Logger
+}
+
+func NewStatementHandler(service *service.StatementService, logger *logrus.Logger) *StatementHandler {
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	return &StatementHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ImportStatement загружает банковскую выписку в формате CSV
+// @Summary Загрузить банковскую выписку
+// @Description Загружает CSV-выписку и сохраняет списания как платежи пользователя. Колонки задаются названиями из заголовка (по умолчанию date, description, amount и currency); по умолчанию списания — отрицательные суммы. Повторная загрузка тех же операций пропускается, списания по существующим подпискам привязываются к ним. Повторяющиеся списания без подписки предлагаются как новые подписки.
+// @Tags statements
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param file formData file true "CSV-выписка (до 10 МБ)"
+// @Param date_column formData string false "Колонка даты" default(date)
+// @Param description_column formData string false "Колонка описания" default(description)
+// @Param amount_column formData string false "Колонка суммы" default(amount)
+// @Param currency_column formData string false "Колонка валюты" default(currency)
+// @Param reference_column formData string false "Колонка идентификатора операции"
+// @Param date_format formData string false "Формат даты из YYYY, YY, MM, DD" default(YYYY-MM-DD)
+// @Param delimiter formData string false "Разделитель колонок: один символ или tab" default(,)
+// @Param decimal_comma formData bool false "Запятая — десятичный разделитель"
+// @Param debits_positive formData bool false "Списания указаны положительными суммами"
+// @Param currency formData string false "Валюта, если колонки валюты нет" default(RUB)
+// @Success 200 {object} models.StatementImportResult
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/statements [post]
+func (h *StatementHandler) ImportStatement(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	var mapping models.StatementMapping
+	if err := c.ShouldBind(&mapping); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Statement file is required"})
+		return
+	}
+	if header.Size > maxStatementSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Statement file is too large"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read statement file"})
+		return
+	}
+	defer file.Close()
+
+	transactions, err := statement.ParseCSV(file, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid statement: " + err.Error()})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id":      userID,
+		"file":         header.Filename,
+		"transactions": len(transactions),
+	}).Info("Importing bank statement")
+
+	result, err := h.service.ImportStatement(userID, transactions)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to import bank statement")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to import bank statement"))
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"imported":   result.Imported,
+		"duplicates": result.Duplicates,
+		"linked":     result.Linked,
+		"candidates": len(result.Candidates),
+	}).Info("Bank statement imported")
+	c.JSON(http.StatusOK, result)
+}
+
+// ListCandidates возвращает подписки, предложенные по выпискам
+// @Summary Предложенные подписки
+// @Description Возвращает повторяющиеся списания без подписки, найденные в выписках, самые уверенные первыми
+// @Tags statements
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param status query string false "Состояние" Enums(pending, confirmed, dismissed)
+// @Success 200 {array} models.SubscriptionCandidate
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/subscription-candidates [get]
+func (h *StatementHandler) ListCandidates(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	var status *models.CandidateStatus
+	if s := c.Query("status"); s != "" {
+		value := models.CandidateStatus(s)
+		if !value.IsValid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, expected pending, confirmed or dismissed"})
+			return
+		}
+		status = &value
+	}
+
+	candidates, err := h.service.ListCandidates(userID, status)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to list subscription candidates")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to list subscription candidates"))
+		return
+	}
+
+	c.JSON(http.StatusOK, candidates)
+}
+
+// ConfirmCandidate создает подписку по предложенному кандидату
+// @Summary Подтвердить предложенную подписку
+// @Description Создает подписку по кандидату: название, цена, валюта и периодичность берутся из найденных списаний, если не указаны в запросе. Подписка начинается с месяца первого списания; списания этого получателя привязываются к ней.
+// @Tags statements
+// @Accept json
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param candidate_id path int true "ID кандидата"
+// @Param request body models.ConfirmCandidateRequest false "Уточнения подписки"
+// @Success 201 {object} models.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /users/{id}/subscription-candidates/{candidate_id}/confirm [post]
+func (h *StatementHandler) ConfirmCandidate(c *gin.Context) {
+	userID, candidateID, ok := parseCandidatePath(c)
+	if !ok {
+		return
+	}
+
+	var req models.ConfirmCandidateRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub, err := h.service.ConfirmCandidate(userID, candidateID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("candidate_id", candidateID).Error("Failed to confirm subscription candidate")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to confirm subscription candidate"))
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"candidate_id":    candidateID,
+		"subscription_id": sub.ID,
+	}).Info("Subscription candidate confirmed")
+	c.JSON(http.StatusCreated, sub)
+}
+
+// DismissCandidate отклоняет предложенную подписку
+// @Summary Отклонить предложенную подписку
+// @Description Отклоняет кандидата; списания этого получателя больше не предлагаются как подписка
+// @Tags statements
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param candidate_id path int true "ID кандидата"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /users/{id}/subscription-candidates/{candidate_id}/dismiss [post]
+func (h *StatementHandler) DismissCandidate(c *gin.Context) {
+	userID, candidateID, ok := parseCandidatePath(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DismissCandidate(userID, candidateID); err != nil {
+		h.logger.WithError(err).WithField("candidate_id", candidateID).Error("Failed to dismiss subscription candidate")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to dismiss subscription candidate"))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription candidate dismissed"})
+}
+
+func parseCandidatePath(c *gin.Context) (userID uuid.UUID, candidateID int, ok bool) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return uuid.Nil, 0, false
+	}
+	candidateID, err = strconv.Atoi(c.Param("candidate_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid candidate ID format"})
+		return uuid.Nil, 0, false
+	}
+	return userID, candidateID, true
+}
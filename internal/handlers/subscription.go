@@ -5,6 +5,8 @@ import (
 	"go-dev/internal/service"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -31,13 +33,14 @@ func NewSubscriptionHandler(service *service.SubscriptionService, logger *logrus
 // Остальной код остается тот же...
 // Create создает новую подписку
 // @Summary Создать подписку
-// @Description Создает новую подписку пользователя
+// @Description Создает новую подписку пользователя. Если у пользователя или участников общих с ним подписок уже есть подписка на тот же сервис с пересекающимися датами, она возвращается в duplicate_warnings.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
 // @Param subscription body models.CreateSubscriptionRequest true "Данные подписки"
 // @Success 201 {object} models.Subscription
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /subscriptions [post]
 func (h *SubscriptionHandler) Create(c *gin.Context) {
@@ -57,10 +60,17 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 	subscription, err := h.service.Create(&req)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to create subscription")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription"})
+		c.JSON(errorStatus(err), errorBody(err, "Failed to create subscription"))
 		return
 	}
 
+	if len(subscription.DuplicateWarnings) > 0 {
+		h.logger.WithFields(logrus.Fields{
+			"subscription_id": subscription.ID,
+			"duplicates":      len(subscription.DuplicateWarnings),
+		}).Warn("Subscription may duplicate existing subscriptions")
+	}
+
 	h.logger.WithField("subscription_id", subscription.ID).Info("Subscription created successfully")
 	c.JSON(http.StatusCreated, subscription)
 }
@@ -101,6 +111,9 @@ func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 // @Produce json
 // @Param user_id query string false "UUID пользователя"
 // @Param service_name query string false "Название сервиса"
+// @Param tags query string false "Теги через запятую; только подписки со всеми тегами"
+// @Param active query bool false "Только активные в текущем месяце (не закончившиеся и не на паузе)"
+// @Param status query string false "Статусы через запятую: trialing, active, paused, pending_cancellation, cancelled, expired"
 // @Param limit query int false "Лимит записей"
 // @Param offset query int false "Смещение"
 // @Success 200 {array} models.Subscription
@@ -108,32 +121,24 @@ func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 // @Failure 500 {object} map[string]string
 // @Router /subscriptions [get]
 func (h *SubscriptionHandler) List(c *gin.Context) {
-	var userID *uuid.UUID
-	if userIDStr := c.Query("user_id"); userIDStr != "" {
-		parsedUUID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id format"})
-			return
-		}
-		userID = &parsedUUID
-	}
-
-	var serviceName *string
-	if serviceNameStr := c.Query("service_name"); serviceNameStr != "" {
-		serviceName = &serviceNameStr
+	filter, ok := parseSubscriptionFilter(c)
+	if !ok {
+		return
 	}
-
-	limit, _ := strconv.Atoi(c.Query("limit"))
-	offset, _ := strconv.Atoi(c.Query("offset"))
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
 
 	h.logger.WithFields(logrus.Fields{
-		"user_id":      userID,
-		"service_name": serviceName,
-		"limit":        limit,
-		"offset":       offset,
+		"user_id":      filter.UserID,
+		"service_name": filter.ServiceName,
+		"tags":         filter.Tags,
+		"active_at":    filter.ActiveAt,
+		"statuses":     filter.Statuses,
+		"limit":        filter.Limit,
+		"offset":       filter.Offset,
 	}).Info("Listing subscriptions")
 
-	subscriptions, err := h.service.List(userID, serviceName, limit, offset)
+	subscriptions, err := h.service.List(filter)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to list subscriptions")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subscriptions"})
@@ -145,7 +150,7 @@ func (h *SubscriptionHandler) List(c *gin.Context) {
 
 // Update обновляет подписку
 // @Summary Обновить подписку
-// @Description Обновляет существующую подписку
+// @Description Обновляет существующую подписку. При переносе start_date без phases пробный и вводный периоды сдвигаются вместе с началом подписки.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
@@ -154,6 +159,8 @@ func (h *SubscriptionHandler) List(c *gin.Context) {
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /subscriptions/{id} [put]
 func (h *SubscriptionHandler) Update(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -173,7 +180,7 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 	err = h.service.Update(id, &req)
 	if err != nil {
 		h.logger.WithError(err).WithField("id", id).Error("Failed to update subscription")
-		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		c.JSON(errorStatus(err), errorBody(err, "Failed to update subscription"))
 		return
 	}
 
@@ -190,6 +197,7 @@ func (h *SubscriptionHandler) Update(c *gin.Context) {
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
 // @Router /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) Delete(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -203,7 +211,7 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 	err = h.service.Delete(id)
 	if err != nil {
 		h.logger.WithError(err).WithField("id", id).Error("Failed to delete subscription")
-		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		c.JSON(errorStatus(err), errorBody(err, "Failed to delete subscription"))
 		return
 	}
 
@@ -213,55 +221,776 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 
 // GetTotalCost подсчитывает общую стоимость подписок
 // @Summary Получить общую стоимость подписок
-// @Description Подсчитывает общую стоимость подписок за период с фильтрацией
+// @Description Подсчитывает сумму, уплаченную за период: складываются все списания подписок (по их расчетным периодам), попавшие в период. Бессрочные подписки учитываются до конца периода.
 // @Tags subscriptions
 // @Produce json
 // @Param start_period query string true "Начальный период (MM-YYYY)"
 // @Param end_period query string true "Конечный период (MM-YYYY)"
-// @Param user_id query string false "UUID пользователя"
+// @Param user_id query string false "UUID пользователя (учитывается только его доля в совместных подписках)"
 // @Param service_name query string false "Название сервиса"
+// @Param tags query string false "Теги через запятую; только подписки со всеми тегами"
+// @Param group_by query string false "Группировка через запятую: service_name, user_id, month"
+// @Param currency query string false "Валюта отчета (ISO 4217); по умолчанию — валюта подписок"
 // @Success 200 {object} models.TotalCostResponse
 // @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /subscriptions/total-cost [get]
 func (h *SubscriptionHandler) GetTotalCost(c *gin.Context) {
+	from, to, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	userID, serviceName, ok := parseFilters(c)
+	if !ok {
+		return
+	}
+
+	tags, ok := parseTags(c)
+	if !ok {
+		return
+	}
+
+	groupBy, err := models.ParseGroupBy(c.Query("group_by"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	currency, ok := parseCurrency(c)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"start_period": from,
+		"end_period":   to,
+		"user_id":      userID,
+		"service_name": serviceName,
+		"tags":         tags,
+		"group_by":     groupBy,
+		"currency":     currency,
+	}).Info("Calculating total cost")
+
+	result, err := h.service.GetTotalCost(userID, serviceName, tags, from, to, groupBy, currency)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to calculate total cost")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to calculate total cost"))
+		return
+	}
+
+	h.logger.WithField("total_cost", result.TotalCost).Info("Total cost calculated successfully")
+	c.JSON(http.StatusOK, result)
+}
+
+// GetCostBreakdown возвращает помесячную разбивку расходов
+// @Summary Получить расходы по месяцам
+// @Description Возвращает по одной записи на каждый месяц периода: сумму расходов, число активных подписок, начавшиеся и закончившиеся подписки
+// @Tags subscriptions
+// @Produce json
+// @Param start_period query string true "Начальный период (MM-YYYY)"
+// @Param end_period query string true "Конечный период (MM-YYYY)"
+// @Param user_id query string false "UUID пользователя"
+// @Param service_name query string false "Название сервиса"
+// @Param currency query string false "Валюта отчета (ISO 4217); по умолчанию — валюта подписок"
+// @Success 200 {object} models.CostBreakdownResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/cost-breakdown [get]
+func (h *SubscriptionHandler) GetCostBreakdown(c *gin.Context) {
+	from, to, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	userID, serviceName, ok := parseFilters(c)
+	if !ok {
+		return
+	}
+
+	currency, ok := parseCurrency(c)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"start_period": from,
+		"end_period":   to,
+		"user_id":      userID,
+		"service_name": serviceName,
+		"currency":     currency,
+	}).Info("Calculating cost breakdown")
+
+	result, err := h.service.GetCostBreakdown(userID, serviceName, from, to, currency)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to calculate cost breakdown")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to calculate cost breakdown"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetForecast возвращает прогноз расходов
+// @Summary Прогноз расходов по месяцам
+// @Description Прогнозирует расходы на months месяцев вперед, начиная с текущего, с учетом дат окончания, расчетных периодов, запланированных изменений цен, окончания пробных периодов и пауз. Каждый месяц разбит по сервисам.
+// @Tags subscriptions
+// @Produce json
+// @Param months query int false "Горизонт прогноза в месяцах (1-36), по умолчанию 12"
+// @Param user_id query string false "UUID пользователя (учитывается только его доля в совместных подписках)"
+// @Param service_name query string false "Название сервиса"
+// @Param currency query string false "Валюта отчета (ISO 4217); по умолчанию — валюта подписок"
+// @Success 200 {object} models.ForecastResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/forecast [get]
+func (h *SubscriptionHandler) GetForecast(c *gin.Context) {
+	months := 12
+	if monthsStr := c.Query("months"); monthsStr != "" {
+		var err error
+		if months, err = strconv.Atoi(monthsStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid months format"})
+			return
+		}
+	}
+
+	userID, serviceName, ok := parseFilters(c)
+	if !ok {
+		return
+	}
+
+	currency, ok := parseCurrency(c)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"months":       months,
+		"user_id":      userID,
+		"service_name": serviceName,
+		"currency":     currency,
+	}).Info("Calculating spending forecast")
+
+	result, err := h.service.GetForecast(userID, serviceName, months, currency)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to calculate spending forecast")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to calculate spending forecast"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetPriceHistory возвращает историю цен подписки
+// @Summary Получить историю цен подписки
+// @Description Возвращает цены подписки с месяцами вступления в силу и рост цены за все время
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} models.PriceHistoryResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/{id}/price-history [get]
+func (h *SubscriptionHandler) GetPriceHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	h.logger.WithField("subscription_id", id).Info("Getting subscription price history")
+
+	history, err := h.service.GetPriceHistory(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to get price history")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to get price history"))
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// ListMembers возвращает участников совместной подписки
+// @Summary Получить участников подписки
+// @Description Возвращает участников совместной подписки и их суммы с одного списания по текущей цене; остаток оплачивает владелец
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} models.MembersResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/{id}/members [get]
+func (h *SubscriptionHandler) ListMembers(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	members, err := h.service.ListMembers(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to list subscription members")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to list subscription members"))
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+// AddMember добавляет участника совместной подписки
+// @Summary Добавить участника подписки
+// @Description Добавляет участника с долей в процентах или фиксированной суммой с каждого списания. Доли не могут превышать полную цену.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param member body models.AddMemberRequest true "Участник и его доля"
+// @Success 201 {object} models.SubscriptionMember
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /subscriptions/{id}/members [post]
+func (h *SubscriptionHandler) AddMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.AddMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"subscription_id": id,
+		"user_id":         req.UserID,
+	}).Info("Adding subscription member")
+
+	member, err := h.service.AddMember(id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to add subscription member")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to add subscription member"))
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// RemoveMember удаляет участника совместной подписки
+// @Summary Удалить участника подписки
+// @Description Удаляет участника; его долю дальше оплачивает владелец
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param user_id path string true "UUID участника"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /subscriptions/{id}/members/{user_id} [delete]
+func (h *SubscriptionHandler) RemoveMember(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	if err := h.service.RemoveMember(id, userID); err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to remove subscription member")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to remove subscription member"))
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"subscription_id": id,
+		"user_id":         userID,
+	}).Info("Subscription member removed")
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed successfully"})
+}
+
+// Pause приостанавливает подписку
+// @Summary Приостановить подписку
+// @Description Открывает паузу с указанного месяца (по умолчанию — с текущего). Месяцы паузы не оплачиваются и не считаются активными.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param pause body models.PauseRequest false "Месяц начала паузы"
+// @Success 200 {object} models.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) Pause(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.PauseRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.WithField("subscription_id", id).Info("Pausing subscription")
+
+	subscription, err := h.service.Pause(id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to pause subscription")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to pause subscription"))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// Resume возобновляет подписку
+// @Summary Возобновить подписку
+// @Description Закрывает текущую паузу: подписка снова оплачивается с указанного месяца (по умолчанию — с текущего)
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param resume body models.ResumeRequest false "Месяц возобновления"
+// @Success 200 {object} models.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) Resume(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.ResumeRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.WithField("subscription_id", id).Info("Resuming subscription")
+
+	subscription, err := h.service.Resume(id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to resume subscription")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to resume subscription"))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// ChangeStatus переводит подписку в другой статус
+// @Summary Сменить статус подписки
+// @Description Ручной переход: paused — пауза с текущего месяца; active — возобновление или отзыв отмены в окне отмены; pending_cancellation — отмена в конце оплаченного периода; cancelled — отмена с окончанием текущим месяцем. trialing и expired выставляются автоматически.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param status body models.StatusChangeRequest true "Новый статус и причина"
+// @Success 200 {object} models.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /subscriptions/{id}/status [post]
+func (h *SubscriptionHandler) ChangeStatus(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.StatusChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"subscription_id": id,
+		"status":          req.Status,
+	}).Info("Changing subscription status")
+
+	subscription, err := h.service.ChangeStatus(id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to change subscription status")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to change subscription status"))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// GetStatusHistory возвращает журнал смены статусов подписки
+// @Summary Получить историю статусов подписки
+// @Description Возвращает переходы подписки между статусами с временем и причиной
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {array} models.StatusTransition
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /subscriptions/{id}/status-history [get]
+func (h *SubscriptionHandler) GetStatusHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	transitions, err := h.service.GetStatusHistory(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to get subscription status history")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to get subscription status history"))
+		return
+	}
+
+	c.JSON(http.StatusOK, transitions)
+}
+
+// Cancel отменяет подписку
+// @Summary Отменить подписку
+// @Description immediate — подписка заканчивается текущим месяцем; at_period_end — последним месяцем оплаченного расчетного периода. Отмену можно отозвать до undo_until.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Param cancel body models.CancelRequest true "Режим и причина отмены"
+// @Success 200 {object} models.CancelResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /subscriptions/{id}/cancel [post]
+func (h *SubscriptionHandler) Cancel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	var req models.CancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"subscription_id": id,
+		"mode":            req.Mode,
+		"reason_code":     req.ReasonCode,
+	}).Info("Cancelling subscription")
+
+	result, err := h.service.Cancel(id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to cancel subscription")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to cancel subscription"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// UndoCancel отзывает отмену подписки
+// @Summary Отозвать отмену подписки
+// @Description Восстанавливает end_date и статус, которые были до последней отмены. Доступно только в окне отмены.
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "ID подписки"
+// @Success 200 {object} models.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /subscriptions/{id}/cancel/undo [post]
+func (h *SubscriptionHandler) UndoCancel(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID format"})
+		return
+	}
+
+	h.logger.WithField("subscription_id", id).Info("Undoing subscription cancellation")
+
+	subscription, err := h.service.UndoCancel(id)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", id).Error("Failed to undo subscription cancellation")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to undo subscription cancellation"))
+		return
+	}
+
+	c.JSON(http.StatusOK, subscription)
+}
+
+// GetCancellationReasons возвращает отчет по причинам отмен
+// @Summary Причины отмен подписок
+// @Description Считает действующие (неотозванные) отмены, сделанные в периоде, по кодам причин
+// @Tags subscriptions
+// @Produce json
+// @Param start_period query string true "Начальный месяц (MM-YYYY)"
+// @Param end_period query string true "Конечный месяц (MM-YYYY)"
+// @Param user_id query string false "UUID пользователя"
+// @Success 200 {object} models.CancellationReportResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/cancellation-reasons [get]
+func (h *SubscriptionHandler) GetCancellationReasons(c *gin.Context) {
+	from, to, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	userID, _, ok := parseFilters(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.service.GetCancellationReasons(userID, from, to)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to build cancellation report")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to build cancellation report"))
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ListTrialEnding возвращает подписки с заканчивающимся пробным периодом
+// @Summary Подписки с заканчивающимся пробным периодом
+// @Description Возвращает подписки, бесплатный пробный период которых заканчивается в указанном интервале месяцев
+// @Tags subscriptions
+// @Produce json
+// @Param start_period query string true "Начальный месяц (MM-YYYY)"
+// @Param end_period query string true "Конечный месяц (MM-YYYY)"
+// @Param user_id query string false "UUID пользователя"
+// @Success 200 {array} models.Subscription
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /subscriptions/trial-ending [get]
+func (h *SubscriptionHandler) ListTrialEnding(c *gin.Context) {
+	from, to, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	userID, _, ok := parseFilters(c)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"start_period": from,
+		"end_period":   to,
+		"user_id":      userID,
+	}).Info("Listing subscriptions with ending trials")
+
+	subscriptions, err := h.service.ListTrialEnding(userID, from, to)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list subscriptions with ending trials")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, subscriptions)
+}
+
+// ListDuplicates возвращает вероятные дубликаты подписок пользователя
+// @Summary Дубликаты подписок пользователя
+// @Description Находит подписки пользователя на один и тот же сервис (по каталогу или похожему названию) с пересекающимися датами, а также такие же подписки участников общих с пользователем подписок
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Success 200 {object} models.DuplicatesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/duplicates [get]
+func (h *SubscriptionHandler) ListDuplicates(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	h.logger.WithField("user_id", userID).Info("Looking for duplicate subscriptions")
+
+	result, err := h.service.FindDuplicates(userID)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to find duplicate subscriptions")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to find duplicate subscriptions"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetUpcomingCharges возвращает календарь ближайших списаний пользователя
+// @Summary Ближайшие списания пользователя
+// @Description Проецирует подписки пользователя вперед и возвращает ожидаемые даты и суммы списаний на days дней, начиная с сегодня. Отмененные подписки, месяцы паузы и пробный период не учитываются.
+// @Tags users
+// @Produce json
+// @Param id path string true "ID пользователя (UUID)"
+// @Param days query int false "Длина окна в днях (1-366), по умолчанию 30"
+// @Param currency query string false "Валюта итога (ISO 4217); по умолчанию — валюта подписок"
+// @Success 200 {object} models.UpcomingChargesResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /users/{id}/upcoming-charges [get]
+func (h *SubscriptionHandler) GetUpcomingCharges(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UUID format"})
+		return
+	}
+
+	days := 30
+	if daysStr := c.Query("days"); daysStr != "" {
+		if days, err = strconv.Atoi(daysStr); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days format"})
+			return
+		}
+	}
+
+	currency, ok := parseCurrency(c)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"user_id":  userID,
+		"days":     days,
+		"currency": currency,
+	}).Info("Listing upcoming charges")
+
+	result, err := h.service.GetUpcomingCharges(userID, days, currency)
+	if err != nil {
+		h.logger.WithError(err).WithField("user_id", userID).Error("Failed to list upcoming charges")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to list upcoming charges"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// parsePeriod разбирает обязательные параметры start_period и end_period (MM-YYYY).
+// При ошибке сам отвечает 400 и возвращает ok = false.
+func parsePeriod(c *gin.Context) (from, to models.Month, ok bool) {
 	startPeriod := c.Query("start_period")
 	endPeriod := c.Query("end_period")
 
 	if startPeriod == "" || endPeriod == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "start_period and end_period are required"})
-		return
+		return 0, 0, false
+	}
+
+	from, err := models.ParseMonth(startPeriod)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_period: " + err.Error()})
+		return 0, 0, false
+	}
+
+	to, err = models.ParseMonth(endPeriod)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_period: " + err.Error()})
+		return 0, 0, false
 	}
 
-	var userID *uuid.UUID
+	if to < from {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_period must not be before start_period"})
+		return 0, 0, false
+	}
+
+	return from, to, true
+}
+
+// parseFilters разбирает общие фильтры user_id и service_name.
+// При ошибке сам отвечает 400 и возвращает ok = false.
+func parseFilters(c *gin.Context) (userID *uuid.UUID, serviceName *string, ok bool) {
 	if userIDStr := c.Query("user_id"); userIDStr != "" {
 		parsedUUID, err := uuid.Parse(userIDStr)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id format"})
-			return
+			return nil, nil, false
 		}
 		userID = &parsedUUID
 	}
 
-	var serviceName *string
 	if serviceNameStr := c.Query("service_name"); serviceNameStr != "" {
 		serviceName = &serviceNameStr
 	}
 
-	h.logger.WithFields(logrus.Fields{
-		"start_period": startPeriod,
-		"end_period":   endPeriod,
-		"user_id":      userID,
-		"service_name": serviceName,
-	}).Info("Calculating total cost")
+	return userID, serviceName, true
+}
+
+// parseSubscriptionFilter разбирает фильтры списка подписок: user_id, service_name,
+// tags, active и status. При ошибке сам отвечает 400 и возвращает ok = false.
+func parseSubscriptionFilter(c *gin.Context) (models.SubscriptionFilter, bool) {
+	userID, serviceName, ok := parseFilters(c)
+	if !ok {
+		return models.SubscriptionFilter{}, false
+	}
+
+	tags, ok := parseTags(c)
+	if !ok {
+		return models.SubscriptionFilter{}, false
+	}
+
+	filter := models.SubscriptionFilter{
+		UserID:      userID,
+		ServiceName: serviceName,
+		Tags:        tags,
+	}
+
+	if active, _ := strconv.ParseBool(c.Query("active")); active {
+		now := models.MonthOf(time.Now())
+		filter.ActiveAt = &now
+	}
 
-	result, err := h.service.GetTotalCost(userID, serviceName, startPeriod, endPeriod)
+	statuses, err := models.ParseStatuses(c.Query("status"))
 	if err != nil {
-		h.logger.WithError(err).Error("Failed to calculate total cost")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate total cost"})
-		return
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.SubscriptionFilter{}, false
 	}
+	filter.Statuses = statuses
 
-	h.logger.WithField("total_cost", result.TotalCost).Info("Total cost calculated successfully")
-	c.JSON(http.StatusOK, result)
+	return filter, true
+}
+
+// parseTags разбирает необязательный параметр tags — теги через запятую.
+// При ошибке сам отвечает 400 и возвращает ok = false.
+func parseTags(c *gin.Context) ([]string, bool) {
+	tags, err := models.ParseTags(c.Query("tags"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tags: " + err.Error()})
+		return nil, false
+	}
+	return tags, true
+}
+
+// parseCurrency разбирает необязательный параметр currency (ISO 4217).
+// При ошибке сам отвечает 400 и возвращает ok = false.
+func parseCurrency(c *gin.Context) (string, bool) {
+	currency := strings.ToUpper(strings.TrimSpace(c.Query("currency")))
+	if currency == "" {
+		return "", true
+	}
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid currency, expected ISO 4217 code"})
+		return "", false
+	}
+	return currency, true
 }
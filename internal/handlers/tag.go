@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"go-dev/internal/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ListTags возвращает теги подписок с числом подписок и расходами
+// @Summary Теги подписок
+// @Description Возвращает теги подписок, активных в периоде, с числом подписок и расходами за период. Подписка с несколькими тегами учитывается в каждом из них. Без периода используется текущий месяц.
+// @Tags tags
+// @Produce json
+// @Param start_period query string false "Начальный период (MM-YYYY)"
+// @Param end_period query string false "Конечный период (MM-YYYY)"
+// @Param user_id query string false "UUID пользователя (учитывается только его доля в совместных подписках)"
+// @Param currency query string false "Валюта отчета (ISO 4217); по умолчанию — валюта подписок"
+// @Success 200 {object} models.TagsResponse
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /tags [get]
+func (h *SubscriptionHandler) ListTags(c *gin.Context) {
+	from := models.MonthOf(time.Now())
+	to := from
+	if c.Query("start_period") != "" || c.Query("end_period") != "" {
+		var ok bool
+		if from, to, ok = parsePeriod(c); !ok {
+			return
+		}
+	}
+
+	userID, _, ok := parseFilters(c)
+	if !ok {
+		return
+	}
+
+	currency, ok := parseCurrency(c)
+	if !ok {
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"start_period": from,
+		"end_period":   to,
+		"user_id":      userID,
+		"currency":     currency,
+	}).Info("Listing tags")
+
+	result, err := h.service.ListTags(userID, from, to, currency)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to list tags")
+		c.JSON(errorStatus(err), errorBody(err, "Failed to list tags"))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
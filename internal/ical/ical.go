@@ -0,0 +1,144 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"go-dev/internal/models"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+	// maxLineOctets — наибольшая длина строки содержимого без CRLF (RFC 5545, 3.1)
+	maxLineOctets = 75
+	// refreshInterval — как часто календарным приложениям перечитывать календарь
+	refreshInterval = "PT6H"
+)
+
+// Calendar — календарь для публикации по ссылке
+type Calendar struct {
+	Name        string
+	Events      []models.CalendarEvent
+	GeneratedAt time.Time // попадает в DTSTAMP событий
+}
+
+// Write пишет календарь в формате iCalendar (RFC 5545). События — на весь день;
+// повторяющиеся описываются RRULE с исключениями EXDATE.
+func Write(w io.Writer, calendar *Calendar) error {
+	out := &writer{w: bufio.NewWriter(w)}
+	stamp := calendar.GeneratedAt.UTC().Format(dateTimeLayout)
+
+	out.line("BEGIN:VCALENDAR")
+	out.line("VERSION:2.0")
+	out.line("PRODID:-//go-dev//Subscription renewals//EN")
+	out.line("CALSCALE:GREGORIAN")
+	out.line("METHOD:PUBLISH")
+	out.line("X-WR-CALNAME:" + escapeText(calendar.Name))
+	out.line("REFRESH-INTERVAL;VALUE=DURATION:" + refreshInterval)
+	out.line("X-PUBLISHED-TTL:" + refreshInterval)
+
+	for _, event := range calendar.Events {
+		out.line("BEGIN:VEVENT")
+		out.line("UID:" + event.UID)
+		out.line("DTSTAMP:" + stamp)
+		if !event.Modified.IsZero() {
+			out.line("LAST-MODIFIED:" + event.Modified.UTC().Format(dateTimeLayout))
+		}
+		out.line("DTSTART;VALUE=DATE:" + event.Date.Format(dateLayout))
+		out.line("DTEND;VALUE=DATE:" + event.Date.AddDate(0, 0, 1).Format(dateLayout))
+		if event.Recurrence != nil {
+			out.line("RRULE:" + recurrenceRule(*event.Recurrence, event.Date, event.BillingDay, event.Until))
+			for i := 0; i < len(event.ExDates); i += exDatesPerLine {
+				out.line("EXDATE;VALUE=DATE:" + joinDates(event.ExDates[i:min(i+exDatesPerLine, len(event.ExDates))]))
+			}
+		}
+		out.line("SUMMARY:" + escapeText(event.Summary))
+		if event.Description != "" {
+			out.line("DESCRIPTION:" + escapeText(event.Description))
+		}
+		out.line("CATEGORIES:" + strings.ToUpper(string(event.Kind)))
+		out.line("TRANSP:TRANSPARENT")
+		out.line("END:VEVENT")
+	}
+
+	out.line("END:VCALENDAR")
+	if out.err != nil {
+		return out.err
+	}
+	return out.w.Flush()
+}
+
+// exDatesPerLine — сколько дат исключений пишется в одно свойство EXDATE
+const exDatesPerLine = 12
+
+// recurrenceRule строит RRULE по расчетному периоду. Списания в день day, которого нет
+// в коротких месяцах, приходятся на последний день месяца — как и в расчете списаний:
+// из дней 28..day выбирается последний существующий. День берется из day, а не из start:
+// первое списание в коротком месяце уже сдвинуто на его последний день.
+func recurrenceRule(cycle models.BillingCycle, start time.Time, day int, until *time.Time) string {
+	var rule string
+	switch cycle.Period {
+	case models.BillingWeek:
+		rule = fmt.Sprintf("FREQ=WEEKLY;INTERVAL=%d", cycle.Interval)
+	case models.BillingYear:
+		rule = fmt.Sprintf("FREQ=YEARLY;INTERVAL=%d;BYMONTH=%d", cycle.Interval, start.Month())
+	default:
+		rule = fmt.Sprintf("FREQ=MONTHLY;INTERVAL=%d", cycle.Months())
+	}
+	if day == 0 {
+		day = start.Day()
+	}
+	if cycle.Period != models.BillingWeek && day > 28 {
+		days := make([]string, 0, day-27)
+		for d := 28; d <= day; d++ {
+			days = append(days, fmt.Sprint(d))
+		}
+		rule += ";BYMONTHDAY=" + strings.Join(days, ",") + ";BYSETPOS=-1"
+	}
+	if until != nil {
+		rule += ";UNTIL=" + until.Format(dateLayout)
+	}
+	return rule
+}
+
+func joinDates(dates []time.Time) string {
+	formatted := make([]string, len(dates))
+	for i, date := range dates {
+		formatted[i] = date.Format(dateLayout)
+	}
+	return strings.Join(formatted, ",")
+}
+
+// escapeText экранирует значение типа TEXT (RFC 5545, 3.3.11)
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "").Replace(s)
+}
+
+// writer пишет строки содержимого с CRLF, перенося длинные строки (RFC 5545, 3.1)
+type writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (w *writer) line(s string) {
+	if w.err != nil {
+		return
+	}
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		w.w.WriteString(s[:cut])
+		w.w.WriteString("\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1 // пробел в начале строки продолжения
+	}
+	w.w.WriteString(s)
+	_, w.err = w.w.WriteString("\r\n")
+}
@@ -0,0 +1,139 @@
+package ical
+
+import (
+	"bytes"
+	"go-dev/internal/models"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestWriteFoldsLongLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary string
+		want    string // SUMMARY после развертывания строк
+	}{
+		{
+			name:    "short",
+			summary: "Netflix: $15.49",
+			want:    `SUMMARY:Netflix: $15.49`,
+		},
+		{
+			name:    "exactly one line",
+			summary: strings.Repeat("a", maxLineOctets-len("SUMMARY:")),
+			want:    "SUMMARY:" + strings.Repeat("a", maxLineOctets-len("SUMMARY:")),
+		},
+		{
+			name:    "one octet over",
+			summary: strings.Repeat("a", maxLineOctets-len("SUMMARY:")+1),
+			want:    "SUMMARY:" + strings.Repeat("a", maxLineOctets-len("SUMMARY:")+1),
+		},
+		{
+			name:    "several continuation lines",
+			summary: strings.Repeat("0123456789", 20),
+			want:    "SUMMARY:" + strings.Repeat("0123456789", 20),
+		},
+		{
+			name:    "multibyte runes are not split",
+			summary: strings.Repeat("Подписка на Кинопоиск, ", 8),
+			want:    "SUMMARY:" + strings.Repeat(`Подписка на Кинопоиск\, `, 8),
+		},
+		{
+			name:    "escaped text",
+			summary: "Plan; family, shared\nsecond line",
+			want:    `SUMMARY:Plan\; family\, shared\nsecond line`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			calendar := &Calendar{
+				Name:        "Subscriptions",
+				GeneratedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				Events: []models.CalendarEvent{{
+					UID:     "billing-1@go-dev",
+					Kind:    models.CalendarBilling,
+					Date:    time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+					Summary: tt.summary,
+				}},
+			}
+			if err := Write(&buf, calendar); err != nil {
+				t.Fatal(err)
+			}
+			out := buf.String()
+
+			if !strings.HasSuffix(out, "\r\n") {
+				t.Error("calendar does not end with CRLF")
+			}
+			for i, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+				if len(line) > maxLineOctets {
+					t.Errorf("line %d is %d octets long: %q", i, len(line), line)
+				}
+				if !utf8.ValidString(line) {
+					t.Errorf("line %d splits a UTF-8 sequence: %q", i, line)
+				}
+				if strings.ContainsAny(line, "\r\n") {
+					t.Errorf("line %d contains a bare line break: %q", i, line)
+				}
+			}
+
+			unfolded := strings.Split(strings.ReplaceAll(out, "\r\n ", ""), "\r\n")
+			found := false
+			for _, line := range unfolded {
+				if strings.HasPrefix(line, "SUMMARY:") {
+					found = true
+					if line != tt.want {
+						t.Errorf("unfolded SUMMARY = %q, want %q", line, tt.want)
+					}
+				}
+			}
+			if !found {
+				t.Error("SUMMARY not written")
+			}
+		})
+	}
+}
+
+func TestRecurrenceRule(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse(dateLayout, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	until := date("20251231")
+
+	tests := []struct {
+		name  string
+		cycle models.BillingCycle
+		start string
+		day   int
+		until *time.Time
+		want  string
+	}{
+		{"monthly", models.BillingCycle{Period: models.BillingMonth, Interval: 1}, "20250115", 15, nil,
+			"FREQ=MONTHLY;INTERVAL=1"},
+		{"monthly on the 31st from February", models.BillingCycle{Period: models.BillingMonth, Interval: 1}, "20250228", 31, &until,
+			"FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=28,29,30,31;BYSETPOS=-1;UNTIL=20251231"},
+		{"quarterly on the 30th", models.BillingCycle{Period: models.BillingQuarter, Interval: 1}, "20241130", 30, nil,
+			"FREQ=MONTHLY;INTERVAL=3;BYMONTHDAY=28,29,30;BYSETPOS=-1"},
+		{"yearly on leap day", models.BillingCycle{Period: models.BillingYear, Interval: 1}, "20240229", 29, nil,
+			"FREQ=YEARLY;INTERVAL=1;BYMONTH=2;BYMONTHDAY=28,29;BYSETPOS=-1"},
+		{"weekly ignores the billing day", models.BillingCycle{Period: models.BillingWeek, Interval: 2}, "20250130", 31, nil,
+			"FREQ=WEEKLY;INTERVAL=2"},
+		{"day defaults to the start date", models.BillingCycle{Period: models.BillingMonth, Interval: 1}, "20250130", 0, nil,
+			"FREQ=MONTHLY;INTERVAL=1;BYMONTHDAY=28,29,30;BYSETPOS=-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recurrenceRule(tt.cycle, date(tt.start), tt.day, tt.until); got != tt.want {
+				t.Errorf("recurrenceRule() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
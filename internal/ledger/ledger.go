@@ -0,0 +1,92 @@
+package ledger
+
+import (
+	"bufio"
+	"fmt"
+	"go-dev/internal/models"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Journal — списания пользователя за период для выгрузки в журнал
+type Journal struct {
+	UserID       uuid.UUID
+	From, To     models.Month
+	Accounts     *models.LedgerAccounts
+	Transactions []models.LedgerTransaction
+	OpenAccounts bool      // объявлять используемые счета (open в beancount, account в hledger)
+	GeneratedAt  time.Time // время выгрузки, попадает в заголовок OFX
+}
+
+// Write пишет журнал в выбранном формате
+func Write(w io.Writer, format models.LedgerFormat, journal *Journal) error {
+	buf := bufio.NewWriter(w)
+	var err error
+	switch format {
+	case models.LedgerBeancount:
+		err = writeBeancount(buf, journal)
+	case models.LedgerHledger:
+		err = writeHledger(buf, journal)
+	case models.LedgerOFX:
+		err = writeOFX(buf, journal)
+	default:
+		err = fmt.Errorf("unsupported ledger format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// ContentType возвращает MIME-тип и расширение файла формата
+func ContentType(format models.LedgerFormat) (contentType, extension string) {
+	switch format {
+	case models.LedgerOFX:
+		return "application/x-ofx", "ofx"
+	case models.LedgerHledger:
+		return "text/plain; charset=utf-8", "journal"
+	default:
+		return "text/plain; charset=utf-8", "beancount"
+	}
+}
+
+// usedAccounts возвращает счета журнала в алфавитном порядке: счета расходов и счет оплаты
+func usedAccounts(journal *Journal) []string {
+	seen := map[string]bool{journal.Accounts.PaymentAccount: true}
+	for _, tx := range journal.Transactions {
+		seen[tx.Account] = true
+	}
+	accounts := make([]string, 0, len(seen))
+	for account := range seen {
+		accounts = append(accounts, account)
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// formatDecimal переводит копейки/центы в десятичную запись: 1549 → 15.49
+func formatDecimal(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+func header(journal *Journal) string {
+	return fmt.Sprintf("Subscription charges of user %s for %s - %s", journal.UserID, journal.From, journal.To)
+}
+
+// singleLine убирает переводы строк и символы, которые формат журнала считает разметкой
+func singleLine(s, markup string) string {
+	return strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(markup, r) {
+			return ' '
+		}
+		return r
+	}, s)), " ")
+}
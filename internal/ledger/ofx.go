@@ -0,0 +1,75 @@
+package ledger
+
+import (
+	"encoding/xml"
+	"fmt"
+	"go-dev/internal/models"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ofxAccountIDLength — наибольшая длина ACCTID в OFX
+const ofxAccountIDLength = 22
+
+// writeOFX пишет выписку OFX 2.2 по кредитной карте, с которой оплачиваются подписки.
+// В OFX у выписки одна валюта, поэтому списания в разных валютах попадают в отдельные
+// выписки. Счет расходов передается в MEMO, чтобы программа импорта могла его сопоставить.
+func writeOFX(w io.Writer, journal *Journal) error {
+	byCurrency := make(map[string][]models.LedgerTransaction)
+	for _, tx := range journal.Transactions {
+		byCurrency[tx.Currency] = append(byCurrency[tx.Currency], tx)
+	}
+	currencies := make([]string, 0, len(byCurrency))
+	for currency := range byCurrency {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	if len(currencies) == 0 {
+		currencies = []string{models.DefaultCurrency} // пустая выписка
+	}
+
+	now := journal.GeneratedAt.UTC().Format("20060102150405")
+	start := journal.From.Time().Format("20060102")
+	end := journal.To.AddMonths(1).Time().Format("20060102")
+	accountID := strings.ReplaceAll(journal.UserID.String(), "-", "")[:ofxAccountIDLength]
+
+	fmt.Fprint(w, xml.Header)
+	fmt.Fprintln(w, `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`)
+	fmt.Fprintln(w, "<OFX>")
+	fmt.Fprintf(w, "<SIGNONMSGSRSV1><SONRS><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>"+
+		"<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE></SONRS></SIGNONMSGSRSV1>\n", now)
+	fmt.Fprintln(w, "<CREDITCARDMSGSRSV1>")
+	for i, currency := range currencies {
+		fmt.Fprintf(w, "<CCSTMTTRNRS><TRNUID>%d</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n", i+1)
+		fmt.Fprintf(w, "<CCSTMTRS><CURDEF>%s</CURDEF><CCACCTFROM><ACCTID>%s</ACCTID></CCACCTFROM>\n", currency, accountID)
+		fmt.Fprintf(w, "<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", start, end)
+
+		balance := 0
+		for _, tx := range byCurrency[currency] {
+			balance -= tx.Amount
+			fmt.Fprintf(w, "<STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT>"+
+				"<FITID>sub%d-%s</FITID><NAME>%s</NAME><MEMO>%s</MEMO></STMTTRN>\n",
+				tx.Date.Format("20060102"), formatDecimal(-tx.Amount), tx.SubscriptionID, tx.Date.Format("20060102"),
+				escapeOFX(tx.ServiceName, 32), escapeOFX(tx.Account, 255))
+		}
+
+		fmt.Fprintln(w, "</BANKTRANLIST>")
+		fmt.Fprintf(w, "<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL></CCSTMTRS></CCSTMTTRNRS>\n",
+			formatDecimal(balance), end)
+	}
+	fmt.Fprintln(w, "</CREDITCARDMSGSRSV1>")
+	_, err := fmt.Fprintln(w, "</OFX>")
+	return err
+}
+
+// escapeOFX обрезает значение до limit символов и экранирует его для XML
+func escapeOFX(s string, limit int) string {
+	s = singleLine(s, "")
+	if runes := []rune(s); len(runes) > limit {
+		s = string(runes[:limit])
+	}
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(s))
+	return escaped.String()
+}
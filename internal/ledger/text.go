@@ -0,0 +1,61 @@
+package ledger
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// writeBeancount пишет транзакции beancount; номер подписки и категория — в метаданных
+func writeBeancount(w io.Writer, journal *Journal) error {
+	fmt.Fprintf(w, "; %s\n", header(journal))
+
+	if journal.OpenAccounts {
+		fmt.Fprintln(w)
+		opened := journal.From.Time().Format("2006-01-02")
+		for _, account := range usedAccounts(journal) {
+			fmt.Fprintf(w, "%s open %s\n", opened, account)
+		}
+	}
+
+	for _, tx := range journal.Transactions {
+		fmt.Fprintf(w, "\n%s * %s %s\n", tx.Date.Format("2006-01-02"),
+			strconv.Quote(singleLine(tx.ServiceName, "")), strconv.Quote("Subscription charge"))
+		fmt.Fprintf(w, "  subscription_id: %d\n", tx.SubscriptionID)
+		if tx.Category != nil {
+			fmt.Fprintf(w, "  category: %s\n", strconv.Quote(singleLine(*tx.Category, "")))
+		}
+		fmt.Fprintf(w, "  %s  %s %s\n", tx.Account, formatDecimal(tx.Amount), tx.Currency)
+		if _, err := fmt.Fprintf(w, "  %s\n", journal.Accounts.PaymentAccount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHledger пишет транзакции hledger; сервис — получатель (payee), номер подписки
+// и категория — теги в комментарии
+func writeHledger(w io.Writer, journal *Journal) error {
+	fmt.Fprintf(w, "; %s\n", header(journal))
+
+	if journal.OpenAccounts {
+		fmt.Fprintln(w)
+		for _, account := range usedAccounts(journal) {
+			fmt.Fprintf(w, "account %s\n", account)
+		}
+	}
+
+	for _, tx := range journal.Transactions {
+		tags := fmt.Sprintf("subscription_id:%d", tx.SubscriptionID)
+		if tx.Category != nil {
+			tags += ", category:" + singleLine(*tx.Category, ",;")
+		}
+		fmt.Fprintf(w, "\n%s %s | Subscription charge  ; %s\n", tx.Date.Format("2006-01-02"),
+			singleLine(tx.ServiceName, "|;"), tags)
+		fmt.Fprintf(w, "    %s  %s %s\n", tx.Account, formatDecimal(tx.Amount), tx.Currency)
+		if _, err := fmt.Fprintf(w, "    %s\n", journal.Accounts.PaymentAccount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -10,7 +11,7 @@ import (
 func Logger(logger *logrus.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		path := c.Request.URL.Path
+		path := redactPath(c.Request.URL.Path)
 		raw := c.Request.URL.RawQuery
 
 		// Process request
@@ -42,3 +43,20 @@ func Logger(logger *logrus.Logger) gin.HandlerFunc {
 		}
 	}
 }
+
+// secretPathPrefixes — префиксы путей, следующий сегмент которых является секретом
+// (токен календарной подписки) и не должен попадать в журнал
+var secretPathPrefixes = []string{"/api/v1/calendar/"}
+
+// redactPath заменяет секретный сегмент пути на "REDACTED"
+func redactPath(path string) string {
+	for _, prefix := range secretPathPrefixes {
+		if rest, ok := strings.CutPrefix(path, prefix); ok && rest != "" {
+			if i := strings.IndexByte(rest, '/'); i >= 0 {
+				return prefix + "REDACTED" + rest[i:]
+			}
+			return prefix + "REDACTED"
+		}
+	}
+	return path
+}
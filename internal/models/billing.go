@@ -0,0 +1,66 @@
+package models
+
+import "math"
+
+// BillingPeriod — единица расчетного периода подписки
+type BillingPeriod string
+
+const (
+	BillingWeek    BillingPeriod = "week"
+	BillingMonth   BillingPeriod = "month"
+	BillingQuarter BillingPeriod = "quarter"
+	BillingYear    BillingPeriod = "year"
+)
+
+// Средняя длина года в неделях с учетом високосных лет
+const weeksPerYear = 365.25 / 7
+
+// BillingCycle — расчетный период: интервал из Interval единиц Period.
+// Произвольные циклы задаются интервалом, например раз в 2 недели или раз в 6 месяцев.
+type BillingCycle struct {
+	Period   BillingPeriod
+	Interval int
+}
+
+// Normalize подставляет значения по умолчанию: ежемесячно, интервал 1
+func (c BillingCycle) Normalize() BillingCycle {
+	if c.Period == "" {
+		c.Period = BillingMonth
+	}
+	if c.Interval < 1 {
+		c.Interval = 1
+	}
+	return c
+}
+
+// Months возвращает длину цикла в месяцах; для недельных циклов — 0
+func (c BillingCycle) Months() int {
+	switch c.Period {
+	case BillingQuarter:
+		return 3 * c.Interval
+	case BillingYear:
+		return 12 * c.Interval
+	case BillingWeek:
+		return 0
+	default:
+		return c.Interval
+	}
+}
+
+// CyclesPerYear возвращает среднее число списаний за год
+func (c BillingCycle) CyclesPerYear() float64 {
+	if c.Period == BillingWeek {
+		return weeksPerYear / float64(c.Interval)
+	}
+	return 12 / float64(c.Months())
+}
+
+// Annualize пересчитывает цену за цикл в годовую сумму
+func (c BillingCycle) Annualize(price int) int {
+	return int(math.Round(float64(price) * c.CyclesPerYear()))
+}
+
+// MonthlyEquivalent пересчитывает цену за цикл в среднемесячную сумму
+func (c BillingCycle) MonthlyEquivalent(price int) int {
+	return int(math.Round(float64(price) * c.CyclesPerYear() / 12))
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BudgetPeriod — период, за который действует лимит бюджета
+type BudgetPeriod string
+
+const (
+	BudgetMonthly BudgetPeriod = "month"
+	BudgetYearly  BudgetPeriod = "year" // календарный год
+)
+
+// BudgetScope — какие расходы учитываются в бюджете
+type BudgetScope string
+
+const (
+	ScopeAll      BudgetScope = "all"      // все расходы пользователя
+	ScopeCategory BudgetScope = "category" // подписки одной категории
+	ScopeService  BudgetScope = "service"  // подписки одного сервиса
+)
+
+// BudgetThresholds — доли лимита в процентах, при достижении которых создаются оповещения
+var BudgetThresholds = []int{80, 100}
+
+// Budget — лимит расходов пользователя за месяц или год
+type Budget struct {
+	ID          int          `json:"id" db:"id"`
+	UserID      uuid.UUID    `json:"user_id" db:"user_id"`
+	Period      BudgetPeriod `json:"period" db:"period" enums:"month,year"`
+	Amount      int          `json:"amount" db:"amount"` // лимит в копейках/центах
+	Currency    string       `json:"currency" db:"currency"`
+	Scope       BudgetScope  `json:"scope" db:"scope" enums:"all,category,service"`
+	Category    *string      `json:"category,omitempty" db:"category"`         // для scope = category
+	ServiceName *string      `json:"service_name,omitempty" db:"service_name"` // для scope = service
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// PeriodAt возвращает первый и последний месяцы периода бюджета, в который попадает месяц m
+func (b *Budget) PeriodAt(m Month) (from, to Month) {
+	if b.Period == BudgetYearly {
+		from = m - Month(m.Month()-time.January)
+		return from, from.AddMonths(11)
+	}
+	return m, m
+}
+
+// Covers сообщает, учитываются ли расходы подписки в бюджете
+func (b *Budget) Covers(sub *Subscription) bool {
+	switch b.Scope {
+	case ScopeCategory:
+		category := sub.EffectiveCategory()
+		return b.Category != nil && category != nil && strings.EqualFold(*category, *b.Category)
+	case ScopeService:
+		return b.ServiceName != nil && strings.EqualFold(sub.CanonicalServiceName(), *b.ServiceName)
+	default:
+		return true
+	}
+}
+
+type CreateBudgetRequest struct {
+	Period      BudgetPeriod `json:"period" binding:"required,oneof=month year" enums:"month,year"`
+	Amount      int          `json:"amount" binding:"required,min=1"`
+	Currency    string       `json:"currency,omitempty" binding:"omitempty,iso4217" example:"RUB"`
+	Scope       BudgetScope  `json:"scope,omitempty" binding:"omitempty,oneof=all category service" enums:"all,category,service"` // по умолчанию — all
+	Category    *string      `json:"category,omitempty"`
+	ServiceName *string      `json:"service_name,omitempty"`
+}
+
+type UpdateBudgetRequest struct {
+	Period   *BudgetPeriod `json:"period,omitempty" binding:"omitempty,oneof=month year" enums:"month,year"`
+	Amount   *int          `json:"amount,omitempty" binding:"omitempty,min=1"`
+	Currency *string       `json:"currency,omitempty" binding:"omitempty,iso4217"`
+}
+
+// BudgetStatus — бюджет с расходами за текущий период
+type BudgetStatus struct {
+	Budget
+	PeriodStart  string  `json:"period_start" example:"01-2024"` // MM-YYYY
+	PeriodEnd    string  `json:"period_end" example:"12-2024"`   // MM-YYYY
+	Spent        int     `json:"spent"`
+	SpentPercent float64 `json:"spent_percent"`
+}
+
+// BudgetAlert — оповещение о достижении порога бюджета; создается один раз за период
+type BudgetAlert struct {
+	ID          int       `json:"id" db:"id"`
+	BudgetID    int       `json:"budget_id" db:"budget_id"`
+	PeriodStart string    `json:"period_start" db:"period_start"` // MM-YYYY, первый месяц периода бюджета
+	Threshold   int       `json:"threshold" db:"threshold"`       // процент лимита
+	Spent       int       `json:"spent" db:"spent"`
+	Amount      int       `json:"amount" db:"amount"` // лимит на момент оповещения
+	Currency    string    `json:"currency" db:"currency"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
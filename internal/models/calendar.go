@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// CalendarEventKind — вид события календаря подписок
+type CalendarEventKind string
+
+const (
+	CalendarBilling  CalendarEventKind = "billing"   // повторяющееся списание
+	CalendarTrialEnd CalendarEventKind = "trial_end" // окончание пробного периода
+	CalendarEnd      CalendarEventKind = "end"       // окончание подписки
+)
+
+// CalendarEvent — событие календаря на весь день. UID не меняется при изменении
+// подписки, чтобы календарь обновлял событие, а не добавлял новое.
+type CalendarEvent struct {
+	UID         string
+	Kind        CalendarEventKind
+	Date        time.Time
+	Summary     string
+	Description string
+	Recurrence  *BillingCycle // повторение по расчетному периоду, начиная с Date
+	BillingDay  int           // день месяца помесячных повторений; 0 — день Date
+	Until       *time.Time    // последний день повторений
+	ExDates     []time.Time   // пропускаемые повторения: паузы и пробный период
+	Modified    time.Time
+}
+
+// CalendarFeed — секретная ссылка на календарь пользователя; токен показывается
+// только при создании
+type CalendarFeed struct {
+	Token     string    `json:"token" example:"Jm0f3v8Qm2Yz0XxkE6bq7sWcV9rTt1uA4nLdPpHhG5I"`
+	URL       string    `json:"url" example:"http://localhost:8080/api/v1/calendar/Jm0f3v8Qm2Yz0XxkE6bq7sWcV9rTt1uA4nLdPpHhG5I.ics"`
+	CreatedAt time.Time `json:"created_at"`
+}
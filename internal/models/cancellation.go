@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// CancellationMode — когда отмена вступает в силу
+type CancellationMode string
+
+const (
+	CancelImmediate   CancellationMode = "immediate"     // подписка заканчивается текущим месяцем
+	CancelAtPeriodEnd CancellationMode = "at_period_end" // подписка действует до конца оплаченного периода
+)
+
+// CancellationReason — код причины отмены для отчетов
+type CancellationReason string
+
+const (
+	ReasonTooExpensive    CancellationReason = "too_expensive"
+	ReasonNotUsing        CancellationReason = "not_using"
+	ReasonSwitchedService CancellationReason = "switched_service"
+	ReasonTechnicalIssues CancellationReason = "technical_issues"
+	ReasonTemporary       CancellationReason = "temporary"
+	ReasonOther           CancellationReason = "other"
+)
+
+// Cancellation — отмена подписки. Пока отмена не отозвана, ее можно
+// отменить до UndoUntil; при отзыве восстанавливаются прежние end_date и статус.
+type Cancellation struct {
+	ID              int                 `json:"id" db:"id"`
+	SubscriptionID  int                 `json:"subscription_id" db:"subscription_id"`
+	Mode            CancellationMode    `json:"mode" db:"mode"`
+	ReasonCode      *CancellationReason `json:"reason_code,omitempty" db:"reason_code"`
+	Comment         *string             `json:"comment,omitempty" db:"comment"`
+	EndDate         string              `json:"end_date" db:"end_date"`                             // MM-YYYY
+	PreviousEndDate *string             `json:"previous_end_date,omitempty" db:"previous_end_date"` // MM-YYYY
+	PreviousStatus  SubscriptionStatus  `json:"previous_status" db:"previous_status"`
+	CreatedAt       time.Time           `json:"created_at" db:"created_at"`
+	RevokedAt       *time.Time          `json:"revoked_at,omitempty" db:"revoked_at"`
+	UndoUntil       time.Time           `json:"undo_until" db:"-"`
+}
+
+type CancelRequest struct {
+	Mode       CancellationMode    `json:"mode" binding:"required,oneof=immediate at_period_end" enums:"immediate,at_period_end"`
+	ReasonCode *CancellationReason `json:"reason_code,omitempty" binding:"omitempty,oneof=too_expensive not_using switched_service technical_issues temporary other" enums:"too_expensive,not_using,switched_service,technical_issues,temporary,other"`
+	Comment    *string             `json:"comment,omitempty"`
+}
+
+type CancelResponse struct {
+	Subscription *Subscription `json:"subscription"`
+	Cancellation *Cancellation `json:"cancellation"`
+}
+
+// CancellationReasonCount — число отмен с одной причиной; reason_code "" — причина не указана
+type CancellationReasonCount struct {
+	ReasonCode CancellationReason `json:"reason_code"`
+	Count      int                `json:"count"`
+}
+
+type CancellationReportResponse struct {
+	Period  string                    `json:"period"`
+	Filters map[string]string         `json:"filters"`
+	Total   int                       `json:"total"`
+	Reasons []CancellationReasonCount `json:"reasons"`
+}
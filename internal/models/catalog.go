@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Service — сервис каталога, на который оформляются подписки
+type Service struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Vendor    *string   `json:"vendor,omitempty" db:"vendor"`
+	Category  *string   `json:"category,omitempty" db:"category"`
+	Plans     []Plan    `json:"plans" db:"-"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Plan — тарифный план сервиса с ценой по умолчанию
+type Plan struct {
+	ID              int           `json:"id" db:"id"`
+	ServiceID       int           `json:"service_id" db:"service_id"`
+	Name            string        `json:"name" db:"name"`
+	Price           int           `json:"price" db:"price"`
+	Currency        string        `json:"currency" db:"currency"`
+	BillingPeriod   BillingPeriod `json:"billing_period" db:"billing_period"`
+	BillingInterval int           `json:"billing_interval" db:"billing_interval"`
+	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at" db:"updated_at"`
+}
+
+type CreateServiceRequest struct {
+	Name     string              `json:"name" binding:"required"`
+	Vendor   *string             `json:"vendor,omitempty"`
+	Category *string             `json:"category,omitempty"`
+	Plans    []CreatePlanRequest `json:"plans,omitempty" binding:"omitempty,dive"`
+}
+
+type UpdateServiceRequest struct {
+	Name     *string `json:"name,omitempty"`
+	Vendor   *string `json:"vendor,omitempty"`
+	Category *string `json:"category,omitempty"`
+}
+
+type CreatePlanRequest struct {
+	Name            string        `json:"name" binding:"required"`
+	Price           int           `json:"price" binding:"required,min=1"`
+	Currency        string        `json:"currency,omitempty" binding:"omitempty,iso4217" example:"RUB"`
+	BillingPeriod   BillingPeriod `json:"billing_period,omitempty" binding:"omitempty,oneof=week month quarter year" enums:"week,month,quarter,year"`
+	BillingInterval int           `json:"billing_interval,omitempty" binding:"omitempty,min=1,max=120"`
+}
+
+type UpdatePlanRequest struct {
+	Name            *string        `json:"name,omitempty"`
+	Price           *int           `json:"price,omitempty" binding:"omitempty,min=1"`
+	Currency        *string        `json:"currency,omitempty" binding:"omitempty,iso4217"`
+	BillingPeriod   *BillingPeriod `json:"billing_period,omitempty" binding:"omitempty,oneof=week month quarter year" enums:"week,month,quarter,year"`
+	BillingInterval *int           `json:"billing_interval,omitempty" binding:"omitempty,min=1,max=120"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "github.com/google/uuid"
+
+// DuplicateKind — кто оплачивает совпадающую подписку
+type DuplicateKind string
+
+const (
+	DuplicateSameUser     DuplicateKind = "same_user"     // сам пользователь
+	DuplicateFamilyMember DuplicateKind = "family_member" // участник общей с пользователем подписки
+)
+
+// DuplicateMatch — пара подписок на один и тот же сервис с пересекающимися датами
+type DuplicateMatch struct {
+	Kind         DuplicateKind   `json:"kind" enums:"same_user,family_member"`
+	Subscription SubscriptionRef `json:"subscription"`                            // подписка пользователя
+	Duplicate    SubscriptionRef `json:"duplicate"`                               // совпадающая подписка
+	Similarity   float64         `json:"similarity"`                              // сходство названий сервисов от 0 до 1
+	OverlapStart string          `json:"overlap_start" example:"01-2024"`         // MM-YYYY
+	OverlapEnd   *string         `json:"overlap_end,omitempty" example:"12-2024"` // MM-YYYY, нет — пересечение бессрочное
+}
+
+type DuplicatesResponse struct {
+	UserID     uuid.UUID        `json:"user_id"`
+	Duplicates []DuplicateMatch `json:"duplicates"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// DefaultCurrency — валюта подписок, созданных без явного указания валюты
+const DefaultCurrency = "RUB"
+
+// ExchangeRate — курс валюты за месяц: за одну единицу Base дают Rate единиц Quote
+type ExchangeRate struct {
+	Base      string    `json:"base_currency" db:"base_currency"`
+	Quote     string    `json:"quote_currency" db:"quote_currency"`
+	Month     Month     `json:"month" db:"month" swaggertype:"string" example:"01-2024"`
+	Rate      float64   `json:"rate" db:"rate"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
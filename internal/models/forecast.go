@@ -0,0 +1,22 @@
+package models
+
+// ServiceCost — расходы на один сервис за месяц прогноза
+type ServiceCost struct {
+	ServiceName string `json:"service_name"`
+	Cost        int    `json:"cost"`
+}
+
+// MonthlyForecast — ожидаемые расходы за один месяц
+type MonthlyForecast struct {
+	Month    Month         `json:"month" swaggertype:"string" example:"01-2024"`
+	Cost     int           `json:"cost"`
+	Services []ServiceCost `json:"services"`
+}
+
+type ForecastResponse struct {
+	TotalCost int               `json:"total_cost"`
+	Currency  string            `json:"currency"`
+	Period    string            `json:"period"`
+	Filters   map[string]string `json:"filters"`
+	Months    []MonthlyForecast `json:"months"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InsightKind — вид необычного события в расходах
+type InsightKind string
+
+const (
+	InsightPriceJump      InsightKind = "price_jump"      // резкий рост цены подписки
+	InsightNewHighCost    InsightKind = "new_high_cost"   // новая подписка заметно дороже остальных
+	InsightSpendDeviation InsightKind = "spend_deviation" // расходы месяца сильно отличаются от среднего
+)
+
+// InsightSeverity — важность находки
+type InsightSeverity string
+
+const (
+	SeverityInfo     InsightSeverity = "info"
+	SeverityWarning  InsightSeverity = "warning"
+	SeverityCritical InsightSeverity = "critical"
+)
+
+// IsValid сообщает, является ли значение известной важностью
+func (s InsightSeverity) IsValid() bool {
+	switch s {
+	case SeverityInfo, SeverityWarning, SeverityCritical:
+		return true
+	}
+	return false
+}
+
+// Insight — находка анализа расходов пользователя с объяснением.
+// Для одной подписки и месяца сохраняется одна находка каждого вида.
+type Insight struct {
+	ID             int             `json:"id" db:"id"`
+	UserID         uuid.UUID       `json:"user_id" db:"user_id"`
+	Kind           InsightKind     `json:"kind" db:"kind" enums:"price_jump,new_high_cost,spend_deviation"`
+	Severity       InsightSeverity `json:"severity" db:"severity" enums:"info,warning,critical"`
+	SubscriptionID *int            `json:"subscription_id,omitempty" db:"subscription_id"`
+	Month          string          `json:"month" db:"month" example:"01-2024"` // MM-YYYY
+	Explanation    string          `json:"explanation" db:"explanation"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// LedgerFormat — формат выгрузки списаний в бухгалтерский журнал
+type LedgerFormat string
+
+const (
+	LedgerBeancount LedgerFormat = "beancount"
+	LedgerHledger   LedgerFormat = "hledger"
+	LedgerOFX       LedgerFormat = "ofx"
+)
+
+func (f LedgerFormat) IsValid() bool {
+	switch f {
+	case LedgerBeancount, LedgerHledger, LedgerOFX:
+		return true
+	}
+	return false
+}
+
+// Счета журнала по умолчанию
+const (
+	DefaultExpenseAccount = "Expenses:Subscriptions"
+	DefaultPaymentAccount = "Liabilities:CreditCard"
+)
+
+// LedgerAccounts — счета журнала пользователя. Подписка относится на счет своей
+// категории, а без него — на ExpenseAccount с категорией в последнем компоненте.
+type LedgerAccounts struct {
+	UserID         uuid.UUID         `json:"user_id"`
+	ExpenseAccount string            `json:"expense_account" example:"Expenses:Subscriptions"`
+	PaymentAccount string            `json:"payment_account" example:"Liabilities:CreditCard"`
+	Categories     map[string]string `json:"categories"` // категория в нижнем регистре → счет расходов
+	UpdatedAt      *time.Time        `json:"updated_at,omitempty"`
+}
+
+// AccountFor возвращает счет расходов для категории подписки
+func (a *LedgerAccounts) AccountFor(category *string) string {
+	if category == nil || strings.TrimSpace(*category) == "" {
+		return a.ExpenseAccount
+	}
+	if account, ok := a.Categories[strings.ToLower(strings.TrimSpace(*category))]; ok {
+		return account
+	}
+	if component := AccountComponent(*category); component != "" {
+		return a.ExpenseAccount + ":" + component
+	}
+	return a.ExpenseAccount
+}
+
+// AccountComponent делает из категории компонент имени счета: "video streaming" → "Video-Streaming"
+func AccountComponent(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, "-")
+}
+
+// UpdateLedgerAccountsRequest — categories, если передан, заменяет все счета категорий
+type UpdateLedgerAccountsRequest struct {
+	ExpenseAccount *string            `json:"expense_account,omitempty" example:"Expenses:Subscriptions"`
+	PaymentAccount *string            `json:"payment_account,omitempty" example:"Assets:Bank:Checking"`
+	Categories     *map[string]string `json:"categories,omitempty"`
+}
+
+// LedgerTransaction — одно списание по подписке в журнале
+type LedgerTransaction struct {
+	Date           time.Time
+	SubscriptionID int
+	ServiceName    string
+	Category       *string
+	Account        string // счет расходов
+	Amount         int    // доля пользователя в копейках/центах
+	Currency       string
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShareType — способ задания доли участника совместной подписки
+type ShareType string
+
+const (
+	SharePercent ShareType = "percent"
+	ShareFixed   ShareType = "fixed"
+)
+
+// SubscriptionMember — участник совместной подписки со своей долей.
+// Остаток каждого списания оплачивает владелец подписки.
+type SubscriptionMember struct {
+	ID             int       `json:"id" db:"id"`
+	SubscriptionID int       `json:"subscription_id" db:"subscription_id"`
+	UserID         uuid.UUID `json:"user_id" db:"user_id"`
+	ShareType      ShareType `json:"share_type" db:"share_type"`
+	SharePercent   *float64  `json:"share_percent,omitempty" db:"share_percent"`
+	ShareAmount    *int      `json:"share_amount,omitempty" db:"share_amount"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// AddMemberRequest — нужно указать ровно одно из share_percent и share_amount
+type AddMemberRequest struct {
+	UserID       uuid.UUID `json:"user_id" binding:"required"`
+	SharePercent *float64  `json:"share_percent,omitempty" binding:"omitempty,gt=0,lte=100"`
+	ShareAmount  *int      `json:"share_amount,omitempty" binding:"omitempty,min=1"`
+}
+
+// MemberShare — сумма участника с одного списания по текущей цене
+type MemberShare struct {
+	SubscriptionMember
+	Amount int `json:"amount"`
+}
+
+type MembersResponse struct {
+	SubscriptionID int           `json:"subscription_id"`
+	Price          int           `json:"price"`
+	Currency       string        `json:"currency"`
+	OwnerID        uuid.UUID     `json:"owner_id"`
+	OwnerAmount    int           `json:"owner_amount"`
+	Members        []MemberShare `json:"members"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// MonthLayout — формат, в котором хранятся и передаются даты подписок
+const MonthLayout = "01-2006"
+
+// Month — календарный месяц, номер месяца от начала нашей эры (year*12 + month-1).
+// Позволяет сравнивать и вычитать месяцы без строковых сравнений MM-YYYY.
+type Month int
+
+// ParseMonth разбирает строку в формате MM-YYYY
+func ParseMonth(s string) (Month, error) {
+	t, err := time.Parse(MonthLayout, s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid month %q, expected MM-YYYY", s)
+	}
+	return MonthOf(t), nil
+}
+
+// MonthOf возвращает месяц, в который попадает момент времени t
+func MonthOf(t time.Time) Month {
+	return Month(t.Year()*12 + int(t.Month()) - 1)
+}
+
+func (m Month) Year() int {
+	return int(m) / 12
+}
+
+func (m Month) Month() time.Month {
+	return time.Month(int(m)%12 + 1)
+}
+
+// Time возвращает первый день месяца (UTC)
+func (m Month) Time() time.Time {
+	return time.Date(m.Year(), m.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Day возвращает день day месяца (UTC); если в месяце меньше дней — последний день месяца
+func (m Month) Day(day int) time.Time {
+	if last := m.AddMonths(1).Time().AddDate(0, 0, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(m.Year(), m.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+func (m Month) AddMonths(n int) Month {
+	return m + Month(n)
+}
+
+// MonthsUntil возвращает количество месяцев от m до other включительно.
+// Для other раньше m возвращает 0.
+func (m Month) MonthsUntil(other Month) int {
+	if other < m {
+		return 0
+	}
+	return int(other-m) + 1
+}
+
+func (m Month) String() string {
+	return fmt.Sprintf("%02d-%04d", int(m.Month()), m.Year())
+}
+
+func (m Month) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Month) UnmarshalText(text []byte) error {
+	parsed, err := ParseMonth(string(text))
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// SubscriptionPause — интервал месяцев, в которые подписка приостановлена
+type SubscriptionPause struct {
+	ID             int       `json:"id" db:"id"`
+	SubscriptionID int       `json:"subscription_id" db:"subscription_id"`
+	StartDate      string    `json:"start_date" db:"start_date"`       // MM-YYYY
+	EndDate        *string   `json:"end_date,omitempty" db:"end_date"` // MM-YYYY, NULL — пауза продолжается
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Covers сообщает, приходится ли месяц m на паузу
+func (p *SubscriptionPause) Covers(m Month) bool {
+	start, err := ParseMonth(p.StartDate)
+	if err != nil || m < start {
+		return false
+	}
+	if p.EndDate == nil {
+		return true
+	}
+	end, err := ParseMonth(*p.EndDate)
+	return err == nil && m <= end
+}
+
+type PauseRequest struct {
+	StartDate *string `json:"start_date,omitempty"` // MM-YYYY, по умолчанию — текущий месяц
+	Reason    *string `json:"reason,omitempty"`
+}
+
+type ResumeRequest struct {
+	ResumeDate *string `json:"resume_date,omitempty"` // MM-YYYY, первый оплачиваемый месяц; по умолчанию — текущий
+	Reason     *string `json:"reason,omitempty"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payment — фактически уплаченная сумма по подписке. Платеж без подписки — списание,
+// которое пока не сопоставлено ни с одной подпиской.
+type Payment struct {
+	ID                int       `json:"id" db:"id"`
+	SubscriptionID    *int      `json:"subscription_id,omitempty" db:"subscription_id"`
+	UserID            uuid.UUID `json:"user_id" db:"user_id"` // плательщик
+	Amount            int       `json:"amount" db:"amount"`
+	Currency          string    `json:"currency" db:"currency"`
+	PaidDate          string    `json:"paid_date" db:"paid_date" example:"2024-01-15"` // YYYY-MM-DD
+	Method            *string   `json:"method,omitempty" db:"method" example:"card"`
+	ExternalReference *string   `json:"external_reference,omitempty" db:"external_reference"`
+	Description       *string   `json:"description,omitempty" db:"description" example:"NETFLIX.COM"`
+	CreatedAt         time.Time `json:"created_at" db:"created_at"`
+}
+
+// CreatePaymentRequest — без user_id плательщиком считается владелец подписки,
+// без currency — платеж в валюте подписки. Для платежа без подписки user_id не
+// используется, а currency обязательна.
+type CreatePaymentRequest struct {
+	UserID            *uuid.UUID `json:"user_id,omitempty"`
+	Amount            int        `json:"amount" binding:"required,min=1"`
+	Currency          string     `json:"currency,omitempty" binding:"omitempty,iso4217" example:"RUB"`
+	PaidDate          string     `json:"paid_date" binding:"required" example:"2024-01-15"` // YYYY-MM-DD
+	Method            *string    `json:"method,omitempty" binding:"omitempty,max=50" example:"card"`
+	ExternalReference *string    `json:"external_reference,omitempty" binding:"omitempty,max=255"`
+	Description       *string    `json:"description,omitempty" binding:"omitempty,max=255" example:"NETFLIX.COM"`
+}
+
+// PaymentFilter — условия выборки платежей; пустые поля не ограничивают выборку
+type PaymentFilter struct {
+	SubscriptionIDs []int
+	UserID          *uuid.UUID
+	Unlinked        bool       // только платежи без подписки
+	From            *time.Time // включительно
+	To              *time.Time // не включительно
+	Limit           int
+	Offset          int
+}
+
+// MonthlyPayments — ожидаемые и уплаченные суммы за месяц
+type MonthlyPayments struct {
+	Month      Month `json:"month" swaggertype:"string" example:"01-2024"`
+	Expected   int   `json:"expected"`
+	Paid       int   `json:"paid"`
+	Difference int   `json:"difference"` // paid - expected
+}
+
+type ExpectedVsPaidResponse struct {
+	Expected   int               `json:"expected"`
+	Paid       int               `json:"paid"`
+	Difference int               `json:"difference"` // paid - expected
+	Currency   string            `json:"currency"`
+	Period     string            `json:"period"`
+	Filters    map[string]string `json:"filters"`
+	Months     []MonthlyPayments `json:"months"`
+}
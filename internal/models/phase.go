@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// PhaseType — вид фазы подписки с особой ценой
+type PhaseType string
+
+const (
+	PhaseTrial PhaseType = "trial" // бесплатный пробный период
+	PhaseIntro PhaseType = "intro" // вводная (промо) цена
+)
+
+// SubscriptionPhase — период, в котором вместо обычной цены действует цена фазы
+type SubscriptionPhase struct {
+	ID             int       `json:"id" db:"id"`
+	SubscriptionID int       `json:"subscription_id" db:"subscription_id"`
+	Type           PhaseType `json:"type" db:"phase_type"`
+	Price          int       `json:"price" db:"price"`
+	StartDate      string    `json:"start_date" db:"start_date"` // MM-YYYY
+	EndDate        string    `json:"end_date" db:"end_date"`     // MM-YYYY, включительно
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// PhaseRequest описывает фазу при создании подписки. Фазы идут подряд
+// с месяца начала подписки, после последней действует обычная цена.
+type PhaseRequest struct {
+	Type   PhaseType `json:"type" binding:"required,oneof=trial intro" enums:"trial,intro"`
+	Months int       `json:"months" binding:"required,min=1,max=120"`
+	Price  int       `json:"price" binding:"min=0"` // для trial всегда 0
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// PriceChange — цена подписки, действующая с месяца EffectiveDate
+type PriceChange struct {
+	ID             int       `json:"id" db:"id"`
+	SubscriptionID int       `json:"subscription_id" db:"subscription_id"`
+	Price          int       `json:"price" db:"price"`
+	EffectiveDate  string    `json:"effective_date" db:"effective_date"` // MM-YYYY
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// PriceHistoryEntry — цена в истории с изменением относительно предыдущей
+type PriceHistoryEntry struct {
+	EffectiveDate string  `json:"effective_date"`
+	Price         int     `json:"price"`
+	Change        int     `json:"change"`
+	ChangePercent float64 `json:"change_percent"`
+}
+
+type PriceHistoryResponse struct {
+	SubscriptionID       int                 `json:"subscription_id"`
+	ServiceName          string              `json:"service_name"`
+	Currency             string              `json:"currency"`
+	Prices               []PriceHistoryEntry `json:"prices"`
+	TotalIncrease        int                 `json:"total_increase"`
+	TotalIncreasePercent float64             `json:"total_increase_percent"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// Receipt — данные чека об оплате подписки, извлеченные из письма
+type Receipt struct {
+	MessageID     string
+	Sender        string // адрес отправителя
+	Domain        string // домен отправителя без поддоменов почтовой рассылки
+	Subject       string
+	Date          time.Time
+	ServiceName   string
+	Amount        int // в копейках/центах
+	Currency      string
+	BillingPeriod BillingPeriod
+	Rule          string // правило, по которому извлечены данные
+}
+
+// ReceiptAction — что сделано по чеку
+type ReceiptAction string
+
+const (
+	ReceiptPaymentRecorded     ReceiptAction = "payment_recorded"     // платеж записан по существующей подписке
+	ReceiptSubscriptionCreated ReceiptAction = "subscription_created" // создана подписка и записан платеж
+	ReceiptDuplicate           ReceiptAction = "duplicate"            // чек уже загружен
+)
+
+// ReceiptImportResult — итог загрузки одного чека
+type ReceiptImportResult struct {
+	Action       ReceiptAction `json:"action" enums:"payment_recorded,subscription_created,duplicate"`
+	Rule         string        `json:"rule" example:"netflix"`
+	Sender       string        `json:"sender" example:"info@account.netflix.com"`
+	ServiceName  string        `json:"service_name" example:"Netflix"`
+	Amount       int           `json:"amount"`
+	Currency     string        `json:"currency"`
+	Date         string        `json:"date" example:"2024-01-15"` // YYYY-MM-DD
+	Subscription *Subscription `json:"subscription,omitempty"`
+	Payment      *Payment      `json:"payment,omitempty"`
+}
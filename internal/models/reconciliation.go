@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReconciliationKind — вид расхождения между подписками и платежами
+type ReconciliationKind string
+
+const (
+	ReconcileMissingCharge    ReconciliationKind = "missing_charge"    // ожидаемое списание не найдено
+	ReconcileCancelledCharge  ReconciliationKind = "cancelled_charge"  // списание по отмененной, закончившейся или приостановленной подписке
+	ReconcileAmountMismatch   ReconciliationKind = "amount_mismatch"   // уплачено не столько, сколько ожидалось
+	ReconcileUnknownRecurring ReconciliationKind = "unknown_recurring" // повторяющееся списание без подписки
+)
+
+// ReconciliationStatus — состояние расхождения
+type ReconciliationStatus string
+
+const (
+	ReconciliationOpen     ReconciliationStatus = "open"
+	ReconciliationResolved ReconciliationStatus = "resolved" // пользователь разобрался с расхождением
+)
+
+// ReconciliationItem — расхождение, найденное сверкой за месяц. Суммы указаны
+// в валюте подписки, а для списаний без подписки — в валюте платежа.
+type ReconciliationItem struct {
+	ID             int                  `json:"id" db:"id"`
+	UserID         uuid.UUID            `json:"user_id" db:"user_id"`
+	Month          string               `json:"month" db:"month" example:"01-2024"` // MM-YYYY
+	Kind           ReconciliationKind   `json:"kind" db:"kind" enums:"missing_charge,cancelled_charge,amount_mismatch,unknown_recurring"`
+	Status         ReconciliationStatus `json:"status" db:"status" enums:"open,resolved"`
+	SubscriptionID *int                 `json:"subscription_id,omitempty" db:"subscription_id"`
+	PaymentID      *int                 `json:"payment_id,omitempty" db:"payment_id"`
+	Expected       int                  `json:"expected" db:"expected"`
+	Actual         int                  `json:"actual" db:"actual"`
+	Currency       string               `json:"currency" db:"currency"`
+	Description    string               `json:"description" db:"description"`
+	Note           *string              `json:"note,omitempty" db:"note"` // комментарий пользователя при разрешении
+	ResolvedAt     *time.Time           `json:"resolved_at,omitempty" db:"resolved_at"`
+	CreatedAt      time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time            `json:"updated_at" db:"updated_at"`
+}
+
+// ReconciliationReport — результат сверки пользователя за месяц
+type ReconciliationReport struct {
+	UserID   uuid.UUID            `json:"user_id"`
+	Month    string               `json:"month" example:"01-2024"` // MM-YYYY
+	Open     int                  `json:"open"`
+	Resolved int                  `json:"resolved"`
+	Items    []ReconciliationItem `json:"items"`
+}
+
+type ResolveReconciliationItemRequest struct {
+	Note *string `json:"note,omitempty" binding:"omitempty,max=1000"`
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatementMapping — соответствие колонок CSV-выписки полям списания. Колонки задаются
+// названиями из заголовка без учета регистра; незаполненные поля принимают значения
+// по умолчанию (см. WithDefaults).
+type StatementMapping struct {
+	DateColumn        string `json:"date_column,omitempty" form:"date_column" example:"date"`
+	DescriptionColumn string `json:"description_column,omitempty" form:"description_column" example:"description"`
+	AmountColumn      string `json:"amount_column,omitempty" form:"amount_column" example:"amount"`
+	CurrencyColumn    string `json:"currency_column,omitempty" form:"currency_column" example:"currency"` // необязательная
+	ReferenceColumn   string `json:"reference_column,omitempty" form:"reference_column"`                  // необязательная, идентификатор операции
+	DateFormat        string `json:"date_format,omitempty" form:"date_format" example:"DD.MM.YYYY"`       // из YYYY, YY, MM, DD
+	Delimiter         string `json:"delimiter,omitempty" form:"delimiter" example:";"`                    // один символ или tab
+	DecimalComma      bool   `json:"decimal_comma,omitempty" form:"decimal_comma"`                        // 1.234,56 вместо 1,234.56
+	DebitsPositive    bool   `json:"debits_positive,omitempty" form:"debits_positive"`                    // списания указаны положительными суммами
+	Currency          string `json:"currency,omitempty" form:"currency" example:"RUB"`                    // валюта, если колонки валюты нет
+}
+
+// WithDefaults подставляет значения по умолчанию: колонки date, description, amount
+// и currency, формат даты YYYY-MM-DD, разделитель запятая, валюта DefaultCurrency
+func (m StatementMapping) WithDefaults() StatementMapping {
+	if m.DateColumn == "" {
+		m.DateColumn = "date"
+	}
+	if m.DescriptionColumn == "" {
+		m.DescriptionColumn = "description"
+	}
+	if m.AmountColumn == "" {
+		m.AmountColumn = "amount"
+	}
+	if m.DateFormat == "" {
+		m.DateFormat = "YYYY-MM-DD"
+	}
+	if m.Delimiter == "" {
+		m.Delimiter = ","
+	}
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	m.Currency = strings.ToUpper(m.Currency)
+	return m
+}
+
+// DateLayout переводит формат даты вида DD.MM.YYYY в шаблон пакета time
+func (m StatementMapping) DateLayout() string {
+	return strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02").Replace(m.DateFormat)
+}
+
+// StatementTransaction — списание из банковской выписки
+type StatementTransaction struct {
+	Date        time.Time
+	Description string
+	Amount      int // положительная сумма списания в копейках/центах
+	Currency    string
+	Reference   string // пустая, если в выписке нет идентификатора операции
+}
+
+// CandidateStatus — состояние предложенной подписки
+type CandidateStatus string
+
+const (
+	CandidatePending   CandidateStatus = "pending"   // ждет решения пользователя
+	CandidateConfirmed CandidateStatus = "confirmed" // по кандидату создана подписка
+	CandidateDismissed CandidateStatus = "dismissed" // пользователь отказался; больше не предлагается
+)
+
+// IsValid сообщает, является ли значение известным состоянием кандидата
+func (s CandidateStatus) IsValid() bool {
+	switch s {
+	case CandidatePending, CandidateConfirmed, CandidateDismissed:
+		return true
+	}
+	return false
+}
+
+// SubscriptionCandidate — повторяющееся списание без подписки, найденное в выписках.
+// Получатель определяется по описанию списания без цифр и знаков препинания.
+type SubscriptionCandidate struct {
+	ID              int             `json:"id" db:"id"`
+	UserID          uuid.UUID       `json:"user_id" db:"user_id"`
+	Merchant        string          `json:"merchant" db:"merchant" example:"netflix com"`
+	ServiceName     string          `json:"service_name" db:"service_name" example:"Netflix Com"`
+	Amount          int             `json:"amount" db:"amount"` // сумма последнего списания
+	Currency        string          `json:"currency" db:"currency"`
+	BillingPeriod   BillingPeriod   `json:"billing_period" db:"billing_period" enums:"week,month,quarter,year"`
+	BillingInterval int             `json:"billing_interval" db:"billing_interval"`
+	FirstCharge     string          `json:"first_charge" db:"first_charge" example:"2024-01-15"` // YYYY-MM-DD
+	LastCharge      string          `json:"last_charge" db:"last_charge" example:"2024-06-15"`   // YYYY-MM-DD
+	Charges         int             `json:"charges" db:"charges"`
+	Confidence      float64         `json:"confidence" db:"confidence"` // доля списаний, совпавших по сумме и интервалу
+	Status          CandidateStatus `json:"status" db:"status" enums:"pending,confirmed,dismissed"`
+	SubscriptionID  *int            `json:"subscription_id,omitempty" db:"subscription_id"`
+	CreatedAt       time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// ConfirmCandidateRequest — незаполненные поля берутся из кандидата
+type ConfirmCandidateRequest struct {
+	ServiceName *string  `json:"service_name,omitempty"`
+	Price       *int     `json:"price,omitempty" binding:"omitempty,min=1"`
+	Category    *string  `json:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// StatementImportResult — итог загрузки выписки
+type StatementImportResult struct {
+	Imported   int                     `json:"imported"`   // новые списания
+	Duplicates int                     `json:"duplicates"` // уже загруженные ранее
+	Linked     int                     `json:"linked"`     // сопоставлены с существующими подписками
+	Candidates []SubscriptionCandidate `json:"candidates"` // предложенные подписки, ждущие решения
+}
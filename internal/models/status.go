@@ -0,0 +1,101 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SubscriptionStatus — состояние жизненного цикла подписки
+type SubscriptionStatus string
+
+const (
+	StatusTrialing            SubscriptionStatus = "trialing"             // идет пробный период
+	StatusActive              SubscriptionStatus = "active"               // оплачивается
+	StatusPaused              SubscriptionStatus = "paused"               // приостановлена
+	StatusPendingCancellation SubscriptionStatus = "pending_cancellation" // отменена, действует до end_date
+	StatusCancelled           SubscriptionStatus = "cancelled"            // отменена
+	StatusExpired             SubscriptionStatus = "expired"              // закончилась по end_date
+)
+
+// statusTransitions — переходы, которые можно выполнить вручную: приостановить,
+// возобновить и отменить подписку. Отзыв отмены проверяет окно отмены сам и в таблицу
+// не входит: после отмены статус окончательный.
+var statusTransitions = map[SubscriptionStatus][]SubscriptionStatus{
+	StatusTrialing:            {StatusPaused, StatusPendingCancellation, StatusCancelled},
+	StatusActive:              {StatusPaused, StatusPendingCancellation, StatusCancelled},
+	StatusPaused:              {StatusActive, StatusPendingCancellation, StatusCancelled},
+	StatusPendingCancellation: {StatusCancelled},
+	StatusExpired:             {},
+	StatusCancelled:           {},
+}
+
+// scheduledTransitions — переходы по датам подписки: начало и конец пробного периода
+// и пауз, наступление end_date. Выполняются только при сверке статуса с расписанием.
+var scheduledTransitions = map[SubscriptionStatus][]SubscriptionStatus{
+	StatusTrialing:            {StatusActive, StatusPaused, StatusExpired},
+	StatusActive:              {StatusTrialing, StatusPaused, StatusExpired},
+	StatusPaused:              {StatusTrialing, StatusActive, StatusExpired},
+	StatusPendingCancellation: {StatusCancelled},
+	StatusExpired:             {StatusTrialing, StatusActive, StatusPaused}, // end_date перенесена вперед
+	StatusCancelled:           {},
+}
+
+// IsValid сообщает, является ли значение известным статусом
+func (s SubscriptionStatus) IsValid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo сообщает, можно ли вручную перевести подписку из статуса s в статус to
+func (s SubscriptionStatus) CanTransitionTo(to SubscriptionStatus) bool {
+	return containsStatus(statusTransitions[s], to)
+}
+
+// CanScheduleTo сообщает, может ли подписка перейти из статуса s в статус to по своим датам
+func (s SubscriptionStatus) CanScheduleTo(to SubscriptionStatus) bool {
+	return containsStatus(scheduledTransitions[s], to)
+}
+
+func containsStatus(statuses []SubscriptionStatus, status SubscriptionStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseStatuses разбирает список статусов через запятую, например "active,paused"
+func ParseStatuses(s string) ([]SubscriptionStatus, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var statuses []SubscriptionStatus
+	for _, part := range strings.Split(s, ",") {
+		status := SubscriptionStatus(strings.TrimSpace(part))
+		if !status.IsValid() {
+			return nil, fmt.Errorf("unsupported status %q", status)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// StatusTransition — запись журнала переходов подписки между статусами
+type StatusTransition struct {
+	ID             int                 `json:"id" db:"id"`
+	SubscriptionID int                 `json:"subscription_id" db:"subscription_id"`
+	FromStatus     *SubscriptionStatus `json:"from_status,omitempty" db:"from_status"` // NULL — подписка создана
+	ToStatus       SubscriptionStatus  `json:"to_status" db:"to_status"`
+	Reason         *string             `json:"reason,omitempty" db:"reason"`
+	CreatedAt      time.Time           `json:"created_at" db:"created_at"`
+}
+
+// StatusChangeRequest — ручная смена статуса. trialing и expired выставляются
+// автоматически по пробному периоду и end_date.
+type StatusChangeRequest struct {
+	Status SubscriptionStatus `json:"status" binding:"required,oneof=active paused pending_cancellation cancelled" enums:"active,paused,pending_cancellation,cancelled"`
+	Reason *string            `json:"reason,omitempty"`
+}
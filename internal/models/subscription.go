@@ -1,39 +1,328 @@
 package models
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type Subscription struct {
-	ID          int       `json:"id" db:"id"`
-	ServiceName string    `json:"service_name" db:"service_name"`
-	Price       int       `json:"price" db:"price"`
-	UserID      uuid.UUID `json:"user_id" db:"user_id"`
-	StartDate   string    `json:"start_date" db:"start_date"`       // MM-YYYY
-	EndDate     *string   `json:"end_date,omitempty" db:"end_date"` // MM-YYYY
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID              int                `json:"id" db:"id"`
+	ServiceName     string             `json:"service_name" db:"service_name"`
+	Price           int                `json:"price" db:"price"`       // за один расчетный период
+	Currency        string             `json:"currency" db:"currency"` // ISO 4217
+	BillingPeriod   BillingPeriod      `json:"billing_period" db:"billing_period"`
+	BillingInterval int                `json:"billing_interval" db:"billing_interval"`
+	BillingDay      int                `json:"billing_day" db:"billing_day"` // день месяца первого списания
+	UserID          uuid.UUID          `json:"user_id" db:"user_id"`
+	PlanID          *int               `json:"plan_id,omitempty" db:"plan_id"`
+	Category        *string            `json:"category,omitempty" db:"category"`
+	StartDate       string             `json:"start_date" db:"start_date"`       // MM-YYYY
+	EndDate         *string            `json:"end_date,omitempty" db:"end_date"` // MM-YYYY
+	Status          SubscriptionStatus `json:"status" db:"status" enums:"trialing,active,paused,pending_cancellation,cancelled,expired"`
+	StatusChangedAt time.Time          `json:"status_changed_at" db:"status_changed_at"`
+	CreatedAt       time.Time          `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at" db:"updated_at"`
+
+	// Сервис каталога, к которому относится тарифный план (только для чтения)
+	CatalogServiceID   *int    `json:"catalog_service_id,omitempty" db:"catalog_service_id"`
+	CatalogServiceName *string `json:"catalog_service_name,omitempty" db:"catalog_service_name"`
+	CatalogCategory    *string `json:"catalog_category,omitempty" db:"catalog_category"`
+
+	Tags    []string             `json:"tags" db:"-"`
+	Phases  []SubscriptionPhase  `json:"phases" db:"-"`
+	Prices  []PriceChange        `json:"-" db:"-"` // история цен по возрастанию EffectiveDate
+	Members []SubscriptionMember `json:"members" db:"-"`
+	Pauses  []SubscriptionPause  `json:"pauses" db:"-"`
+
+	// Вычисляемые поля, в базе не хранятся
+	MonthlyEquivalent int `json:"monthly_equivalent" db:"-"`
+	AnnualizedPrice   int `json:"annualized_price" db:"-"`
+
+	// Возможные дубликаты; заполняется только в ответе на создание подписки
+	DuplicateWarnings []DuplicateMatch `json:"duplicate_warnings,omitempty" db:"-"`
+}
+
+// IsPausedAt сообщает, приостановлена ли подписка в месяце m
+func (s *Subscription) IsPausedAt(m Month) bool {
+	for i := range s.Pauses {
+		if s.Pauses[i].Covers(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// InTrialAt сообщает, приходится ли месяц m на пробный период
+func (s *Subscription) InTrialAt(m Month) bool {
+	for _, phase := range s.Phases {
+		if phase.Type != PhaseTrial {
+			continue
+		}
+		start, err := ParseMonth(phase.StartDate)
+		if err != nil {
+			continue
+		}
+		end, err := ParseMonth(phase.EndDate)
+		if err != nil {
+			continue
+		}
+		if start <= m && m <= end {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenPause возвращает незавершенную паузу или nil
+func (s *Subscription) OpenPause() *SubscriptionPause {
+	for i := range s.Pauses {
+		if s.Pauses[i].EndDate == nil {
+			return &s.Pauses[i]
+		}
+	}
+	return nil
+}
+
+// CanonicalServiceName возвращает название сервиса из каталога, а для подписок
+// без тарифного плана — введенное вручную название
+func (s *Subscription) CanonicalServiceName() string {
+	if s.CatalogServiceName != nil {
+		return *s.CatalogServiceName
+	}
+	return s.ServiceName
+}
+
+// EffectiveCategory возвращает категорию подписки, а если она не задана —
+// категорию сервиса каталога; nil, если категории нет
+func (s *Subscription) EffectiveCategory() *string {
+	if s.Category != nil {
+		return s.Category
+	}
+	return s.CatalogCategory
+}
+
+// PriceAt возвращает цену за расчетный период, действующую в месяце m,
+// с учетом пробного периода, вводной цены и истории цен
+func (s *Subscription) PriceAt(m Month) int {
+	for _, phase := range s.Phases {
+		start, err := ParseMonth(phase.StartDate)
+		if err != nil {
+			continue
+		}
+		end, err := ParseMonth(phase.EndDate)
+		if err != nil {
+			continue
+		}
+		if start <= m && m <= end {
+			return phase.Price
+		}
+	}
+	return s.BasePriceAt(m)
+}
+
+// BasePriceAt возвращает обычную цену, действовавшую в месяце m, по истории цен.
+// До первой записи истории действует первая известная цена.
+func (s *Subscription) BasePriceAt(m Month) int {
+	if len(s.Prices) == 0 {
+		return s.Price
+	}
+
+	price := s.Prices[0].Price
+	for _, change := range s.Prices[1:] {
+		effective, err := ParseMonth(change.EffectiveDate)
+		if err != nil || effective > m {
+			break
+		}
+		price = change.Price
+	}
+	return price
+}
+
+// SplitCharge делит сумму списания между участниками и владельцем.
+// Сначала вычитаются фиксированные доли, затем процентные (от полной суммы);
+// доли ограничиваются остатком, остаток достается владельцу.
+func (s *Subscription) SplitCharge(amount int) map[uuid.UUID]int {
+	shares := make(map[uuid.UUID]int, len(s.Members)+1)
+	remaining := amount
+
+	take := func(userID uuid.UUID, share int) {
+		if share > remaining {
+			share = remaining
+		}
+		shares[userID] += share
+		remaining -= share
+	}
+	for _, member := range s.Members {
+		if member.ShareType == ShareFixed && member.ShareAmount != nil {
+			take(member.UserID, *member.ShareAmount)
+		}
+	}
+	for _, member := range s.Members {
+		if member.ShareType == SharePercent && member.SharePercent != nil {
+			take(member.UserID, int(math.Round(float64(amount)**member.SharePercent/100)))
+		}
+	}
+
+	shares[s.UserID] += remaining
+	return shares
+}
+
+// Cycle возвращает расчетный период подписки; по умолчанию — ежемесячно
+func (s *Subscription) Cycle() BillingCycle {
+	return BillingCycle{Period: s.BillingPeriod, Interval: s.BillingInterval}.Normalize()
 }
 
+// BillingAnchor возвращает дату первого списания: день BillingDay месяца начала подписки.
+// В коротких месяцах день ограничивается последним днем месяца.
+func (s *Subscription) BillingAnchor() (time.Time, error) {
+	start, err := ParseMonth(s.StartDate)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return start.Day(s.ChargeDay()), nil
+}
+
+// ChargeDay возвращает день месяца списаний помесячных циклов; по умолчанию — 1.
+// В коротких месяцах списание приходится на последний день месяца.
+func (s *Subscription) ChargeDay() int {
+	if s.BillingDay < 1 {
+		return 1
+	}
+	return s.BillingDay
+}
+
+// SubscriptionFilter — фильтры списка подписок
+type SubscriptionFilter struct {
+	UserID      *uuid.UUID
+	OwnerIDs    []uuid.UUID // только подписки, которыми владеют эти пользователи (без участия)
+	ServiceName *string
+	Tags        []string // только подписки, отмеченные всеми тегами
+	ActiveAt    *Month   // только подписки, активные (начатые, не закончившиеся и не на паузе) в этом месяце
+	Statuses    []SubscriptionStatus
+	Limit       int
+	Offset      int
+}
+
+// CreateSubscriptionRequest — при указании plan_id незаполненные название, цена,
+// валюта и периодичность берутся из тарифного плана каталога
 type CreateSubscriptionRequest struct {
-	ServiceName string    `json:"service_name" binding:"required"`
-	Price       int       `json:"price" binding:"required,min=1"`
-	UserID      uuid.UUID `json:"user_id" binding:"required"`
-	StartDate   string    `json:"start_date" binding:"required"`
-	EndDate     *string   `json:"end_date,omitempty"`
+	PlanID          *int           `json:"plan_id,omitempty"`
+	ServiceName     string         `json:"service_name"`
+	Price           int            `json:"price" binding:"omitempty,min=1"`
+	Currency        string         `json:"currency,omitempty" binding:"omitempty,iso4217" example:"RUB"`
+	BillingPeriod   BillingPeriod  `json:"billing_period,omitempty" binding:"omitempty,oneof=week month quarter year" enums:"week,month,quarter,year"`
+	BillingInterval int            `json:"billing_interval,omitempty" binding:"omitempty,min=1,max=120"`
+	BillingDay      int            `json:"billing_day,omitempty" binding:"omitempty,min=1,max=31"` // по умолчанию — 1
+	UserID          uuid.UUID      `json:"user_id" binding:"required"`
+	StartDate       string         `json:"start_date" binding:"required"`
+	EndDate         *string        `json:"end_date,omitempty"`
+	Category        *string        `json:"category,omitempty"`
+	Tags            []string       `json:"tags,omitempty" example:"work,tax-deductible"`
+	Phases          []PhaseRequest `json:"phases,omitempty" binding:"omitempty,dive"`
 }
 
 type UpdateSubscriptionRequest struct {
-	ServiceName *string `json:"service_name,omitempty"`
-	Price       *int    `json:"price,omitempty" binding:"omitempty,min=1"`
-	StartDate   *string `json:"start_date,omitempty"`
-	EndDate     *string `json:"end_date,omitempty"`
+	PlanID             *int            `json:"plan_id,omitempty"`
+	ServiceName        *string         `json:"service_name,omitempty"`
+	Price              *int            `json:"price,omitempty" binding:"omitempty,min=1"`
+	PriceEffectiveDate *string         `json:"price_effective_date,omitempty"` // MM-YYYY, по умолчанию — текущий месяц
+	Currency           *string         `json:"currency,omitempty" binding:"omitempty,iso4217"`
+	BillingPeriod      *BillingPeriod  `json:"billing_period,omitempty" binding:"omitempty,oneof=week month quarter year" enums:"week,month,quarter,year"`
+	BillingInterval    *int            `json:"billing_interval,omitempty" binding:"omitempty,min=1,max=120"`
+	BillingDay         *int            `json:"billing_day,omitempty" binding:"omitempty,min=1,max=31"`
+	StartDate          *string         `json:"start_date,omitempty"`
+	EndDate            *string         `json:"end_date,omitempty"`
+	Category           *string         `json:"category,omitempty"`                        // пустая строка убирает категорию
+	Tags               *[]string       `json:"tags,omitempty"`                            // заменяет все теги
+	Phases             *[]PhaseRequest `json:"phases,omitempty" binding:"omitempty,dive"` // заменяет все фазы; без него при переносе start_date фазы сдвигаются вместе с ним
 }
 
 type TotalCostResponse struct {
 	TotalCost int               `json:"total_cost"`
+	Currency  string            `json:"currency"`
 	Period    string            `json:"period"`
 	Filters   map[string]string `json:"filters"`
+	GroupBy   []GroupBy         `json:"group_by,omitempty"`
+	Groups    []CostGroup       `json:"groups,omitempty"`
+}
+
+// GroupBy — измерение группировки общей стоимости
+type GroupBy string
+
+const (
+	GroupByServiceName GroupBy = "service_name"
+	GroupByUserID      GroupBy = "user_id"
+	GroupByMonth       GroupBy = "month"
+)
+
+// ParseGroupBy разбирает список измерений через запятую, например "service_name,month"
+func ParseGroupBy(s string) ([]GroupBy, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var groupBy []GroupBy
+	seen := make(map[GroupBy]bool)
+	for _, part := range strings.Split(s, ",") {
+		g := GroupBy(strings.TrimSpace(part))
+		switch g {
+		case GroupByServiceName, GroupByUserID, GroupByMonth:
+		default:
+			return nil, fmt.Errorf("unsupported group_by value %q", g)
+		}
+		if !seen[g] {
+			seen[g] = true
+			groupBy = append(groupBy, g)
+		}
+	}
+	return groupBy, nil
+}
+
+// CostGroup — промежуточный итог по одной группе. Заполнены только поля,
+// соответствующие запрошенным измерениям.
+type CostGroup struct {
+	ServiceName *string    `json:"service_name,omitempty"`
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	Month       *Month     `json:"month,omitempty" swaggertype:"string" example:"01-2024"`
+	TotalCost   int        `json:"total_cost"`
+}
+
+// MonthlyCost — расходы за один месяц отчета
+type MonthlyCost struct {
+	Month               Month             `json:"month" swaggertype:"string" example:"01-2024"`
+	Cost                int               `json:"cost"`
+	ActiveSubscriptions int               `json:"active_subscriptions"`
+	Started             []SubscriptionRef `json:"started"`
+	Ended               []SubscriptionRef `json:"ended"`
+}
+
+type CostBreakdownResponse struct {
+	TotalCost int               `json:"total_cost"`
+	Currency  string            `json:"currency"`
+	Period    string            `json:"period"`
+	Filters   map[string]string `json:"filters"`
+	Months    []MonthlyCost     `json:"months"`
+}
+
+// SubscriptionRef — краткое представление подписки в отчетах
+type SubscriptionRef struct {
+	ID          int       `json:"id"`
+	ServiceName string    `json:"service_name"`
+	UserID      uuid.UUID `json:"user_id"`
+	Price       int       `json:"price"`
+	Currency    string    `json:"currency"`
+}
+
+func NewSubscriptionRef(sub *Subscription) SubscriptionRef {
+	return SubscriptionRef{
+		ID:          sub.ID,
+		ServiceName: sub.ServiceName,
+		UserID:      sub.UserID,
+		Price:       sub.Price,
+		Currency:    sub.Currency,
+	}
 }
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxTagLength — наибольшая длина тега в символах
+const maxTagLength = 50
+
+// NormalizeTags приводит теги к нижнему регистру, убирает пробелы по краям и повторы.
+// Порядок первых вхождений сохраняется.
+func NormalizeTags(tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			return nil, fmt.Errorf("tag must not be empty")
+		}
+		if len([]rune(tag)) > maxTagLength {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+		if strings.Contains(tag, ",") {
+			return nil, fmt.Errorf("tag %q must not contain commas", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+// ParseTags разбирает список тегов через запятую, например "work,tax-deductible"
+func ParseTags(s string) ([]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return NormalizeTags(strings.Split(s, ","))
+}
+
+// TagUsage — использование тега: число подписок и расходы на них за период.
+// Подписка с несколькими тегами учитывается в каждом из них.
+type TagUsage struct {
+	Tag           string `json:"tag"`
+	Subscriptions int    `json:"subscriptions"`
+	TotalCost     int    `json:"total_cost"`
+}
+
+type TagsResponse struct {
+	Currency string            `json:"currency"`
+	Period   string            `json:"period"`
+	Filters  map[string]string `json:"filters"`
+	Tags     []TagUsage        `json:"tags"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "github.com/google/uuid"
+
+// DateLayout — формат дат отдельных списаний
+const DateLayout = "2006-01-02"
+
+// UpcomingCharge — ожидаемое списание по подписке
+type UpcomingCharge struct {
+	Date           string    `json:"date" example:"2024-01-15"` // YYYY-MM-DD
+	SubscriptionID int       `json:"subscription_id"`
+	ServiceName    string    `json:"service_name"`
+	OwnerID        uuid.UUID `json:"owner_id"`
+	Amount         int       `json:"amount"` // полная сумма списания в валюте подписки
+	Share          int       `json:"share"`  // доля пользователя в валюте подписки
+	Currency       string    `json:"currency"`
+}
+
+type UpcomingChargesResponse struct {
+	UserID    uuid.UUID        `json:"user_id"`
+	From      string           `json:"from" example:"2024-01-01"` // YYYY-MM-DD, сегодня
+	To        string           `json:"to" example:"2024-01-30"`   // YYYY-MM-DD, последний день окна
+	TotalCost int              `json:"total_cost"`                // сумма долей пользователя в валюте отчета
+	Currency  string           `json:"currency"`
+	Charges   []UpcomingCharge `json:"charges"`
+}
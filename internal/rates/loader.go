@@ -0,0 +1,210 @@
+package rates
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"go-dev/internal/models"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Базовая валюта курсов ЕЦБ
+const ecbBaseCurrency = "EUR"
+
+// LoadFile читает курсы из локального файла. Формат определяется по расширению:
+// .csv — CSV с заголовком, .xml — XML в формате ЕЦБ (eurofxref).
+func LoadFile(path string) ([]models.ExchangeRate, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return ParseCSV(file)
+	case ".xml":
+		return ParseECB(file)
+	default:
+		return nil, fmt.Errorf("unsupported exchange rate file format %q, expected .csv or .xml", filepath.Ext(path))
+	}
+}
+
+// ParseCSV разбирает CSV с заголовком base_currency,quote_currency,month,rate.
+// Колонка month принимает MM-YYYY или дату YYYY-MM-DD (колонка может называться date).
+// Если за месяц несколько курсов, берется курс с самой поздней датой.
+func ParseCSV(r io.Reader) ([]models.ExchangeRate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["month"]; !ok {
+		if i, ok := columns["date"]; ok {
+			columns["month"] = i
+		}
+	}
+	for _, name := range []string{"base_currency", "quote_currency", "month", "rate"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header is missing column %q", name)
+		}
+	}
+
+	collector := newCollector()
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		date, err := parseRateDate(record[columns["month"]])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[columns["rate"]]), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("line %d: invalid rate %q", line, record[columns["rate"]])
+		}
+
+		err = collector.add(record[columns["base_currency"]], record[columns["quote_currency"]], date, rate)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+
+	return collector.result(), nil
+}
+
+type ecbEnvelope struct {
+	Days []struct {
+		Time  string `xml:"time,attr"`
+		Rates []struct {
+			Currency string  `xml:"currency,attr"`
+			Rate     float64 `xml:"rate,attr"`
+		} `xml:"Cube"`
+	} `xml:"Cube>Cube"`
+}
+
+// ParseECB разбирает XML в формате ЕЦБ (eurofxref-daily/hist.xml).
+// Курсы указаны за одну единицу EUR; за месяц берется последний опубликованный курс.
+func ParseECB(r io.Reader) ([]models.ExchangeRate, error) {
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(r).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse ECB XML: %w", err)
+	}
+
+	collector := newCollector()
+	for _, day := range envelope.Days {
+		date, err := time.Parse("2006-01-02", day.Time)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ECB date %q", day.Time)
+		}
+		for _, rate := range day.Rates {
+			if rate.Rate <= 0 {
+				return nil, fmt.Errorf("invalid ECB rate for %s on %s", rate.Currency, day.Time)
+			}
+			if err := collector.add(ecbBaseCurrency, rate.Currency, date, rate.Rate); err != nil {
+				return nil, fmt.Errorf("%s: %w", day.Time, err)
+			}
+		}
+	}
+
+	return collector.result(), nil
+}
+
+func parseRateDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if date, err := time.Parse("2006-01-02", s); err == nil {
+		return date, nil
+	}
+	month, err := models.ParseMonth(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q, expected MM-YYYY or YYYY-MM-DD", s)
+	}
+	return month.Time(), nil
+}
+
+type rateKey struct {
+	base, quote string
+	month       models.Month
+}
+
+type datedRate struct {
+	date time.Time
+	rate float64
+}
+
+// collector сводит курсы к одному значению на пару валют и месяц
+type collector struct {
+	rates map[rateKey]datedRate
+}
+
+func newCollector() *collector {
+	return &collector{rates: make(map[rateKey]datedRate)}
+}
+
+func (c *collector) add(base, quote string, date time.Time, rate float64) error {
+	base = strings.ToUpper(strings.TrimSpace(base))
+	quote = strings.ToUpper(strings.TrimSpace(quote))
+	if !isCurrencyCode(base) || !isCurrencyCode(quote) {
+		return fmt.Errorf("invalid currency pair %s/%s", base, quote)
+	}
+
+	key := rateKey{base: base, quote: quote, month: models.MonthOf(date)}
+	if existing, ok := c.rates[key]; ok && existing.date.After(date) {
+		return nil
+	}
+	c.rates[key] = datedRate{date: date, rate: rate}
+	return nil
+}
+
+func (c *collector) result() []models.ExchangeRate {
+	rates := make([]models.ExchangeRate, 0, len(c.rates))
+	for key, value := range c.rates {
+		rates = append(rates, models.ExchangeRate{
+			Base:  key.base,
+			Quote: key.quote,
+			Month: key.month,
+			Rate:  value.rate,
+		})
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		a, b := rates[i], rates[j]
+		if a.Month != b.Month {
+			return a.Month < b.Month
+		}
+		if a.Base != b.Base {
+			return a.Base < b.Base
+		}
+		return a.Quote < b.Quote
+	})
+	return rates
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
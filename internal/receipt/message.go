@@ -0,0 +1,168 @@
+package receipt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// maxBodySize — сколько байт тела письма читается; чеки заметно меньше
+const maxBodySize = 5 << 20
+
+// Message — письмо, сведенное к тексту
+type Message struct {
+	ID      string
+	From    string // адрес отправителя
+	Domain  string // домен адреса отправителя
+	Subject string
+	Date    time.Time
+	Text    string // текстовая часть письма; HTML переводится в текст
+}
+
+// ParseMessage разбирает письмо в формате RFC 5322 (.eml). Текст берется из части
+// text/plain, а если ее нет — из text/html; вложения пропускаются.
+func ParseMessage(r io.Reader) (*Message, error) {
+	m, err := mail.ReadMessage(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email: %w", err)
+	}
+
+	decoder := &mime.WordDecoder{CharsetReader: charsetReader}
+	msg := &Message{ID: strings.Trim(m.Header.Get("Message-Id"), "<> ")}
+	if msg.Subject, err = decoder.DecodeHeader(m.Header.Get("Subject")); err != nil {
+		msg.Subject = m.Header.Get("Subject")
+	}
+
+	parser := &mail.AddressParser{WordDecoder: decoder}
+	from, err := parser.Parse(m.Header.Get("From"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid From header: %w", err)
+	}
+	msg.From = strings.ToLower(from.Address)
+	if at := strings.LastIndex(msg.From, "@"); at >= 0 {
+		msg.Domain = msg.From[at+1:]
+	}
+
+	if date, err := m.Header.Date(); err == nil {
+		msg.Date = date
+	}
+
+	plain, htmlText, err := bodyText(m.Header.Get("Content-Type"), m.Header.Get("Content-Transfer-Encoding"),
+		io.LimitReader(m.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+	msg.Text = plain
+	if strings.TrimSpace(msg.Text) == "" {
+		msg.Text = htmlToText(htmlText)
+	}
+	return msg, nil
+}
+
+// bodyText возвращает текстовую и HTML-части тела; multipart разбирается рекурсивно,
+// берется первая часть каждого вида
+func bodyText(contentType, encoding string, body io.Reader) (plain, htmlText string, err error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", "", fmt.Errorf("failed to read email part: %w", err)
+			}
+			if strings.HasPrefix(part.Header.Get("Content-Disposition"), "attachment") {
+				continue
+			}
+			p, h, err := bodyText(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", "", err
+			}
+			if plain == "" {
+				plain = p
+			}
+			if htmlText == "" {
+				htmlText = h
+			}
+		}
+		return plain, htmlText, nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", "", nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	if body, err = charsetReader(params["charset"], body); err != nil {
+		return "", "", err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read email body: %w", err)
+	}
+
+	if mediaType == "text/html" {
+		return "", string(data), nil
+	}
+	return string(data), "", nil
+}
+
+// charsetReader перекодирует текст в UTF-8; неизвестная кодировка — ошибка
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii":
+		return input, nil
+	}
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+var (
+	invisibleTags = regexp.MustCompile(`(?is)<(style|script|head)\b.*?</(style|script|head)>`)
+	breakTags     = regexp.MustCompile(`(?i)<(br|/p|/div|/tr|/li|/h[1-6]|/table)\b[^>]*>`)
+	cellTags      = regexp.MustCompile(`(?i)</t[dh]>`)
+	anyTag        = regexp.MustCompile(`<[^>]*>`)
+	spaces        = regexp.MustCompile(`[ \t\x{00A0}]+`)
+)
+
+// htmlToText переводит HTML письма в текст, сохраняя строки таблиц и абзацы
+func htmlToText(s string) string {
+	s = invisibleTags.ReplaceAllString(s, "")
+	s = breakTags.ReplaceAllString(s, "\n")
+	s = cellTags.ReplaceAllString(s, " ")
+	s = anyTag.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	lines := strings.Split(s, "\n")
+	text := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(spaces.ReplaceAllString(line, " ")); line != "" {
+			text = append(text, line)
+		}
+	}
+	return strings.Join(text, "\n")
+}
@@ -0,0 +1,332 @@
+package receipt
+
+import (
+	"errors"
+	"fmt"
+	"go-dev/internal/models"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoReceipt — в письме не найдена сумма оплаты
+var ErrNoReceipt = errors.New("no payment amount found in email")
+
+// Rule — правило извлечения чека из писем одного поставщика
+type Rule interface {
+	Name() string
+	Matches(msg *Message) bool
+	Extract(msg *Message) (*models.Receipt, error)
+}
+
+// PatternRule — правило по домену отправителя и регулярным выражениям. Amount
+// должно содержать группу amount и может содержать группу currency; если Amount
+// не задано, сумма ищется так же, как в общем правиле.
+type PatternRule struct {
+	RuleName      string               `yaml:"name"`
+	Service       string               `yaml:"service"`
+	Domains       []string             `yaml:"domains"`
+	Subject       string               `yaml:"subject,omitempty"` // письма с другой темой пропускаются
+	Amount        string               `yaml:"amount,omitempty"`
+	Currency      string               `yaml:"currency,omitempty"` // если в письме валюта не указана
+	BillingPeriod models.BillingPeriod `yaml:"billing_period,omitempty"`
+
+	subject *regexp.Regexp
+	amount  *regexp.Regexp
+}
+
+// Compile проверяет правило и компилирует его выражения
+func (r *PatternRule) Compile() error {
+	if r.RuleName == "" {
+		return errors.New("rule name is required")
+	}
+	if len(r.Domains) == 0 {
+		return fmt.Errorf("rule %q: at least one domain is required", r.RuleName)
+	}
+	for i, domain := range r.Domains {
+		r.Domains[i] = strings.ToLower(strings.TrimSpace(domain))
+	}
+
+	var err error
+	if r.Subject != "" {
+		if r.subject, err = regexp.Compile(r.Subject); err != nil {
+			return fmt.Errorf("rule %q: invalid subject pattern: %w", r.RuleName, err)
+		}
+	}
+	if r.Amount != "" {
+		if r.amount, err = regexp.Compile(r.Amount); err != nil {
+			return fmt.Errorf("rule %q: invalid amount pattern: %w", r.RuleName, err)
+		}
+		if r.amount.SubexpIndex("amount") < 0 {
+			return fmt.Errorf("rule %q: amount pattern must have an \"amount\" group", r.RuleName)
+		}
+	}
+
+	switch r.BillingPeriod {
+	case "", models.BillingWeek, models.BillingMonth, models.BillingQuarter, models.BillingYear:
+	default:
+		return fmt.Errorf("rule %q: invalid billing period %q", r.RuleName, r.BillingPeriod)
+	}
+	r.Currency = strings.ToUpper(r.Currency)
+	return nil
+}
+
+func (r *PatternRule) Name() string { return r.RuleName }
+
+func (r *PatternRule) Matches(msg *Message) bool {
+	matched := false
+	for _, domain := range r.Domains {
+		if msg.Domain == domain || strings.HasSuffix(msg.Domain, "."+domain) {
+			matched = true
+			break
+		}
+	}
+	return matched && (r.subject == nil || r.subject.MatchString(msg.Subject))
+}
+
+func (r *PatternRule) Extract(msg *Message) (*models.Receipt, error) {
+	var amount int
+	var currency string
+	if r.amount != nil {
+		match := r.amount.FindStringSubmatch(msg.Text)
+		if match == nil {
+			return nil, ErrNoReceipt
+		}
+		var ok bool
+		if amount, ok = parseNumber(match[r.amount.SubexpIndex("amount")]); !ok {
+			return nil, ErrNoReceipt
+		}
+		if i := r.amount.SubexpIndex("currency"); i >= 0 {
+			currency = currencyCode(match[i])
+		}
+	} else {
+		var ok bool
+		if amount, currency, ok = findTotal(msg.Text, r.Currency != ""); !ok {
+			return nil, ErrNoReceipt
+		}
+	}
+	if currency == "" {
+		currency = r.Currency
+	}
+
+	service := r.Service
+	if service == "" {
+		service = serviceFromDomain(msg.Domain)
+	}
+	return newReceipt(msg, r.RuleName, service, amount, currency, r.BillingPeriod)
+}
+
+// genericRule — правило для писем без своего правила: сумма ищется в строке с итогом,
+// название сервиса берется из домена отправителя
+type genericRule struct{}
+
+func (genericRule) Name() string { return "generic" }
+
+func (genericRule) Matches(*Message) bool { return true }
+
+func (genericRule) Extract(msg *Message) (*models.Receipt, error) {
+	amount, currency, ok := findTotal(msg.Text, false)
+	if !ok {
+		return nil, ErrNoReceipt
+	}
+	return newReceipt(msg, "generic", serviceFromDomain(msg.Domain), amount, currency, "")
+}
+
+func newReceipt(msg *Message, rule, service string, amount int, currency string, period models.BillingPeriod) (*models.Receipt, error) {
+	if amount <= 0 {
+		return nil, ErrNoReceipt
+	}
+	if currency == "" {
+		return nil, fmt.Errorf("rule %q: currency not found in email", rule)
+	}
+	if msg.Date.IsZero() {
+		return nil, errors.New("email has no Date header")
+	}
+	if period == "" {
+		period = models.BillingMonth
+	}
+	return &models.Receipt{
+		MessageID:     msg.ID,
+		Sender:        msg.From,
+		Domain:        msg.Domain,
+		Subject:       msg.Subject,
+		Date:          msg.Date,
+		ServiceName:   service,
+		Amount:        amount,
+		Currency:      currency,
+		BillingPeriod: period,
+		Rule:          rule,
+	}, nil
+}
+
+// DefaultRules — встроенные правила для распространенных сервисов
+func DefaultRules() []Rule {
+	rules := []*PatternRule{
+		{RuleName: "netflix", Service: "Netflix", Domains: []string{"netflix.com"}},
+		{RuleName: "spotify", Service: "Spotify", Domains: []string{"spotify.com"}},
+		{RuleName: "youtube", Service: "YouTube Premium", Domains: []string{"youtube.com"}},
+		{RuleName: "apple", Service: "Apple", Domains: []string{"apple.com", "itunes.com"}},
+		{RuleName: "yandex-plus", Service: "Яндекс Плюс", Domains: []string{"plus.yandex.ru", "plus.yandex.com"}},
+		{RuleName: "github", Service: "GitHub", Domains: []string{"github.com"}},
+		{RuleName: "jetbrains", Service: "JetBrains", Domains: []string{"jetbrains.com"}, BillingPeriod: models.BillingYear},
+		{RuleName: "adobe", Service: "Adobe Creative Cloud", Domains: []string{"adobe.com"}},
+	}
+
+	result := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if err := rule.Compile(); err != nil {
+			panic(err)
+		}
+		result = append(result, rule)
+	}
+	return result
+}
+
+// LoadRules читает правила поставщиков из YAML-файла со списком правил
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipt rules: %w", err)
+	}
+
+	var patterns []*PatternRule
+	if err := yaml.Unmarshal(data, &patterns); err != nil {
+		return nil, fmt.Errorf("failed to parse receipt rules: %w", err)
+	}
+
+	rules := make([]Rule, 0, len(patterns))
+	for _, pattern := range patterns {
+		if err := pattern.Compile(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, pattern)
+	}
+	return rules, nil
+}
+
+// Extractor выбирает первое подходящее правило; письма, к которым не подошло ни одно,
+// разбираются общим правилом
+type Extractor struct {
+	rules []Rule
+}
+
+func NewExtractor(rules ...Rule) *Extractor {
+	return &Extractor{rules: append(rules, genericRule{})}
+}
+
+// Parse разбирает письмо и извлекает из него чек
+func (e *Extractor) Parse(r io.Reader) (*models.Receipt, error) {
+	msg, err := ParseMessage(r)
+	if err != nil {
+		return nil, err
+	}
+	return e.Extract(msg)
+}
+
+func (e *Extractor) Extract(msg *Message) (*models.Receipt, error) {
+	for _, rule := range e.rules {
+		if rule.Matches(msg) {
+			return rule.Extract(msg)
+		}
+	}
+	return nil, ErrNoReceipt
+}
+
+const currencyPattern = `[$€£₽₸]|USD|EUR|GBP|RUB|KZT|руб\.?|р\.`
+
+var (
+	moneyPattern = regexp.MustCompile(`(?i)(` + currencyPattern + `)?\s?(\d[\d \x{00A0}.,]*\d|\d)\s?(` + currencyPattern + `)?`)
+	totalLine    = regexp.MustCompile(`(?i)total|amount|charged|paid|итого|сумма|к оплате|оплачено|списано`)
+)
+
+var currencySymbols = map[string]string{
+	"$": "USD", "€": "EUR", "£": "GBP", "₽": "RUB", "₸": "KZT", "руб": "RUB", "р": "RUB",
+}
+
+// findTotal ищет сумму сначала в строках с итогом, затем во всем тексте. Сумма без
+// валюты принимается, только если allowBare — валюту тогда задает правило.
+func findTotal(text string, allowBare bool) (amount int, currency string, ok bool) {
+	lines := strings.Split(text, "\n")
+	for _, onlyTotals := range []bool{true, false} {
+		for _, line := range lines {
+			if onlyTotals && !totalLine.MatchString(line) {
+				continue
+			}
+			for _, match := range moneyPattern.FindAllStringSubmatch(line, -1) {
+				currency = currencyCode(match[1])
+				if currency == "" {
+					currency = currencyCode(match[3])
+				}
+				if currency == "" && !(allowBare && onlyTotals) {
+					continue
+				}
+				if amount, ok = parseNumber(match[2]); ok && amount > 0 {
+					return amount, currency, true
+				}
+			}
+		}
+	}
+	return 0, "", false
+}
+
+func currencyCode(s string) string {
+	s = strings.TrimSuffix(strings.TrimSpace(s), ".")
+	if code, ok := currencySymbols[strings.ToLower(s)]; ok {
+		return code
+	}
+	return strings.ToUpper(s)
+}
+
+// parseNumber переводит сумму в копейки/центы. Разделитель, за которым стоят одна
+// или две цифры в конце, считается десятичным, остальные — разделителями разрядов.
+func parseNumber(s string) (int, bool) {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+
+	whole, fraction := s, ""
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 && len(s)-i-1 <= 2 {
+		whole, fraction = s[:i], s[i+1:]
+	}
+	whole = strings.NewReplacer(".", "", ",", "").Replace(whole)
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+
+	units, err := strconv.Atoi(whole)
+	if err != nil {
+		return 0, false
+	}
+	cents, err := strconv.Atoi(fraction)
+	if err != nil {
+		return 0, false
+	}
+	return units*100 + cents, true
+}
+
+// serviceFromDomain выводит название сервиса из домена: mail.netflix.com → Netflix
+func serviceFromDomain(domain string) string {
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return domain
+	}
+	name := labels[len(labels)-2]
+	switch name {
+	case "co", "com", "org", "net":
+		if len(labels) >= 3 {
+			name = labels[len(labels)-3]
+		}
+	}
+	if name == "" {
+		return domain
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
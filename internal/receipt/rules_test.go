@@ -0,0 +1,37 @@
+package receipt
+
+import "testing"
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   int
+		wantOK bool
+	}{
+		{"5", 500, true},
+		{"12.99", 1299, true},
+		{"12,99", 1299, true},
+		{"5.5", 550, true},
+		{"5,5", 550, true},
+		{"1,299.00", 129900, true},
+		{"1.299,00", 129900, true},
+		{"1 299,00", 129900, true},
+		{"1\u00a0299,00", 129900, true},
+		{"1,299", 129900, true},
+		{"1.299", 129900, true},
+		{"12 345 678", 1234567800, true},
+		{"12.", 1200, true},
+		{"", 0, false},
+		{"abc", 0, false},
+		{"12.ab", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := parseNumber(tt.in)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseNumber(%q) = %d, %v, want %d, %v", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package receipt
+
+import (
+	"fmt"
+	"go-dev/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	processedDir = "processed"
+	failedDir    = "failed"
+)
+
+// FileResult — итог разбора одного письма из каталога
+type FileResult struct {
+	Path   string
+	UserID uuid.UUID
+	Err    error
+	Retry  bool // письмо оставлено в каталоге и будет разобрано при следующем обходе
+}
+
+// ImportFunc сохраняет чек пользователя
+type ImportFunc func(userID uuid.UUID, receipt *models.Receipt) error
+
+// ScanDir разбирает письма, разложенные по каталогам пользователей: <dir>/<UUID>/*.eml.
+// Разобранное письмо переносится в подкаталог processed, письмо, из которого не удалось
+// извлечь чек, — в failed, чтобы при следующем обходе оно не разбиралось снова. Если чек
+// извлечен, но не сохранен (например, недоступна база), письмо остается на месте и
+// разбирается повторно. Письма, измененные позже чем minAge назад, пропускаются: они
+// могут быть еще не дописаны.
+func ScanDir(dir string, minAge time.Duration, extractor *Extractor, importFn ImportFunc) ([]FileResult, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read receipts directory: %w", err)
+	}
+
+	settled := time.Now().Add(-minAge)
+	var results []FileResult
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		userID, err := uuid.Parse(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		userDir := filepath.Join(dir, entry.Name())
+		files, err := os.ReadDir(userDir)
+		if err != nil {
+			return results, fmt.Errorf("failed to read receipts directory: %w", err)
+		}
+		for _, file := range files {
+			if file.IsDir() || !strings.EqualFold(filepath.Ext(file.Name()), ".eml") {
+				continue
+			}
+			info, err := file.Info()
+			if err != nil || info.ModTime().After(settled) {
+				continue
+			}
+			path := filepath.Join(userDir, file.Name())
+			result := FileResult{Path: path, UserID: userID}
+			result.Retry, result.Err = importFile(path, userID, extractor, importFn)
+			if result.Retry {
+				results = append(results, result)
+				continue
+			}
+
+			target := processedDir
+			if result.Err != nil {
+				target = failedDir
+			}
+			if err := moveTo(path, filepath.Join(userDir, target)); err != nil {
+				return results, err
+			}
+			results = append(results, result)
+		}
+	}
+	return results, nil
+}
+
+// importFile разбирает письмо и сохраняет чек. retry = true, если ошибка не связана
+// с содержимым письма и его стоит разобрать снова.
+func importFile(path string, userID uuid.UUID, extractor *Extractor, importFn ImportFunc) (retry bool, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return true, err
+	}
+	defer file.Close()
+
+	parsed, err := extractor.Parse(file)
+	if err != nil {
+		return false, err
+	}
+	if err := importFn(userID, parsed); err != nil {
+		return true, err
+	}
+	return false, nil
+}
+
+func moveTo(path, dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	if err := os.Rename(path, filepath.Join(dir, filepath.Base(path))); err != nil {
+		return fmt.Errorf("failed to move %s: %w", path, err)
+	}
+	return nil
+}
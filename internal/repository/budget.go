@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-dev/internal/models"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+const budgetColumns = `id, user_id, period, amount, currency, scope, category, service_name, created_at, updated_at`
+
+type BudgetRepository struct {
+	db *sql.DB
+}
+
+func NewBudgetRepository(db *sql.DB) *BudgetRepository {
+	return &BudgetRepository{db: db}
+}
+
+func (r *BudgetRepository) Create(budget *models.Budget) error {
+	query := `
+		INSERT INTO budgets (user_id, period, amount, currency, scope, category, service_name)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRow(query, budget.UserID, budget.Period, budget.Amount, budget.Currency,
+		budget.Scope, budget.Category, budget.ServiceName).
+		Scan(&budget.ID, &budget.CreatedAt, &budget.UpdatedAt)
+}
+
+func (r *BudgetRepository) GetByID(id int) (*models.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM budgets WHERE id = $1`
+
+	budget, err := scanBudget(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// ListByUser возвращает бюджеты пользователя в порядке создания
+func (r *BudgetRepository) ListByUser(userID uuid.UUID) ([]*models.Budget, error) {
+	query := `SELECT ` + budgetColumns + ` FROM budgets WHERE user_id = $1 ORDER BY id`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := []*models.Budget{}
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+
+	return budgets, rows.Err()
+}
+
+// ListUserIDs возвращает пользователей, у которых есть хотя бы один бюджет
+func (r *BudgetRepository) ListUserIDs() ([]uuid.UUID, error) {
+	rows, err := r.db.Query("SELECT DISTINCT user_id FROM budgets ORDER BY user_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+func (r *BudgetRepository) Update(id int, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	setParts := []string{}
+	args := []interface{}{}
+	argCount := 0
+
+	for field, value := range updates {
+		argCount++
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", field, argCount))
+		args = append(args, value)
+	}
+
+	argCount++
+	query := fmt.Sprintf("UPDATE budgets SET %s, updated_at = NOW() WHERE id = $%d",
+		strings.Join(setParts, ", "), argCount)
+	args = append(args, id)
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("budget not found")
+	}
+
+	return nil
+}
+
+func (r *BudgetRepository) Delete(id int) error {
+	result, err := r.db.Exec("DELETE FROM budgets WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("budget not found")
+	}
+
+	return nil
+}
+
+// AddAlert записывает оповещение, если за этот период порог еще не отмечался.
+// Возвращает false, если оповещение уже было.
+func (r *BudgetRepository) AddAlert(alert *models.BudgetAlert) (bool, error) {
+	query := `
+		INSERT INTO budget_alerts (budget_id, period_start, threshold, spent, amount, currency)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (budget_id, period_start, threshold) DO NOTHING
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(query, alert.BudgetID, alert.PeriodStart, alert.Threshold,
+		alert.Spent, alert.Amount, alert.Currency).
+		Scan(&alert.ID, &alert.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ListAlerts возвращает оповещения по бюджетам пользователя, новые первыми
+func (r *BudgetRepository) ListAlerts(userID uuid.UUID, limit, offset int) ([]models.BudgetAlert, error) {
+	query := `
+		SELECT a.id, a.budget_id, a.period_start, a.threshold, a.spent, a.amount, a.currency, a.created_at
+		FROM budget_alerts a
+		JOIN budgets b ON b.id = a.budget_id
+		WHERE b.user_id = $1
+		ORDER BY a.created_at DESC, a.id DESC`
+
+	args := []interface{}{userID}
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if offset > 0 {
+		args = append(args, offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []models.BudgetAlert{}
+	for rows.Next() {
+		var alert models.BudgetAlert
+		err := rows.Scan(&alert.ID, &alert.BudgetID, &alert.PeriodStart, &alert.Threshold,
+			&alert.Spent, &alert.Amount, &alert.Currency, &alert.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+func scanBudget(row scanner) (*models.Budget, error) {
+	budget := &models.Budget{}
+	err := row.Scan(&budget.ID, &budget.UserID, &budget.Period, &budget.Amount, &budget.Currency,
+		&budget.Scope, &budget.Category, &budget.ServiceName, &budget.CreatedAt, &budget.UpdatedAt)
+	return budget, err
+}
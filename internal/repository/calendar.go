@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CalendarRepository struct {
+	db *sql.DB
+}
+
+func NewCalendarRepository(db *sql.DB) *CalendarRepository {
+	return &CalendarRepository{db: db}
+}
+
+// SaveToken сохраняет хэш токена календаря пользователя, заменяя прежний
+func (r *CalendarRepository) SaveToken(userID uuid.UUID, tokenHash string) (time.Time, error) {
+	var createdAt time.Time
+	err := r.db.QueryRow(`
+		INSERT INTO calendar_feeds (user_id, token_hash)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET token_hash = EXCLUDED.token_hash, created_at = NOW()
+		RETURNING created_at`, userID, tokenHash).Scan(&createdAt)
+	return createdAt, err
+}
+
+// DeleteToken отзывает ссылку на календарь. Возвращает false, если ссылки не было.
+func (r *CalendarRepository) DeleteToken(userID uuid.UUID) (bool, error) {
+	result, err := r.db.Exec("DELETE FROM calendar_feeds WHERE user_id = $1", userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// FindUser возвращает владельца токена по его хэшу или nil, если такого токена нет
+func (r *CalendarRepository) FindUser(tokenHash string) (*uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.db.QueryRow("SELECT user_id FROM calendar_feeds WHERE token_hash = $1", tokenHash).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &userID, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"database/sql"
+	"go-dev/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Cancel записывает отмену и в той же транзакции устанавливает end_date и статус подписки.
+// Возвращает false, если статус подписки уже не c.PreviousStatus (изменен параллельно).
+func (r *SubscriptionRepository) Cancel(c *models.Cancellation, status models.SubscriptionStatus, reason *string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE subscriptions SET end_date = $1, status = $2, status_changed_at = NOW(), updated_at = NOW()
+		WHERE id = $3 AND status = $4`, c.EndDate, status, c.SubscriptionID, c.PreviousStatus)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := insertTransition(tx, c.SubscriptionID, &c.PreviousStatus, status, reason); err != nil {
+		return false, err
+	}
+
+	query := `
+		INSERT INTO subscription_cancellations (subscription_id, mode, reason_code, comment,
+			end_date, previous_end_date, previous_status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	err = tx.QueryRow(query, c.SubscriptionID, c.Mode, c.ReasonCode, c.Comment,
+		c.EndDate, c.PreviousEndDate, c.PreviousStatus).
+		Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// LatestCancellation возвращает последнюю неотозванную отмену подписки или nil
+func (r *SubscriptionRepository) LatestCancellation(subscriptionID int) (*models.Cancellation, error) {
+	query := `
+		SELECT id, subscription_id, mode, reason_code, comment, end_date, previous_end_date,
+			previous_status, created_at, revoked_at
+		FROM subscription_cancellations
+		WHERE subscription_id = $1 AND revoked_at IS NULL
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1`
+
+	c := &models.Cancellation{}
+	err := r.db.QueryRow(query, subscriptionID).Scan(
+		&c.ID, &c.SubscriptionID, &c.Mode, &c.ReasonCode, &c.Comment, &c.EndDate, &c.PreviousEndDate,
+		&c.PreviousStatus, &c.CreatedAt, &c.RevokedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// RevokeCancellation отзывает отмену: восстанавливает прежнюю end_date и переводит
+// подписку из статуса from в статус to. Возвращает false, если отмена уже отозвана
+// или статус подписки изменен параллельно.
+func (r *SubscriptionRepository) RevokeCancellation(c *models.Cancellation, from, to models.SubscriptionStatus, reason *string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE subscription_cancellations SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL`, c.ID)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return false, err
+	}
+
+	result, err = tx.Exec(`
+		UPDATE subscriptions SET end_date = $1, status = $2, status_changed_at = NOW(), updated_at = NOW()
+		WHERE id = $3 AND status = $4`, c.PreviousEndDate, to, c.SubscriptionID, from)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
+		return false, err
+	}
+
+	if err := insertTransition(tx, c.SubscriptionID, &from, to, reason); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// CountCancellationReasons считает действующие отмены, сделанные в месяцах [from, to],
+// по кодам причин; отмены без причины попадают в reason_code "".
+func (r *SubscriptionRepository) CountCancellationReasons(userID *uuid.UUID, from, to models.Month) ([]models.CancellationReasonCount, error) {
+	query := `
+		SELECT COALESCE(c.reason_code, ''), COUNT(*)
+		FROM subscription_cancellations c
+		JOIN subscriptions ON subscriptions.id = c.subscription_id
+		WHERE c.revoked_at IS NULL AND c.created_at >= $1 AND c.created_at < $2`
+
+	args := []interface{}{from.Time(), to.AddMonths(1).Time()}
+
+	if userID != nil {
+		query += userCondition(3)
+		args = append(args, *userID)
+	}
+
+	query += " GROUP BY 1 ORDER BY 2 DESC, 1"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []models.CancellationReasonCount{}
+	for rows.Next() {
+		var count models.CancellationReasonCount
+		if err := rows.Scan(&count.ReasonCode, &count.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-dev/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const candidateColumns = `id, user_id, merchant, service_name, amount, currency, billing_period, billing_interval,
+	first_charge, last_charge, charges, confidence, status, subscription_id, created_at, updated_at`
+
+type CandidateRepository struct {
+	db *sql.DB
+}
+
+func NewCandidateRepository(db *sql.DB) *CandidateRepository {
+	return &CandidateRepository{db: db}
+}
+
+// Upsert сохраняет кандидата или обновляет ожидающего решения кандидата того же
+// получателя. Подтвержденные и отклоненные кандидаты не меняются; тогда возвращается false.
+func (r *CandidateRepository) Upsert(candidate *models.SubscriptionCandidate) (bool, error) {
+	query := `
+		INSERT INTO subscription_candidates (user_id, merchant, service_name, amount, currency,
+			billing_period, billing_interval, first_charge, last_charge, charges, confidence)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (user_id, merchant, currency) DO UPDATE
+		SET service_name = EXCLUDED.service_name, amount = EXCLUDED.amount,
+			billing_period = EXCLUDED.billing_period, billing_interval = EXCLUDED.billing_interval,
+			first_charge = EXCLUDED.first_charge, last_charge = EXCLUDED.last_charge,
+			charges = EXCLUDED.charges, confidence = EXCLUDED.confidence, updated_at = NOW()
+		WHERE subscription_candidates.status = 'pending'
+		RETURNING ` + candidateColumns
+
+	saved, err := scanCandidate(r.db.QueryRow(query, candidate.UserID, candidate.Merchant, candidate.ServiceName,
+		candidate.Amount, candidate.Currency, candidate.BillingPeriod, candidate.BillingInterval,
+		candidate.FirstCharge, candidate.LastCharge, candidate.Charges, candidate.Confidence))
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	*candidate = *saved
+	return true, nil
+}
+
+func (r *CandidateRepository) GetByID(id int) (*models.SubscriptionCandidate, error) {
+	query := `SELECT ` + candidateColumns + ` FROM subscription_candidates WHERE id = $1`
+
+	candidate, err := scanCandidate(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return candidate, nil
+}
+
+// ListByUser возвращает кандидатов пользователя, самые уверенные первыми
+func (r *CandidateRepository) ListByUser(userID uuid.UUID, status *models.CandidateStatus) ([]models.SubscriptionCandidate, error) {
+	query := `SELECT ` + candidateColumns + ` FROM subscription_candidates WHERE user_id = $1`
+
+	args := []interface{}{userID}
+	if status != nil {
+		args = append(args, *status)
+		query += fmt.Sprintf(" AND status = $%d", len(args))
+	}
+	query += " ORDER BY confidence DESC, last_charge DESC, id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := []models.SubscriptionCandidate{}
+	for rows.Next() {
+		candidate, err := scanCandidate(rows)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, *candidate)
+	}
+
+	return candidates, rows.Err()
+}
+
+// SetStatus меняет состояние ожидающего решения кандидата. Возвращает false, если
+// кандидат уже подтвержден или отклонен.
+func (r *CandidateRepository) SetStatus(id int, status models.CandidateStatus, subscriptionID *int) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE subscription_candidates SET status = $1, subscription_id = $2, updated_at = NOW()
+		WHERE id = $3 AND status = 'pending'`, status, subscriptionID, id)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func scanCandidate(row scanner) (*models.SubscriptionCandidate, error) {
+	candidate := &models.SubscriptionCandidate{}
+	var firstCharge, lastCharge time.Time
+	err := row.Scan(&candidate.ID, &candidate.UserID, &candidate.Merchant, &candidate.ServiceName,
+		&candidate.Amount, &candidate.Currency, &candidate.BillingPeriod, &candidate.BillingInterval,
+		&firstCharge, &lastCharge, &candidate.Charges, &candidate.Confidence, &candidate.Status,
+		&candidate.SubscriptionID, &candidate.CreatedAt, &candidate.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	candidate.FirstCharge = firstCharge.Format(models.DateLayout)
+	candidate.LastCharge = lastCharge.Format(models.DateLayout)
+	return candidate, nil
+}
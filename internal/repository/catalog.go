@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-dev/internal/models"
+	"strings"
+)
+
+type CatalogRepository struct {
+	db *sql.DB
+}
+
+func NewCatalogRepository(db *sql.DB) *CatalogRepository {
+	return &CatalogRepository{db: db}
+}
+
+// CreateService создает сервис вместе с его тарифными планами
+func (r *CatalogRepository) CreateService(service *models.Service) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO services (name, vendor, category)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at`
+
+	err = tx.QueryRow(query, service.Name, service.Vendor, service.Category).
+		Scan(&service.ID, &service.CreatedAt, &service.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range service.Plans {
+		service.Plans[i].ServiceID = service.ID
+		if err := insertPlan(tx, &service.Plans[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *CatalogRepository) GetService(id int) (*models.Service, error) {
+	query := `
+		SELECT id, name, vendor, category, created_at, updated_at
+		FROM services WHERE id = $1`
+
+	service := &models.Service{}
+	err := r.db.QueryRow(query, id).Scan(
+		&service.ID, &service.Name, &service.Vendor, &service.Category,
+		&service.CreatedAt, &service.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	service.Plans, err = r.ListPlans(id)
+	return service, err
+}
+
+// GetServiceByName ищет сервис по названию без учета регистра
+func (r *CatalogRepository) GetServiceByName(name string) (*models.Service, error) {
+	query := `
+		SELECT id, name, vendor, category, created_at, updated_at
+		FROM services WHERE lower(name) = lower($1)`
+
+	service := &models.Service{}
+	err := r.db.QueryRow(query, strings.TrimSpace(name)).Scan(
+		&service.ID, &service.Name, &service.Vendor, &service.Category,
+		&service.CreatedAt, &service.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return service, err
+}
+
+func (r *CatalogRepository) ListServices(category *string, limit, offset int) ([]*models.Service, error) {
+	query := `
+		SELECT id, name, vendor, category, created_at, updated_at
+		FROM services WHERE 1=1`
+
+	args := []interface{}{}
+	argCount := 0
+
+	if category != nil {
+		argCount++
+		query += fmt.Sprintf(" AND category = $%d", argCount)
+		args = append(args, *category)
+	}
+
+	query += " ORDER BY name"
+
+	if limit > 0 {
+		argCount++
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, limit)
+	}
+
+	if offset > 0 {
+		argCount++
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, offset)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var services []*models.Service
+	for rows.Next() {
+		service := &models.Service{}
+		err := rows.Scan(
+			&service.ID, &service.Name, &service.Vendor, &service.Category,
+			&service.CreatedAt, &service.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, service := range services {
+		if service.Plans, err = r.ListPlans(service.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return services, nil
+}
+
+func (r *CatalogRepository) UpdateService(id int, updates map[string]interface{}) error {
+	return r.update("services", "service not found", id, updates)
+}
+
+func (r *CatalogRepository) DeleteService(id int) error {
+	return r.delete("services", "service not found", id)
+}
+
+func (r *CatalogRepository) CreatePlan(plan *models.Plan) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertPlan(tx, plan); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func insertPlan(tx *sql.Tx, plan *models.Plan) error {
+	query := `
+		INSERT INTO plans (service_id, name, price, currency, billing_period, billing_interval)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at`
+
+	return tx.QueryRow(query, plan.ServiceID, plan.Name, plan.Price, plan.Currency,
+		plan.BillingPeriod, plan.BillingInterval).
+		Scan(&plan.ID, &plan.CreatedAt, &plan.UpdatedAt)
+}
+
+func (r *CatalogRepository) GetPlan(id int) (*models.Plan, error) {
+	query := `
+		SELECT id, service_id, name, price, currency, billing_period, billing_interval, created_at, updated_at
+		FROM plans WHERE id = $1`
+
+	plan := &models.Plan{}
+	err := r.db.QueryRow(query, id).Scan(
+		&plan.ID, &plan.ServiceID, &plan.Name, &plan.Price, &plan.Currency,
+		&plan.BillingPeriod, &plan.BillingInterval, &plan.CreatedAt, &plan.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return plan, err
+}
+
+func (r *CatalogRepository) ListPlans(serviceID int) ([]models.Plan, error) {
+	query := `
+		SELECT id, service_id, name, price, currency, billing_period, billing_interval, created_at, updated_at
+		FROM plans WHERE service_id = $1 ORDER BY price, id`
+
+	rows, err := r.db.Query(query, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []models.Plan{}
+	for rows.Next() {
+		var plan models.Plan
+		err := rows.Scan(
+			&plan.ID, &plan.ServiceID, &plan.Name, &plan.Price, &plan.Currency,
+			&plan.BillingPeriod, &plan.BillingInterval, &plan.CreatedAt, &plan.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+
+	return plans, rows.Err()
+}
+
+func (r *CatalogRepository) UpdatePlan(id int, updates map[string]interface{}) error {
+	return r.update("plans", "plan not found", id, updates)
+}
+
+func (r *CatalogRepository) DeletePlan(id int) error {
+	return r.delete("plans", "plan not found", id)
+}
+
+func (r *CatalogRepository) update(table, notFound string, id int, updates map[string]interface{}) error {
+	if len(updates) == 0 {
+		return fmt.Errorf("no fields to update")
+	}
+
+	setParts := []string{}
+	args := []interface{}{}
+	argCount := 0
+
+	for field, value := range updates {
+		argCount++
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", field, argCount))
+		args = append(args, value)
+	}
+
+	argCount++
+	query := fmt.Sprintf("UPDATE %s SET %s, updated_at = NOW() WHERE id = $%d",
+		table, strings.Join(setParts, ", "), argCount)
+	args = append(args, id)
+
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s", notFound)
+	}
+
+	return nil
+}
+
+func (r *CatalogRepository) delete(table, notFound string, id int) error {
+	result, err := r.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = $1", table), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s", notFound)
+	}
+
+	return nil
+}
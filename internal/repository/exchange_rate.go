@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"database/sql"
+	"go-dev/internal/models"
+	"time"
+)
+
+type ExchangeRateRepository struct {
+	db *sql.DB
+}
+
+func NewExchangeRateRepository(db *sql.DB) *ExchangeRateRepository {
+	return &ExchangeRateRepository{db: db}
+}
+
+// Upsert сохраняет курсы, перезаписывая уже загруженные за те же месяцы
+func (r *ExchangeRateRepository) Upsert(rates []models.ExchangeRate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO exchange_rates (base_currency, quote_currency, month, rate)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (base_currency, quote_currency, month)
+		DO UPDATE SET rate = EXCLUDED.rate, updated_at = NOW()`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.Exec(rate.Base, rate.Quote, rate.Month.Time(), rate.Rate); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListUpTo возвращает все курсы за месяцы не позже to
+func (r *ExchangeRateRepository) ListUpTo(to models.Month) ([]models.ExchangeRate, error) {
+	query := `
+		SELECT base_currency, quote_currency, month, rate, updated_at
+		FROM exchange_rates
+		WHERE month <= $1::date
+		ORDER BY month`
+
+	rows, err := r.db.Query(query, to.Time())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.ExchangeRate
+	for rows.Next() {
+		var rate models.ExchangeRate
+		var month time.Time
+		if err := rows.Scan(&rate.Base, &rate.Quote, &month, &rate.Rate, &rate.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rate.Month = models.MonthOf(month)
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-dev/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type InsightRepository struct {
+	db *sql.DB
+}
+
+func NewInsightRepository(db *sql.DB) *InsightRepository {
+	return &InsightRepository{db: db}
+}
+
+// Upsert сохраняет находку; повторная находка того же вида для той же подписки
+// и месяца обновляет важность и объяснение
+func (r *InsightRepository) Upsert(insight *models.Insight) error {
+	query := `
+		INSERT INTO insights (user_id, kind, severity, subscription_id, month, explanation)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, kind, COALESCE(subscription_id, 0), month)
+		DO UPDATE SET severity = EXCLUDED.severity, explanation = EXCLUDED.explanation, updated_at = NOW()
+		RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRow(query, insight.UserID, insight.Kind, insight.Severity, insight.SubscriptionID,
+		insight.Month, insight.Explanation).
+		Scan(&insight.ID, &insight.CreatedAt, &insight.UpdatedAt)
+}
+
+// ListByUser возвращает находки пользователя, новые первыми
+func (r *InsightRepository) ListByUser(userID uuid.UUID, severity *models.InsightSeverity, limit, offset int) ([]models.Insight, error) {
+	query := `
+		SELECT id, user_id, kind, severity, subscription_id, month, explanation, created_at, updated_at
+		FROM insights
+		WHERE user_id = $1`
+
+	args := []interface{}{userID}
+	if severity != nil {
+		args = append(args, *severity)
+		query += fmt.Sprintf(" AND severity = $%d", len(args))
+	}
+
+	query += " ORDER BY to_date(month, 'MM-YYYY') DESC, created_at DESC, id DESC"
+
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if offset > 0 {
+		args = append(args, offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	insights := []models.Insight{}
+	for rows.Next() {
+		var insight models.Insight
+		err := rows.Scan(&insight.ID, &insight.UserID, &insight.Kind, &insight.Severity,
+			&insight.SubscriptionID, &insight.Month, &insight.Explanation, &insight.CreatedAt, &insight.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		insights = append(insights, insight)
+	}
+
+	return insights, rows.Err()
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"go-dev/internal/models"
+
+	"github.com/google/uuid"
+)
+
+type LedgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) *LedgerRepository {
+	return &LedgerRepository{db: db}
+}
+
+// GetAccounts возвращает счета журнала пользователя; без сохраненных настроек — счета по умолчанию
+func (r *LedgerRepository) GetAccounts(userID uuid.UUID) (*models.LedgerAccounts, error) {
+	accounts := &models.LedgerAccounts{
+		UserID:         userID,
+		ExpenseAccount: models.DefaultExpenseAccount,
+		PaymentAccount: models.DefaultPaymentAccount,
+		Categories:     map[string]string{},
+	}
+
+	var updatedAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT expense_account, payment_account, updated_at
+		FROM ledger_settings WHERE user_id = $1`, userID).
+		Scan(&accounts.ExpenseAccount, &accounts.PaymentAccount, &updatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if updatedAt.Valid {
+		accounts.UpdatedAt = &updatedAt.Time
+	}
+
+	rows, err := r.db.Query(`
+		SELECT category, account FROM ledger_category_accounts WHERE user_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var category, account string
+		if err := rows.Scan(&category, &account); err != nil {
+			return nil, err
+		}
+		accounts.Categories[category] = account
+	}
+
+	return accounts, rows.Err()
+}
+
+// SaveAccounts сохраняет счета журнала пользователя, заменяя все счета категорий
+func (r *LedgerRepository) SaveAccounts(accounts *models.LedgerAccounts) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var updatedAt sql.NullTime
+	err = tx.QueryRow(`
+		INSERT INTO ledger_settings (user_id, expense_account, payment_account)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET expense_account = EXCLUDED.expense_account, payment_account = EXCLUDED.payment_account,
+			updated_at = NOW()
+		RETURNING updated_at`,
+		accounts.UserID, accounts.ExpenseAccount, accounts.PaymentAccount).Scan(&updatedAt)
+	if err != nil {
+		return err
+	}
+	if updatedAt.Valid {
+		accounts.UpdatedAt = &updatedAt.Time
+	}
+
+	if _, err := tx.Exec("DELETE FROM ledger_category_accounts WHERE user_id = $1", accounts.UserID); err != nil {
+		return err
+	}
+	for category, account := range accounts.Categories {
+		_, err := tx.Exec(`
+			INSERT INTO ledger_category_accounts (user_id, category, account)
+			VALUES ($1, $2, $3)`, accounts.UserID, category, account)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-dev/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const paymentColumns = `id, subscription_id, user_id, amount, currency, paid_date, method, external_reference, description, created_at`
+
+type PaymentRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentRepository(db *sql.DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+// Create записывает платеж. Возвращает false, если платеж с тем же external_reference
+// у этого плательщика уже записан.
+func (r *PaymentRepository) Create(payment *models.Payment) (bool, error) {
+	query := `
+		INSERT INTO payments (subscription_id, user_id, amount, currency, paid_date, method,
+			external_reference, description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (user_id, external_reference) WHERE external_reference IS NOT NULL DO NOTHING
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(query, payment.SubscriptionID, payment.UserID, payment.Amount, payment.Currency,
+		payment.PaidDate, payment.Method, payment.ExternalReference, payment.Description).
+		Scan(&payment.ID, &payment.CreatedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *PaymentRepository) GetByID(id int) (*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE id = $1`
+
+	payment, err := scanPayment(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// List возвращает платежи по фильтру, новые первыми
+func (r *PaymentRepository) List(filter models.PaymentFilter) ([]models.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments`
+
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.SubscriptionIDs != nil {
+		args = append(args, pq.Array(filter.SubscriptionIDs))
+		conditions = append(conditions, fmt.Sprintf("subscription_id = ANY($%d)", len(args)))
+	}
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.Unlinked {
+		conditions = append(conditions, "subscription_id IS NULL")
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("paid_date >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("paid_date < $%d", len(args)))
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query += " ORDER BY paid_date DESC, id DESC"
+
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []models.Payment{}
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, *payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// ListUserIDs возвращает пользователей, у которых есть хотя бы один платеж
+func (r *PaymentRepository) ListUserIDs() ([]uuid.UUID, error) {
+	rows, err := r.db.Query("SELECT DISTINCT user_id FROM payments ORDER BY user_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// ExistsReference сообщает, записан ли у пользователя платеж с этим external_reference
+func (r *PaymentRepository) ExistsReference(userID uuid.UUID, reference string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM payments WHERE user_id = $1 AND external_reference = $2)`,
+		userID, reference).Scan(&exists)
+	return exists, err
+}
+
+// Link привязывает платежи без подписки к подписке
+func (r *PaymentRepository) Link(ids []int, subscriptionID int) error {
+	_, err := r.db.Exec(`
+		UPDATE payments SET subscription_id = $1
+		WHERE id = ANY($2) AND subscription_id IS NULL`, subscriptionID, pq.Array(ids))
+	return err
+}
+
+// Delete удаляет платеж. Возвращает false, если такого платежа не было.
+func (r *PaymentRepository) Delete(id int) (bool, error) {
+	result, err := r.db.Exec("DELETE FROM payments WHERE id = $1", id)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+func scanPayment(row scanner) (*models.Payment, error) {
+	payment := &models.Payment{}
+	var paidDate time.Time
+	err := row.Scan(&payment.ID, &payment.SubscriptionID, &payment.UserID, &payment.Amount, &payment.Currency,
+		&paidDate, &payment.Method, &payment.ExternalReference, &payment.Description, &payment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	payment.PaidDate = paidDate.Format(models.DateLayout)
+	return payment, nil
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"go-dev/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const reconciliationColumns = `id, user_id, month, kind, status, subscription_id, payment_id, expected, actual,
+	currency, description, note, resolved_at, created_at, updated_at`
+
+type ReconciliationRepository struct {
+	db *sql.DB
+}
+
+func NewReconciliationRepository(db *sql.DB) *ReconciliationRepository {
+	return &ReconciliationRepository{db: db}
+}
+
+// Replace сохраняет результат сверки пользователя за месяц: обновляет найденные
+// расхождения и удаляет открытые, которых больше нет. Разрешенные расхождения
+// сохраняют свой статус.
+func (r *ReconciliationRepository) Replace(userID uuid.UUID, month string, items []models.ReconciliationItem) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO reconciliation_items (user_id, month, kind, subscription_id, payment_id,
+			expected, actual, currency, description)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id, month, kind, COALESCE(subscription_id, 0), COALESCE(payment_id, 0)) DO UPDATE
+		SET expected = EXCLUDED.expected, actual = EXCLUDED.actual, currency = EXCLUDED.currency,
+			description = EXCLUDED.description, updated_at = NOW()
+		RETURNING id`
+
+	ids := make([]int, 0, len(items))
+	for i := range items {
+		item := &items[i]
+		err := tx.QueryRow(query, userID, month, item.Kind, item.SubscriptionID, item.PaymentID,
+			item.Expected, item.Actual, item.Currency, item.Description).Scan(&item.ID)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, item.ID)
+	}
+
+	_, err = tx.Exec(`
+		DELETE FROM reconciliation_items
+		WHERE user_id = $1 AND month = $2 AND status = $3 AND NOT (id = ANY($4))`,
+		userID, month, models.ReconciliationOpen, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *ReconciliationRepository) GetByID(id int) (*models.ReconciliationItem, error) {
+	query := `SELECT ` + reconciliationColumns + ` FROM reconciliation_items WHERE id = $1`
+
+	item, err := scanReconciliationItem(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// ListByMonth возвращает расхождения пользователя за месяц: сначала открытые
+func (r *ReconciliationRepository) ListByMonth(userID uuid.UUID, month string) ([]models.ReconciliationItem, error) {
+	query := `SELECT ` + reconciliationColumns + ` FROM reconciliation_items
+		WHERE user_id = $1 AND month = $2
+		ORDER BY status = 'resolved', id`
+
+	rows, err := r.db.Query(query, userID, month)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.ReconciliationItem{}
+	for rows.Next() {
+		item, err := scanReconciliationItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
+	}
+
+	return items, rows.Err()
+}
+
+// Resolve отмечает расхождение разрешенным
+func (r *ReconciliationRepository) Resolve(id int, note *string) error {
+	result, err := r.db.Exec(`
+		UPDATE reconciliation_items
+		SET status = $1, note = $2, resolved_at = NOW(), updated_at = NOW()
+		WHERE id = $3`, models.ReconciliationResolved, note, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("reconciliation item not found")
+	}
+
+	return nil
+}
+
+func scanReconciliationItem(row scanner) (*models.ReconciliationItem, error) {
+	item := &models.ReconciliationItem{}
+	err := row.Scan(&item.ID, &item.UserID, &item.Month, &item.Kind, &item.Status, &item.SubscriptionID,
+		&item.PaymentID, &item.Expected, &item.Actual, &item.Currency, &item.Description, &item.Note,
+		&item.ResolvedAt, &item.CreatedAt, &item.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
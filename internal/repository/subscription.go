@@ -7,8 +7,17 @@ import (
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const subscriptionColumns = `id, service_name, price, currency, billing_period, billing_interval, billing_day,
+		user_id, plan_id, category, start_date, end_date, status, status_changed_at, created_at, updated_at,
+		(SELECT p.service_id FROM plans p WHERE p.id = subscriptions.plan_id) AS catalog_service_id,
+		(SELECT sv.name FROM plans p JOIN services sv ON sv.id = p.service_id
+			WHERE p.id = subscriptions.plan_id) AS catalog_service_name,
+		(SELECT sv.category FROM plans p JOIN services sv ON sv.id = p.service_id
+			WHERE p.id = subscriptions.plan_id) AS catalog_category`
+
 type SubscriptionRepository struct {
 	db *sql.DB
 }
@@ -18,164 +27,744 @@ func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
 }
 
 func (r *SubscriptionRepository) Create(sub *models.Subscription) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO subscriptions (service_name, price, user_id, start_date, end_date)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at, updated_at`
+		INSERT INTO subscriptions (service_name, price, currency, billing_period, billing_interval, billing_day,
+			user_id, plan_id, category, start_date, end_date, status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id, status_changed_at, created_at, updated_at`
+
+	err = tx.QueryRow(query, sub.ServiceName, sub.Price, sub.Currency, sub.BillingPeriod, sub.BillingInterval, sub.BillingDay,
+		sub.UserID, sub.PlanID, sub.Category, sub.StartDate, sub.EndDate, sub.Status).
+		Scan(&sub.ID, &sub.StatusChangedAt, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return err
+	}
 
-	return r.db.QueryRow(query, sub.ServiceName, sub.Price, sub.UserID, sub.StartDate, sub.EndDate).
-		Scan(&sub.ID, &sub.CreatedAt, &sub.UpdatedAt)
+	reason := "created"
+	if err := insertTransition(tx, sub.ID, nil, sub.Status, &reason); err != nil {
+		return err
+	}
+
+	if err := insertTags(tx, sub.ID, sub.Tags); err != nil {
+		return err
+	}
+
+	if err := insertPhases(tx, sub.ID, sub.Phases); err != nil {
+		return err
+	}
+
+	// Начальная цена действует с месяца начала подписки
+	initial := models.PriceChange{SubscriptionID: sub.ID, Price: sub.Price, EffectiveDate: sub.StartDate}
+	if err := upsertPrice(tx, &initial); err != nil {
+		return err
+	}
+	sub.Prices = []models.PriceChange{initial}
+
+	return tx.Commit()
 }
 
 func (r *SubscriptionRepository) GetByID(id int) (*models.Subscription, error) {
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions WHERE id = $1`
 
-	sub := &models.Subscription{}
-	err := r.db.QueryRow(query, id).Scan(
-		&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID,
-		&sub.StartDate, &sub.EndDate, &sub.CreatedAt, &sub.UpdatedAt)
-
+	sub, err := scanSubscription(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
-	return sub, err
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.attachDetails([]*models.Subscription{sub}); err != nil {
+		return nil, err
+	}
+	return sub, nil
 }
 
-func (r *SubscriptionRepository) List(userID *uuid.UUID, serviceName *string, limit, offset int) ([]*models.Subscription, error) {
+func (r *SubscriptionRepository) List(filter models.SubscriptionFilter) ([]*models.Subscription, error) {
 	query := `
-		SELECT id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions WHERE 1=1`
 
 	args := []interface{}{}
 	argCount := 0
 
-	if userID != nil {
+	if filter.UserID != nil {
 		argCount++
-		query += fmt.Sprintf(" AND user_id = $%d", argCount)
-		args = append(args, *userID)
+		query += userCondition(argCount)
+		args = append(args, *filter.UserID)
 	}
 
-	if serviceName != nil {
+	if filter.OwnerIDs != nil {
+		argCount++
+		query += fmt.Sprintf(" AND user_id = ANY($%d::uuid[])", argCount)
+		owners := make([]string, len(filter.OwnerIDs))
+		for i, id := range filter.OwnerIDs {
+			owners[i] = id.String()
+		}
+		args = append(args, pq.Array(owners))
+	}
+
+	if filter.ServiceName != nil {
 		argCount++
 		query += fmt.Sprintf(" AND service_name ILIKE $%d", argCount)
-		args = append(args, "%"+*serviceName+"%")
+		args = append(args, "%"+*filter.ServiceName+"%")
 	}
 
-	query += " ORDER BY created_at DESC"
+	if len(filter.Tags) > 0 {
+		argCount++
+		query += tagsCondition(argCount)
+		args = append(args, pq.Array(filter.Tags))
+	}
 
-	if limit > 0 {
+	if filter.ActiveAt != nil {
+		argCount++
+		query += activeCondition(argCount)
+		args = append(args, filter.ActiveAt.Time())
+	}
+
+	if len(filter.Statuses) > 0 {
+		argCount++
+		query += fmt.Sprintf(" AND status = ANY($%d)", argCount)
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+		args = append(args, pq.Array(statuses))
+	}
+
+	query += " ORDER BY created_at DESC, id DESC"
+
+	if filter.Limit > 0 {
 		argCount++
 		query += fmt.Sprintf(" LIMIT $%d", argCount)
-		args = append(args, limit)
+		args = append(args, filter.Limit)
 	}
 
-	if offset > 0 {
+	if filter.Offset > 0 {
 		argCount++
 		query += fmt.Sprintf(" OFFSET $%d", argCount)
-		args = append(args, offset)
+		args = append(args, filter.Offset)
 	}
 
-	rows, err := r.db.Query(query, args...)
+	return r.query(query, args...)
+}
+
+// SubscriptionUpdate — изменения подписки, которые записываются вместе.
+// Nil Tags, Phases и Price оставляют теги, фазы и историю цен без изменений.
+type SubscriptionUpdate struct {
+	Fields map[string]interface{}
+	Tags   *[]string
+	Phases *[]models.SubscriptionPhase
+	Price  *models.PriceChange // цена, действующая с месяца Price.EffectiveDate
+}
+
+// ApplyUpdate записывает поля, теги, фазы и новую цену подписки в одной транзакции:
+// при ошибке подписка остается без изменений. Текущая цена подписки обновляется на
+// последнюю уже вступившую в силу; запланированные на будущие месяцы цены ее не меняют,
+// а пока в силу не вступила ни одна, действует самая ранняя.
+// Возвращает false, если такой подписки нет.
+func (r *SubscriptionRepository) ApplyUpdate(id int, update *SubscriptionUpdate) (bool, error) {
+	tx, err := r.db.Begin()
 	if err != nil {
-		return nil, err
+		return false, err
 	}
-	defer rows.Close()
+	defer tx.Rollback()
 
-	var subscriptions []*models.Subscription
-	for rows.Next() {
-		sub := &models.Subscription{}
-		err := rows.Scan(
-			&sub.ID, &sub.ServiceName, &sub.Price, &sub.UserID,
-			&sub.StartDate, &sub.EndDate, &sub.CreatedAt, &sub.UpdatedAt)
+	setParts := []string{"updated_at = NOW()"}
+	args := []interface{}{}
+	for field, value := range update.Fields {
+		args = append(args, value)
+		setParts = append(setParts, fmt.Sprintf("%s = $%d", field, len(args)))
+	}
+	args = append(args, id)
+	query := fmt.Sprintf("UPDATE subscriptions SET %s WHERE id = $%d", strings.Join(setParts, ", "), len(args))
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if update.Tags != nil {
+		if _, err := tx.Exec("DELETE FROM subscription_tags WHERE subscription_id = $1", id); err != nil {
+			return false, err
+		}
+		if err := insertTags(tx, id, *update.Tags); err != nil {
+			return false, err
+		}
+	}
+
+	if update.Phases != nil {
+		if _, err := tx.Exec("DELETE FROM subscription_phases WHERE subscription_id = $1", id); err != nil {
+			return false, err
+		}
+		if err := insertPhases(tx, id, *update.Phases); err != nil {
+			return false, err
+		}
+	}
+
+	if update.Price != nil {
+		if err := upsertPrice(tx, update.Price); err != nil {
+			return false, err
+		}
+		_, err = tx.Exec(`
+			UPDATE subscriptions SET price = (
+				SELECT price FROM subscription_prices
+				WHERE subscription_id = $1
+				ORDER BY to_date(effective_date, 'MM-YYYY') <= date_trunc('month', CURRENT_DATE) DESC,
+					CASE WHEN to_date(effective_date, 'MM-YYYY') <= date_trunc('month', CURRENT_DATE)
+						THEN to_date(effective_date, 'MM-YYYY') END DESC,
+					to_date(effective_date, 'MM-YYYY')
+				LIMIT 1
+			)
+			WHERE id = $1`, id)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
-		subscriptions = append(subscriptions, sub)
 	}
 
-	return subscriptions, rows.Err()
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
-func (r *SubscriptionRepository) Update(id int, updates map[string]interface{}) error {
-	if len(updates) == 0 {
-		return fmt.Errorf("no fields to update")
+// Delete удаляет подписку. Возвращает false, если такой подписки не было.
+func (r *SubscriptionRepository) Delete(id int) (bool, error) {
+	query := "DELETE FROM subscriptions WHERE id = $1"
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return false, err
 	}
 
-	setParts := []string{}
-	args := []interface{}{}
-	argCount := 0
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
 
-	for field, value := range updates {
+// ListForPeriod возвращает подписки, активные хотя бы в одном месяце периода [from, to].
+// Если заданы tags, отбираются только подписки, отмеченные всеми тегами.
+// Даты сравниваются как даты, а не как строки MM-YYYY.
+func (r *SubscriptionRepository) ListForPeriod(userID *uuid.UUID, serviceName *string, tags []string, from, to models.Month) ([]*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE to_date(start_date, 'MM-YYYY') <= $2::date
+			AND (end_date IS NULL OR to_date(end_date, 'MM-YYYY') >= $1::date)`
+
+	args := []interface{}{from.Time(), to.Time()}
+	argCount := 2
+
+	if userID != nil {
 		argCount++
-		setParts = append(setParts, fmt.Sprintf("%s = $%d", field, argCount))
-		args = append(args, value)
+		query += userCondition(argCount)
+		args = append(args, *userID)
 	}
 
-	argCount++
-	query := fmt.Sprintf("UPDATE subscriptions SET %s, updated_at = NOW() WHERE id = $%d",
-		strings.Join(setParts, ", "), argCount)
-	args = append(args, id)
+	if serviceName != nil {
+		argCount++
+		query += fmt.Sprintf(" AND service_name ILIKE $%d", argCount)
+		args = append(args, "%"+*serviceName+"%")
+	}
+
+	if len(tags) > 0 {
+		argCount++
+		query += tagsCondition(argCount)
+		args = append(args, pq.Array(tags))
+	}
+
+	query += " ORDER BY id"
+
+	return r.query(query, args...)
+}
+
+// ListTrialEnding возвращает подписки, пробный период которых заканчивается в месяцах [from, to]
+func (r *SubscriptionRepository) ListTrialEnding(userID *uuid.UUID, from, to models.Month) ([]*models.Subscription, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE EXISTS (
+			SELECT 1 FROM subscription_phases ph
+			WHERE ph.subscription_id = subscriptions.id AND ph.phase_type = 'trial'
+				AND to_date(ph.end_date, 'MM-YYYY') BETWEEN $1::date AND $2::date)`
+
+	args := []interface{}{from.Time(), to.Time()}
+
+	if userID != nil {
+		query += userCondition(3)
+		args = append(args, *userID)
+	}
+
+	query += " ORDER BY id"
+
+	return r.query(query, args...)
+}
+
+func insertTags(tx *sql.Tx, subscriptionID int, tags []string) error {
+	for _, tag := range tags {
+		_, err := tx.Exec(`
+			INSERT INTO subscription_tags (subscription_id, tag) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`, subscriptionID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertPhases(tx *sql.Tx, subscriptionID int, phases []models.SubscriptionPhase) error {
+	query := `
+		INSERT INTO subscription_phases (subscription_id, phase_type, price, start_date, end_date)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	for i := range phases {
+		phase := &phases[i]
+		phase.SubscriptionID = subscriptionID
+		err := tx.QueryRow(query, subscriptionID, phase.Type, phase.Price, phase.StartDate, phase.EndDate).
+			Scan(&phase.ID, &phase.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListPrices возвращает историю цен подписки по возрастанию даты вступления в силу
+func (r *SubscriptionRepository) ListPrices(subscriptionID int) ([]models.PriceChange, error) {
+	sub := &models.Subscription{ID: subscriptionID}
+	if err := r.attachPrices(map[int]*models.Subscription{subscriptionID: sub}); err != nil {
+		return nil, err
+	}
+	return sub.Prices, nil
+}
+
+func upsertPrice(tx *sql.Tx, change *models.PriceChange) error {
+	query := `
+		INSERT INTO subscription_prices (subscription_id, price, effective_date)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (subscription_id, effective_date)
+		DO UPDATE SET price = EXCLUDED.price, created_at = NOW()
+		RETURNING id, created_at`
+
+	return tx.QueryRow(query, change.SubscriptionID, change.Price, change.EffectiveDate).
+		Scan(&change.ID, &change.CreatedAt)
+}
+
+// attachDetails загружает теги, фазы, историю цен, участников и паузы для набора подписок
+func (r *SubscriptionRepository) attachDetails(subscriptions []*models.Subscription) error {
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*models.Subscription, len(subscriptions))
+	for _, sub := range subscriptions {
+		byID[sub.ID] = sub
+	}
+
+	if err := r.attachTags(byID); err != nil {
+		return err
+	}
+	if err := r.attachPhases(byID); err != nil {
+		return err
+	}
+	if err := r.attachPrices(byID); err != nil {
+		return err
+	}
+	if err := r.attachMembers(byID); err != nil {
+		return err
+	}
+	return r.attachPauses(byID)
+}
+
+func subscriptionIDs(byID map[int]*models.Subscription) interface{} {
+	ids := make([]int64, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, int64(id))
+	}
+	return pq.Array(ids)
+}
 
-	result, err := r.db.Exec(query, args...)
+func (r *SubscriptionRepository) attachTags(byID map[int]*models.Subscription) error {
+	for _, sub := range byID {
+		sub.Tags = []string{}
+	}
+
+	query := `
+		SELECT subscription_id, tag
+		FROM subscription_tags
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, tag`
+
+	rows, err := r.db.Query(query, subscriptionIDs(byID))
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	rowsAffected, err := result.RowsAffected()
+	for rows.Next() {
+		var subscriptionID int
+		var tag string
+		if err := rows.Scan(&subscriptionID, &tag); err != nil {
+			return err
+		}
+		sub := byID[subscriptionID]
+		sub.Tags = append(sub.Tags, tag)
+	}
+
+	return rows.Err()
+}
+
+func (r *SubscriptionRepository) attachPhases(byID map[int]*models.Subscription) error {
+	for _, sub := range byID {
+		sub.Phases = []models.SubscriptionPhase{}
+	}
+
+	query := `
+		SELECT id, subscription_id, phase_type, price, start_date, end_date, created_at
+		FROM subscription_phases
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, to_date(start_date, 'MM-YYYY')`
+
+	rows, err := r.db.Query(query, subscriptionIDs(byID))
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("subscription not found")
+	for rows.Next() {
+		var phase models.SubscriptionPhase
+		err := rows.Scan(&phase.ID, &phase.SubscriptionID, &phase.Type, &phase.Price,
+			&phase.StartDate, &phase.EndDate, &phase.CreatedAt)
+		if err != nil {
+			return err
+		}
+		sub := byID[phase.SubscriptionID]
+		sub.Phases = append(sub.Phases, phase)
 	}
 
-	return nil
+	return rows.Err()
 }
 
-func (r *SubscriptionRepository) Delete(id int) error {
-	query := "DELETE FROM subscriptions WHERE id = $1"
-	result, err := r.db.Exec(query, id)
+func (r *SubscriptionRepository) attachPrices(byID map[int]*models.Subscription) error {
+	for _, sub := range byID {
+		sub.Prices = []models.PriceChange{}
+	}
+
+	query := `
+		SELECT id, subscription_id, price, effective_date, created_at
+		FROM subscription_prices
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, to_date(effective_date, 'MM-YYYY')`
+
+	rows, err := r.db.Query(query, subscriptionIDs(byID))
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
 
-	rowsAffected, err := result.RowsAffected()
+	for rows.Next() {
+		var change models.PriceChange
+		err := rows.Scan(&change.ID, &change.SubscriptionID, &change.Price,
+			&change.EffectiveDate, &change.CreatedAt)
+		if err != nil {
+			return err
+		}
+		sub := byID[change.SubscriptionID]
+		sub.Prices = append(sub.Prices, change)
+	}
+
+	return rows.Err()
+}
+
+func (r *SubscriptionRepository) attachMembers(byID map[int]*models.Subscription) error {
+	for _, sub := range byID {
+		sub.Members = []models.SubscriptionMember{}
+	}
+
+	query := `
+		SELECT id, subscription_id, user_id, share_type, share_percent, share_amount, created_at
+		FROM subscription_members
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, id`
+
+	rows, err := r.db.Query(query, subscriptionIDs(byID))
 	if err != nil {
 		return err
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member models.SubscriptionMember
+		err := rows.Scan(&member.ID, &member.SubscriptionID, &member.UserID, &member.ShareType,
+			&member.SharePercent, &member.ShareAmount, &member.CreatedAt)
+		if err != nil {
+			return err
+		}
+		sub := byID[member.SubscriptionID]
+		sub.Members = append(sub.Members, member)
+	}
+
+	return rows.Err()
+}
+
+func (r *SubscriptionRepository) attachPauses(byID map[int]*models.Subscription) error {
+	for _, sub := range byID {
+		sub.Pauses = []models.SubscriptionPause{}
+	}
+
+	query := `
+		SELECT id, subscription_id, start_date, end_date, created_at, updated_at
+		FROM subscription_pauses
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, to_date(start_date, 'MM-YYYY')`
+
+	rows, err := r.db.Query(query, subscriptionIDs(byID))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pause models.SubscriptionPause
+		err := rows.Scan(&pause.ID, &pause.SubscriptionID, &pause.StartDate, &pause.EndDate,
+			&pause.CreatedAt, &pause.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		sub := byID[pause.SubscriptionID]
+		sub.Pauses = append(sub.Pauses, pause)
+	}
+
+	return rows.Err()
+}
+
+// AddPause открывает паузу подписки
+func (r *SubscriptionRepository) AddPause(pause *models.SubscriptionPause) error {
+	query := `
+		INSERT INTO subscription_pauses (subscription_id, start_date, end_date)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, updated_at`
+
+	return r.db.QueryRow(query, pause.SubscriptionID, pause.StartDate, pause.EndDate).
+		Scan(&pause.ID, &pause.CreatedAt, &pause.UpdatedAt)
+}
+
+// ClosePause завершает паузу последним месяцем endDate
+func (r *SubscriptionRepository) ClosePause(pauseID int, endDate string) error {
+	_, err := r.db.Exec(
+		"UPDATE subscription_pauses SET end_date = $1, updated_at = NOW() WHERE id = $2",
+		endDate, pauseID)
+	return err
+}
+
+// DeletePause удаляет паузу, которая не успела начаться
+func (r *SubscriptionRepository) DeletePause(pauseID int) error {
+	_, err := r.db.Exec("DELETE FROM subscription_pauses WHERE id = $1", pauseID)
+	return err
+}
+
+// SetStatus переводит подписку из статуса from в статус to и записывает переход в журнал.
+// Возвращает false, если статус подписки уже не from (изменен параллельно).
+func (r *SubscriptionRepository) SetStatus(id int, from, to models.SubscriptionStatus, reason *string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
 
+	result, err := tx.Exec(`
+		UPDATE subscriptions SET status = $1, status_changed_at = NOW(), updated_at = NOW()
+		WHERE id = $2 AND status = $3`, to, id, from)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("subscription not found")
+		return false, nil
 	}
 
-	return nil
+	if err := insertTransition(tx, id, &from, to, reason); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
 }
 
-func (r *SubscriptionRepository) GetTotalCost(userID *uuid.UUID, serviceName *string, startPeriod, endPeriod string) (int, error) {
+// ListTransitions возвращает журнал переходов подписки в хронологическом порядке
+func (r *SubscriptionRepository) ListTransitions(subscriptionID int) ([]models.StatusTransition, error) {
 	query := `
-		SELECT COALESCE(SUM(price), 0)
-		FROM subscriptions
-		WHERE start_date >= $1 AND (end_date IS NULL OR end_date <= $2)`
+		SELECT id, subscription_id, from_status, to_status, reason, created_at
+		FROM subscription_status_transitions
+		WHERE subscription_id = $1
+		ORDER BY created_at, id`
 
-	args := []interface{}{startPeriod, endPeriod}
-	argCount := 2
+	rows, err := r.db.Query(query, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
 
-	if userID != nil {
-		argCount++
-		query += fmt.Sprintf(" AND user_id = $%d", argCount)
-		args = append(args, *userID)
+	transitions := []models.StatusTransition{}
+	for rows.Next() {
+		var t models.StatusTransition
+		err := rows.Scan(&t.ID, &t.SubscriptionID, &t.FromStatus, &t.ToStatus, &t.Reason, &t.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, t)
 	}
 
-	if serviceName != nil {
-		argCount++
-		query += fmt.Sprintf(" AND service_name ILIKE $%d", argCount)
-		args = append(args, "%"+*serviceName+"%")
+	return transitions, rows.Err()
+}
+
+func insertTransition(tx *sql.Tx, subscriptionID int, from *models.SubscriptionStatus, to models.SubscriptionStatus, reason *string) error {
+	_, err := tx.Exec(`
+		INSERT INTO subscription_status_transitions (subscription_id, from_status, to_status, reason)
+		VALUES ($1, $2, $3, $4)`, subscriptionID, from, to, reason)
+	return err
+}
+
+// AddMember добавляет участника совместной подписки
+func (r *SubscriptionRepository) AddMember(member *models.SubscriptionMember) error {
+	query := `
+		INSERT INTO subscription_members (subscription_id, user_id, share_type, share_percent, share_amount)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	return r.db.QueryRow(query, member.SubscriptionID, member.UserID, member.ShareType,
+		member.SharePercent, member.ShareAmount).
+		Scan(&member.ID, &member.CreatedAt)
+}
+
+// ListPayerIDs возвращает пользователей, которые оплачивают хотя бы одну подписку
+// как владельцы или участники
+func (r *SubscriptionRepository) ListPayerIDs() ([]uuid.UUID, error) {
+	rows, err := r.db.Query(`
+		SELECT user_id FROM subscriptions
+		UNION
+		SELECT user_id FROM subscription_members
+		ORDER BY user_id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []uuid.UUID
+	for rows.Next() {
+		var userID uuid.UUID
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// UserExists проверяет, что пользователь существует
+func (r *SubscriptionRepository) UserExists(userID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)", userID).Scan(&exists)
+	return exists, err
+}
+
+// RemoveMember удаляет участника подписки. Возвращает false, если такого участника не было.
+func (r *SubscriptionRepository) RemoveMember(subscriptionID int, userID uuid.UUID) (bool, error) {
+	query := "DELETE FROM subscription_members WHERE subscription_id = $1 AND user_id = $2"
+	result, err := r.db.Exec(query, subscriptionID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// activeCondition отбирает подписки, активные в месяце, переданном первым днем:
+// начатые, не закончившиеся и не приостановленные
+func activeCondition(arg int) string {
+	return fmt.Sprintf(` AND to_date(start_date, 'MM-YYYY') <= $%[1]d::date
+		AND (end_date IS NULL OR to_date(end_date, 'MM-YYYY') >= $%[1]d::date)
+		AND NOT EXISTS (
+			SELECT 1 FROM subscription_pauses ps
+			WHERE ps.subscription_id = subscriptions.id
+				AND to_date(ps.start_date, 'MM-YYYY') <= $%[1]d::date
+				AND (ps.end_date IS NULL OR to_date(ps.end_date, 'MM-YYYY') >= $%[1]d::date))`, arg)
+}
+
+// tagsCondition отбирает подписки, отмеченные всеми тегами из массива без повторов
+func tagsCondition(arg int) string {
+	return fmt.Sprintf(` AND (
+		SELECT COUNT(*) FROM subscription_tags t
+		WHERE t.subscription_id = subscriptions.id AND t.tag = ANY($%[1]d)) = cardinality($%[1]d::text[])`, arg)
+}
+
+// userCondition отбирает подписки, которые пользователь оплачивает как владелец или участник
+func userCondition(arg int) string {
+	return fmt.Sprintf(` AND (user_id = $%[1]d OR EXISTS (
+			SELECT 1 FROM subscription_members m
+			WHERE m.subscription_id = subscriptions.id AND m.user_id = $%[1]d))`, arg)
+}
+
+func (r *SubscriptionRepository) query(query string, args ...interface{}) ([]*models.Subscription, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []*models.Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, sub)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := r.attachDetails(subscriptions); err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// scanner — общий интерфейс *sql.Row и *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
 
-	var totalCost int
-	err := r.db.QueryRow(query, args...).Scan(&totalCost)
-	return totalCost, err
+func scanSubscription(row scanner) (*models.Subscription, error) {
+	sub := &models.Subscription{}
+	err := row.Scan(
+		&sub.ID, &sub.ServiceName, &sub.Price, &sub.Currency, &sub.BillingPeriod, &sub.BillingInterval, &sub.BillingDay,
+		&sub.UserID, &sub.PlanID, &sub.Category, &sub.StartDate, &sub.EndDate, &sub.Status, &sub.StatusChangedAt,
+		&sub.CreatedAt, &sub.UpdatedAt,
+		&sub.CatalogServiceID, &sub.CatalogServiceName, &sub.CatalogCategory)
+	return sub, err
 }
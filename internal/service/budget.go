@@ -0,0 +1,282 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go-dev/internal/models"
+	"go-dev/internal/repository"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type BudgetService struct {
+	subscriptions *SubscriptionService
+	repo          *repository.BudgetRepository
+	logger        *logrus.Logger
+}
+
+func NewBudgetService(subscriptions *SubscriptionService, repo *repository.BudgetRepository, logger *logrus.Logger) *BudgetService {
+	if logger == nil {
+		logger = logrus.New()
+	}
+	return &BudgetService{subscriptions: subscriptions, repo: repo, logger: logger}
+}
+
+func (s *BudgetService) CreateBudget(userID uuid.UUID, req *models.CreateBudgetRequest) (*models.Budget, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	budget := &models.Budget{
+		UserID:   userID,
+		Period:   req.Period,
+		Amount:   req.Amount,
+		Currency: req.Currency,
+		Scope:    req.Scope,
+	}
+	if budget.Currency == "" {
+		budget.Currency = models.DefaultCurrency
+	}
+	if budget.Scope == "" {
+		budget.Scope = models.ScopeAll
+	}
+
+	switch budget.Scope {
+	case models.ScopeCategory:
+		if budget.Category = trimmedOrNil(req.Category); budget.Category == nil {
+			return nil, fmt.Errorf("%w: category is required for category budgets", ErrValidation)
+		}
+	case models.ScopeService:
+		if budget.ServiceName = trimmedOrNil(req.ServiceName); budget.ServiceName == nil {
+			return nil, fmt.Errorf("%w: service_name is required for service budgets", ErrValidation)
+		}
+	}
+	if budget.Scope != models.ScopeCategory && req.Category != nil {
+		return nil, fmt.Errorf("%w: category is only allowed for category budgets", ErrValidation)
+	}
+	if budget.Scope != models.ScopeService && req.ServiceName != nil {
+		return nil, fmt.Errorf("%w: service_name is only allowed for service budgets", ErrValidation)
+	}
+
+	if err := s.repo.Create(budget); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// ListBudgets возвращает бюджеты пользователя с расходами за текущий период
+func (s *BudgetService) ListBudgets(userID uuid.UUID) ([]models.BudgetStatus, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	budgets, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.budgetStatuses(userID, budgets, models.MonthOf(time.Now()))
+}
+
+// GetBudget возвращает бюджет пользователя с расходами за текущий период
+func (s *BudgetService) GetBudget(userID uuid.UUID, id int) (*models.BudgetStatus, error) {
+	budget, err := s.getBudget(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := s.budgetStatuses(userID, []*models.Budget{budget}, models.MonthOf(time.Now()))
+	if err != nil {
+		return nil, err
+	}
+	return &statuses[0], nil
+}
+
+func (s *BudgetService) UpdateBudget(userID uuid.UUID, id int, req *models.UpdateBudgetRequest) error {
+	if _, err := s.getBudget(userID, id); err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.Period != nil {
+		updates["period"] = *req.Period
+	}
+	if req.Amount != nil {
+		updates["amount"] = *req.Amount
+	}
+	if req.Currency != nil {
+		updates["currency"] = *req.Currency
+	}
+
+	if len(updates) == 0 {
+		return fmt.Errorf("%w: no fields to update", ErrValidation)
+	}
+	return s.repo.Update(id, updates)
+}
+
+func (s *BudgetService) DeleteBudget(userID uuid.UUID, id int) error {
+	if _, err := s.getBudget(userID, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// ListBudgetAlerts возвращает оповещения по бюджетам пользователя, новые первыми
+func (s *BudgetService) ListBudgetAlerts(userID uuid.UUID, limit, offset int) ([]models.BudgetAlert, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListAlerts(userID, limit, offset)
+}
+
+// EvaluateBudgets сравнивает расходы пользователей за текущий период с их бюджетами
+// и создает оповещения о достижении порогов. Каждый порог отмечается один раз за период;
+// возвращаются только новые оповещения. Ошибка по одному пользователю не мешает оценить
+// бюджеты остальных: ошибки всех пользователей возвращаются вместе.
+func (s *BudgetService) EvaluateBudgets(userIDs ...uuid.UUID) ([]models.BudgetAlert, error) {
+	now := models.MonthOf(time.Now())
+	alerts := []models.BudgetAlert{}
+	var errs []error
+	for _, userID := range userIDs {
+		created, err := s.evaluateUser(userID, now)
+		alerts = append(alerts, created...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", userID, err))
+		}
+	}
+	return alerts, errors.Join(errs...)
+}
+
+// EvaluateAllBudgets оценивает бюджеты всех пользователей. Запускается периодически;
+// возвращает число новых оповещений.
+func (s *BudgetService) EvaluateAllBudgets() (int, error) {
+	userIDs, err := s.repo.ListUserIDs()
+	if err != nil {
+		return 0, err
+	}
+	alerts, err := s.EvaluateBudgets(userIDs...)
+	return len(alerts), err
+}
+
+// CheckSubscription оценивает бюджеты плательщиков подписки после изменения ее расходов.
+// Ошибки не прерывают изменение подписки и только записываются в лог: бюджеты повторно
+// оцениваются по расписанию.
+func (s *BudgetService) CheckSubscription(sub *models.Subscription) {
+	userIDs := []uuid.UUID{sub.UserID}
+	for _, member := range sub.Members {
+		userIDs = append(userIDs, member.UserID)
+	}
+	if _, err := s.EvaluateBudgets(userIDs...); err != nil {
+		s.logger.WithError(err).WithField("subscription_id", sub.ID).Error("Failed to evaluate budgets")
+	}
+}
+
+// evaluateUser создает оповещения по бюджетам пользователя за период, в который попадает месяц m
+func (s *BudgetService) evaluateUser(userID uuid.UUID, m models.Month) ([]models.BudgetAlert, error) {
+	budgets, err := s.repo.ListByUser(userID)
+	if err != nil || len(budgets) == 0 {
+		return nil, err
+	}
+	statuses, err := s.budgetStatuses(userID, budgets, m)
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []models.BudgetAlert
+	for _, status := range statuses {
+		for _, threshold := range models.BudgetThresholds {
+			if status.Spent*100 < threshold*status.Amount {
+				continue
+			}
+			alert := models.BudgetAlert{
+				BudgetID:    status.ID,
+				PeriodStart: status.PeriodStart,
+				Threshold:   threshold,
+				Spent:       status.Spent,
+				Amount:      status.Amount,
+				Currency:    status.Currency,
+			}
+			created, err := s.repo.AddAlert(&alert)
+			if err != nil {
+				return alerts, err
+			}
+			if created {
+				alerts = append(alerts, alert)
+			}
+		}
+	}
+	return alerts, nil
+}
+
+// budgetStatuses считает расходы пользователя по бюджетам за период, в который попадает месяц m.
+// Учитываются все списания периода, включая еще не наступившие, — так пользователь
+// узнает о превышении заранее.
+func (s *BudgetService) budgetStatuses(userID uuid.UUID, budgets []*models.Budget, m models.Month) ([]models.BudgetStatus, error) {
+	// Годовой период охватывает месячный, поэтому подписки загружаются один раз
+	yearly := models.Budget{Period: models.BudgetYearly}
+	from, to := yearly.PeriodAt(m)
+	subscriptions, err := s.subscriptions.repo.ListForPeriod(&userID, nil, nil, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]models.BudgetStatus, 0, len(budgets))
+	for _, budget := range budgets {
+		start, end := budget.PeriodAt(m)
+		status := models.BudgetStatus{
+			Budget:      *budget,
+			PeriodStart: start.String(),
+			PeriodEnd:   end.String(),
+		}
+
+		var covered []*models.Subscription
+		for _, sub := range subscriptions {
+			if budget.Covers(sub) {
+				covered = append(covered, sub)
+			}
+		}
+		calc, err := s.subscriptions.newCostCalculator(covered, end, budget.Currency, &userID)
+		if err != nil {
+			return nil, err
+		}
+		for _, sub := range covered {
+			charges, err := calc.charges(sub, start, end)
+			if err != nil {
+				return nil, err
+			}
+			for _, ch := range charges {
+				status.Spent += ch.Amount
+			}
+		}
+		status.SpentPercent = math.Round(float64(status.Spent)/float64(budget.Amount)*10000) / 100
+
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// getBudget возвращает бюджет, проверяя, что он принадлежит пользователю
+func (s *BudgetService) getBudget(userID uuid.UUID, id int) (*models.Budget, error) {
+	budget, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if budget == nil || budget.UserID != userID {
+		return nil, fmt.Errorf("%w: budget %d of user %s", ErrNotFound, id, userID)
+	}
+	return budget, nil
+}
+
+// trimmedOrNil возвращает строку без пробелов по краям или nil для пустой строки
+func trimmedOrNil(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"go-dev/internal/models"
+	"go-dev/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// calendarHorizonMonths — на сколько месяцев вперед вычисляются пропуски повторяющихся
+// списаний (паузы и пробный период); дальше списания повторяются без пропусков
+const calendarHorizonMonths = 24
+
+type CalendarService struct {
+	subscriptions *SubscriptionService
+	repo          *repository.CalendarRepository
+}
+
+func NewCalendarService(subscriptions *SubscriptionService, repo *repository.CalendarRepository) *CalendarService {
+	return &CalendarService{subscriptions: subscriptions, repo: repo}
+}
+
+// CreateCalendarFeed выдает новую секретную ссылку на календарь пользователя; прежняя
+// ссылка перестает работать. Хранится только хэш токена.
+func (s *CalendarService) CreateCalendarFeed(userID uuid.UUID) (*models.CalendarFeed, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	createdAt, err := s.repo.SaveToken(userID, hashCalendarToken(token))
+	if err != nil {
+		return nil, err
+	}
+	return &models.CalendarFeed{Token: token, CreatedAt: createdAt}, nil
+}
+
+// DeleteCalendarFeed отзывает ссылку на календарь пользователя
+func (s *CalendarService) DeleteCalendarFeed(userID uuid.UUID) error {
+	deleted, err := s.repo.DeleteToken(userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: calendar feed of user %s not found", ErrNotFound, userID)
+	}
+	return nil
+}
+
+// GetCalendar возвращает события календаря владельца токена: повторяющееся списание
+// по каждой действующей подписке, окончания пробных периодов и окончания подписок.
+// Списания строятся так же, как календарь списаний: по расчетному периоду, без месяцев
+// паузы и бесплатного пробного периода, суммой доли пользователя.
+func (s *CalendarService) GetCalendar(token string) ([]models.CalendarEvent, error) {
+	userID, err := s.repo.FindUser(hashCalendarToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if userID == nil {
+		return nil, fmt.Errorf("%w: calendar feed not found", ErrNotFound)
+	}
+
+	subscriptions, err := s.subscriptions.repo.List(models.SubscriptionFilter{UserID: userID})
+	if err != nil {
+		return nil, err
+	}
+
+	today := models.MonthOf(time.Now().UTC())
+	events := []models.CalendarEvent{}
+	for _, sub := range subscriptions {
+		subEvents, err := subscriptionEvents(sub, *userID, today)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, subEvents...)
+	}
+	return events, nil
+}
+
+func subscriptionEvents(sub *models.Subscription, userID uuid.UUID, today models.Month) ([]models.CalendarEvent, error) {
+	start, end, err := subscriptionSpan(sub)
+	if err != nil {
+		return nil, err
+	}
+	name := sub.CanonicalServiceName()
+
+	var events []models.CalendarEvent
+	if sub.Status != models.StatusCancelled && sub.Status != models.StatusExpired {
+		billing, err := billingEvent(sub, userID, start, end, today)
+		if err != nil {
+			return nil, err
+		}
+		if billing != nil {
+			events = append(events, *billing)
+		}
+	}
+
+	for _, phase := range sub.Phases {
+		if phase.Type != models.PhaseTrial {
+			continue
+		}
+		trialEnd, err := models.ParseMonth(phase.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("subscription %d: %w", sub.ID, err)
+		}
+		events = append(events, models.CalendarEvent{
+			UID:         calendarUID(sub.ID, "trial-"+phase.StartDate),
+			Kind:        models.CalendarTrialEnd,
+			Date:        lastDay(trialEnd),
+			Summary:     name + ": trial ends",
+			Description: fmt.Sprintf("The free trial of %s ends; regular charges start next month.", name),
+			Modified:    sub.UpdatedAt,
+		})
+	}
+
+	if end != nil {
+		events = append(events, models.CalendarEvent{
+			UID:         calendarUID(sub.ID, "end"),
+			Kind:        models.CalendarEnd,
+			Date:        lastDay(*end),
+			Summary:     name + ": subscription ends",
+			Description: fmt.Sprintf("The subscription to %s ends after %s.", name, end),
+			Modified:    sub.UpdatedAt,
+		})
+	}
+	return events, nil
+}
+
+// billingEvent строит повторяющееся событие списаний с первого списания подписки.
+// Списания, которые не оплачиваются (пауза, пробный период), до горизонта становятся
+// исключениями повторения. Сумма в названии — ближайшее списание, начиная с текущего месяца.
+func billingEvent(sub *models.Subscription, userID uuid.UUID, start models.Month, end *models.Month, today models.Month) (*models.CalendarEvent, error) {
+	last := today.AddMonths(calendarHorizonMonths)
+	if end != nil && *end < last {
+		last = *end
+	}
+	anchor, err := sub.BillingAnchor()
+	if err != nil {
+		return nil, fmt.Errorf("subscription %d: %w", sub.ID, err)
+	}
+	billed, err := billedCharges(sub, start, last)
+	if err != nil {
+		return nil, err
+	}
+	if len(billed) == 0 {
+		return nil, nil
+	}
+
+	paid := make(map[time.Time]bool, len(billed))
+	next := billed[len(billed)-1]
+	for i := len(billed) - 1; i >= 0; i-- {
+		paid[billed[i].Date] = true
+		if billed[i].Month >= today {
+			next = billed[i]
+		}
+	}
+
+	cycle := sub.Cycle()
+	event := &models.CalendarEvent{
+		UID:        calendarUID(sub.ID, "billing"),
+		Kind:       models.CalendarBilling,
+		Date:       anchor,
+		Recurrence: &cycle,
+		BillingDay: sub.ChargeDay(),
+		Modified:   sub.UpdatedAt,
+	}
+	for _, date := range chargeDates(cycle, anchor, sub.ChargeDay(), start, last) {
+		if !paid[date] {
+			event.ExDates = append(event.ExDates, date)
+		}
+	}
+	if end != nil {
+		until := lastDay(*end)
+		event.Until = &until
+	}
+
+	name := sub.CanonicalServiceName()
+	share := sub.SplitCharge(next.Amount)[userID]
+	event.Summary = fmt.Sprintf("%s: %s", name, formatAmount(share, sub.Currency))
+	event.Description = fmt.Sprintf("%s is charged %s every %s.", name, formatAmount(next.Amount, sub.Currency), describeCycle(cycle))
+	if share != next.Amount {
+		event.Description += fmt.Sprintf(" Your share is %s.", formatAmount(share, sub.Currency))
+	}
+	return event, nil
+}
+
+// describeCycle описывает расчетный период для календаря: "month", "3 months", "2 weeks"
+func describeCycle(cycle models.BillingCycle) string {
+	unit, count := string(cycle.Period), cycle.Interval
+	if cycle.Period == models.BillingQuarter {
+		unit, count = "month", 3*cycle.Interval
+	}
+	if count == 1 {
+		return unit
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
+
+// calendarUID — постоянный идентификатор события: не зависит от дат и цены подписки
+func calendarUID(subscriptionID int, kind string) string {
+	return fmt.Sprintf("subscription-%d-%s@go-dev", subscriptionID, kind)
+}
+
+func lastDay(m models.Month) time.Time {
+	return m.AddMonths(1).Time().AddDate(0, 0, -1)
+}
+
+func hashCalendarToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
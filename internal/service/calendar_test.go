@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+	"go-dev/internal/ical"
+	"go-dev/internal/models"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestBillingEventMatchesBilledCharges проверяет, что RRULE и EXDATE опубликованного
+// события разворачиваются ровно в те даты, которые считает billedCharges
+func TestBillingEventMatchesBilledCharges(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	userID := uuid.New()
+	today, _ := models.ParseMonth("01-2025")
+
+	tests := []struct {
+		name string
+		sub  models.Subscription
+	}{
+		{
+			name: "monthly on the 31st starting in February",
+			sub:  models.Subscription{StartDate: "02-2025", BillingDay: 31},
+		},
+		{
+			name: "monthly on the 30th starting in leap February",
+			sub:  models.Subscription{StartDate: "02-2024", BillingDay: 30},
+		},
+		{
+			name: "monthly on the 15th",
+			sub:  models.Subscription{StartDate: "06-2024", BillingDay: 15},
+		},
+		{
+			name: "every two months on the 31st",
+			sub:  models.Subscription{StartDate: "04-2024", BillingDay: 31, BillingPeriod: models.BillingMonth, BillingInterval: 2},
+		},
+		{
+			name: "quarterly on the 31st",
+			sub:  models.Subscription{StartDate: "11-2024", BillingDay: 31, BillingPeriod: models.BillingQuarter},
+		},
+		{
+			name: "yearly on leap day",
+			sub:  models.Subscription{StartDate: "02-2024", BillingDay: 29, BillingPeriod: models.BillingYear},
+		},
+		{
+			name: "every two weeks",
+			sub:  models.Subscription{StartDate: "12-2024", BillingDay: 30, BillingPeriod: models.BillingWeek, BillingInterval: 2},
+		},
+		{
+			name: "trial, pause and end date",
+			sub: models.Subscription{
+				StartDate:  "01-2025",
+				EndDate:    strPtr("12-2025"),
+				BillingDay: 31,
+				Phases: []models.SubscriptionPhase{
+					{Type: models.PhaseTrial, StartDate: "01-2025", EndDate: "02-2025"},
+				},
+				Pauses: []models.SubscriptionPause{
+					{StartDate: "06-2025", EndDate: strPtr("07-2025")},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := tt.sub
+			sub.ID = 1
+			sub.ServiceName = "Netflix"
+			sub.Price = 1000
+			sub.Currency = "USD"
+			sub.UserID = userID
+
+			start, end, err := subscriptionSpan(&sub)
+			if err != nil {
+				t.Fatal(err)
+			}
+			event, err := billingEvent(&sub, userID, start, end, today)
+			if err != nil {
+				t.Fatal(err)
+			}
+			last := today.AddMonths(calendarHorizonMonths)
+			if end != nil && *end < last {
+				last = *end
+			}
+			billed, err := billedCharges(&sub, start, last)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := ical.Write(&buf, &ical.Calendar{Name: "test", Events: []models.CalendarEvent{*event}}); err != nil {
+				t.Fatal(err)
+			}
+			got := expandEvent(t, buf.String(), lastDay(last))
+
+			if len(got) != len(billed) {
+				t.Fatalf("RRULE expands to %d dates, billedCharges has %d:\n%v\n%v", len(got), len(billed), got, billed)
+			}
+			for i, charge := range billed {
+				if !got[i].Equal(charge.Date) {
+					t.Errorf("occurrence %d: RRULE gives %s, billedCharges gives %s",
+						i, got[i].Format(time.DateOnly), charge.Date.Format(time.DateOnly))
+				}
+			}
+		})
+	}
+}
+
+// expandEvent разворачивает единственное событие календаря до horizon включительно.
+// Поддерживает только то подмножество RRULE, которое пишет ical.Write.
+func expandEvent(t *testing.T, calendar string, horizon time.Time) []time.Time {
+	t.Helper()
+
+	var dtstart time.Time
+	rule := map[string]string{}
+	exdates := map[time.Time]bool{}
+	for _, line := range strings.Split(strings.ReplaceAll(calendar, "\r\n ", ""), "\r\n") {
+		name, value, _ := strings.Cut(line, ":")
+		switch name {
+		case "DTSTART;VALUE=DATE":
+			dtstart = parseICalDate(t, value)
+		case "RRULE":
+			for _, part := range strings.Split(value, ";") {
+				key, val, _ := strings.Cut(part, "=")
+				rule[key] = val
+			}
+		case "EXDATE;VALUE=DATE":
+			for _, date := range strings.Split(value, ",") {
+				exdates[parseICalDate(t, date)] = true
+			}
+		}
+	}
+
+	until := horizon
+	if value, ok := rule["UNTIL"]; ok {
+		if u := parseICalDate(t, value); u.Before(until) {
+			until = u
+		}
+	}
+	interval := 1
+	if value, ok := rule["INTERVAL"]; ok {
+		interval, _ = strconv.Atoi(value)
+	}
+	var monthDays []int
+	if value, ok := rule["BYMONTHDAY"]; ok {
+		for _, d := range strings.Split(value, ",") {
+			day, _ := strconv.Atoi(d)
+			monthDays = append(monthDays, day)
+		}
+		if rule["BYSETPOS"] != "-1" {
+			t.Fatalf("unsupported BYSETPOS %q", rule["BYSETPOS"])
+		}
+	}
+
+	// occurrence возвращает повторение в месяце m или false, если его нет
+	occurrence := func(m models.Month) (time.Time, bool) {
+		days := monthDays
+		if days == nil {
+			days = []int{dtstart.Day()}
+		}
+		var found time.Time
+		for _, day := range days {
+			if date := m.Day(day); date.Day() == day {
+				found = date // BYSETPOS=-1: последний существующий день
+			}
+		}
+		return found, !found.IsZero()
+	}
+
+	var dates []time.Time
+	add := func(date time.Time) {
+		if !date.Before(dtstart) && !exdates[date] {
+			dates = append(dates, date)
+		}
+	}
+	switch rule["FREQ"] {
+	case "WEEKLY":
+		for date := dtstart; !date.After(until); date = date.AddDate(0, 0, 7*interval) {
+			add(date)
+		}
+	case "MONTHLY", "YEARLY":
+		step := interval
+		first := models.MonthOf(dtstart)
+		if rule["FREQ"] == "YEARLY" {
+			step *= 12
+			month, _ := strconv.Atoi(rule["BYMONTH"])
+			if time.Month(month) != dtstart.Month() {
+				t.Fatalf("BYMONTH %d does not match DTSTART %s", month, dtstart.Format(time.DateOnly))
+			}
+		}
+		for m := first; m.Time().Before(until) || m.Time().Equal(until); m = m.AddMonths(step) {
+			if date, ok := occurrence(m); ok && !date.After(until) {
+				add(date)
+			}
+		}
+	default:
+		t.Fatalf("unsupported FREQ %q", rule["FREQ"])
+	}
+	return dates
+}
+
+func parseICalDate(t *testing.T, s string) time.Time {
+	t.Helper()
+	date, err := time.Parse("20060102", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return date
+}
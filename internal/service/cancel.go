@@ -0,0 +1,179 @@
+package service
+
+import (
+	"fmt"
+	"go-dev/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// cancellationUndoWindow — сколько времени после отмены ее можно отозвать
+const cancellationUndoWindow = 7 * 24 * time.Hour
+
+// Cancel отменяет подписку: immediate — подписка заканчивается текущим месяцем,
+// at_period_end — последним месяцем уже оплаченного расчетного периода.
+// end_date вычисляется по расчетному периоду подписки и не переносится позже уже заданной.
+func (s *SubscriptionService) Cancel(id int, req *models.CancelRequest) (*models.CancelResponse, error) {
+	sub, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.cancel(sub, req)
+}
+
+func (s *SubscriptionService) cancel(sub *models.Subscription, req *models.CancelRequest) (*models.CancelResponse, error) {
+	status := models.StatusPendingCancellation
+	if req.Mode == models.CancelImmediate {
+		status = models.StatusCancelled
+	}
+	if !sub.Status.CanTransitionTo(status) {
+		return nil, fmt.Errorf("%w: %s subscription cannot be cancelled %s", ErrConflict, sub.Status, req.Mode)
+	}
+
+	now := time.Now()
+	end := models.MonthOf(now)
+	if req.Mode == models.CancelAtPeriodEnd {
+		var err error
+		if end, err = periodEnd(sub, now); err != nil {
+			return nil, err
+		}
+	}
+
+	start, currentEnd, err := subscriptionSpan(sub)
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		end = start
+	}
+	if currentEnd != nil && *currentEnd < end {
+		end = *currentEnd
+	}
+
+	c := &models.Cancellation{
+		SubscriptionID:  sub.ID,
+		Mode:            req.Mode,
+		ReasonCode:      req.ReasonCode,
+		Comment:         req.Comment,
+		EndDate:         end.String(),
+		PreviousEndDate: sub.EndDate,
+		PreviousStatus:  sub.Status,
+	}
+	ok, err := s.repo.Cancel(c, status, cancellationReason(req))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: subscription status was changed concurrently", ErrConflict)
+	}
+	c.UndoUntil = c.CreatedAt.Add(cancellationUndoWindow)
+
+	updated, err := s.GetByID(sub.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.CancelResponse{Subscription: updated, Cancellation: c}, nil
+}
+
+// UndoCancel отзывает последнюю отмену подписки, если окно отмены еще не истекло.
+// Восстанавливаются end_date и статус, которые были до отмены.
+func (s *SubscriptionService) UndoCancel(id int) (*models.Subscription, error) {
+	sub, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.undoCancel(sub)
+}
+
+func (s *SubscriptionService) undoCancel(sub *models.Subscription) (*models.Subscription, error) {
+	if sub.Status != models.StatusCancelled && sub.Status != models.StatusPendingCancellation {
+		return nil, fmt.Errorf("%w: %s subscription is not cancelled", ErrConflict, sub.Status)
+	}
+	c, err := s.repo.LatestCancellation(sub.ID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, fmt.Errorf("%w: subscription has no cancellation to undo", ErrConflict)
+	}
+	if undoUntil := c.CreatedAt.Add(cancellationUndoWindow); time.Now().After(undoUntil) {
+		return nil, fmt.Errorf("%w: undo window closed at %s", ErrConflict, undoUntil.Format(time.RFC3339))
+	}
+
+	// Статус после отзыва — тот, что подписка имела бы без отмены на сегодня.
+	// Окно отмены уже проверено, поэтому таблица переходов здесь не применяется:
+	// в ней отмененная подписка окончательна.
+	restored := *sub
+	restored.EndDate, restored.Status = c.PreviousEndDate, c.PreviousStatus
+	to := scheduledStatus(&restored, models.MonthOf(time.Now()))
+
+	reason := "cancellation undone"
+	ok, err := s.repo.RevokeCancellation(c, sub.Status, to, &reason)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("%w: subscription status was changed concurrently", ErrConflict)
+	}
+	return s.GetByID(sub.ID)
+}
+
+// GetCancellationReasons считает отмены, сделанные в месяцах [startPeriod, endPeriod], по причинам
+func (s *SubscriptionService) GetCancellationReasons(userID *uuid.UUID, startPeriod, endPeriod models.Month) (*models.CancellationReportResponse, error) {
+	reasons, err := s.repo.CountCancellationReasons(userID, startPeriod, endPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, reason := range reasons {
+		total += reason.Count
+	}
+
+	return &models.CancellationReportResponse{
+		Period:  formatPeriod(startPeriod, endPeriod),
+		Filters: costFilters(userID, nil),
+		Total:   total,
+		Reasons: reasons,
+	}, nil
+}
+
+// periodEnd возвращает последний месяц расчетного периода, уже оплаченного на момент now.
+// Для еще не начавшейся подписки — месяц ее начала.
+func periodEnd(sub *models.Subscription, now time.Time) (models.Month, error) {
+	anchor, err := sub.BillingAnchor()
+	if err != nil {
+		return 0, fmt.Errorf("subscription %d: %w", sub.ID, err)
+	}
+	if now.Before(anchor) {
+		return models.MonthOf(anchor), nil
+	}
+
+	// Ищем ближайшее списание после now; период заканчивается накануне
+	cycle := sub.Cycle()
+	for from := models.MonthOf(now); ; from = from.AddMonths(12) {
+		for _, date := range chargeDates(cycle, anchor, sub.ChargeDay(), from, from.AddMonths(11)) {
+			if date.After(now) {
+				return models.MonthOf(date.AddDate(0, 0, -1)), nil
+			}
+		}
+	}
+}
+
+// cancellationReason формирует причину перехода для журнала статусов
+func cancellationReason(req *models.CancelRequest) *string {
+	var parts []string
+	if req.ReasonCode != nil {
+		parts = append(parts, string(*req.ReasonCode))
+	}
+	if req.Comment != nil && strings.TrimSpace(*req.Comment) != "" {
+		parts = append(parts, strings.TrimSpace(*req.Comment))
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	reason := strings.Join(parts, ": ")
+	return &reason
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"fmt"
+	"go-dev/internal/models"
+	"go-dev/internal/repository"
+	"strings"
+)
+
+type CatalogService struct {
+	repo *repository.CatalogRepository
+}
+
+func NewCatalogService(repo *repository.CatalogRepository) *CatalogService {
+	return &CatalogService{repo: repo}
+}
+
+func (s *CatalogService) CreateService(req *models.CreateServiceRequest) (*models.Service, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := s.checkNameAvailable(name, 0); err != nil {
+		return nil, err
+	}
+
+	service := &models.Service{
+		Name:     name,
+		Vendor:   req.Vendor,
+		Category: req.Category,
+		Plans:    make([]models.Plan, 0, len(req.Plans)),
+	}
+	for i := range req.Plans {
+		service.Plans = append(service.Plans, newPlan(&req.Plans[i]))
+	}
+
+	if err := s.repo.CreateService(service); err != nil {
+		return nil, err
+	}
+	return service, nil
+}
+
+func (s *CatalogService) GetService(id int) (*models.Service, error) {
+	service, err := s.repo.GetService(id)
+	if err != nil {
+		return nil, err
+	}
+	if service == nil {
+		return nil, fmt.Errorf("%w: service %d", ErrNotFound, id)
+	}
+	return service, nil
+}
+
+func (s *CatalogService) ListServices(category *string, limit, offset int) ([]*models.Service, error) {
+	return s.repo.ListServices(category, limit, offset)
+}
+
+func (s *CatalogService) UpdateService(id int, req *models.UpdateServiceRequest) error {
+	if _, err := s.GetService(id); err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if err := s.checkNameAvailable(name, id); err != nil {
+			return err
+		}
+		updates["name"] = name
+	}
+	if req.Vendor != nil {
+		updates["vendor"] = *req.Vendor
+	}
+	if req.Category != nil {
+		updates["category"] = *req.Category
+	}
+
+	if len(updates) == 0 {
+		return fmt.Errorf("%w: no fields to update", ErrValidation)
+	}
+	return s.repo.UpdateService(id, updates)
+}
+
+func (s *CatalogService) DeleteService(id int) error {
+	if _, err := s.GetService(id); err != nil {
+		return err
+	}
+	return s.repo.DeleteService(id)
+}
+
+func (s *CatalogService) CreatePlan(serviceID int, req *models.CreatePlanRequest) (*models.Plan, error) {
+	if _, err := s.GetService(serviceID); err != nil {
+		return nil, err
+	}
+
+	plan := newPlan(req)
+	plan.ServiceID = serviceID
+	if err := s.repo.CreatePlan(&plan); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+func (s *CatalogService) ListPlans(serviceID int) ([]models.Plan, error) {
+	if _, err := s.GetService(serviceID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListPlans(serviceID)
+}
+
+// GetPlan возвращает тарифный план, проверяя, что он принадлежит сервису
+func (s *CatalogService) GetPlan(serviceID, planID int) (*models.Plan, error) {
+	plan, err := s.repo.GetPlan(planID)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil || plan.ServiceID != serviceID {
+		return nil, fmt.Errorf("%w: plan %d of service %d", ErrNotFound, planID, serviceID)
+	}
+	return plan, nil
+}
+
+func (s *CatalogService) UpdatePlan(serviceID, planID int, req *models.UpdatePlanRequest) error {
+	if _, err := s.GetPlan(serviceID, planID); err != nil {
+		return err
+	}
+
+	updates := make(map[string]interface{})
+
+	if req.Name != nil {
+		updates["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Price != nil {
+		updates["price"] = *req.Price
+	}
+	if req.Currency != nil {
+		updates["currency"] = *req.Currency
+	}
+	if req.BillingPeriod != nil {
+		updates["billing_period"] = *req.BillingPeriod
+	}
+	if req.BillingInterval != nil {
+		updates["billing_interval"] = *req.BillingInterval
+	}
+
+	if len(updates) == 0 {
+		return fmt.Errorf("%w: no fields to update", ErrValidation)
+	}
+	return s.repo.UpdatePlan(planID, updates)
+}
+
+func (s *CatalogService) DeletePlan(serviceID, planID int) error {
+	if _, err := s.GetPlan(serviceID, planID); err != nil {
+		return err
+	}
+	return s.repo.DeletePlan(planID)
+}
+
+// checkNameAvailable проверяет, что название не занято другим сервисом (без учета регистра)
+func (s *CatalogService) checkNameAvailable(name string, exceptID int) error {
+	if name == "" {
+		return fmt.Errorf("%w: service name must not be empty", ErrValidation)
+	}
+	existing, err := s.repo.GetServiceByName(name)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.ID != exceptID {
+		return fmt.Errorf("%w: service with name %s already exists", ErrConflict, existing.Name)
+	}
+	return nil
+}
+
+func newPlan(req *models.CreatePlanRequest) models.Plan {
+	cycle := models.BillingCycle{Period: req.BillingPeriod, Interval: req.BillingInterval}.Normalize()
+
+	plan := models.Plan{
+		Name:            strings.TrimSpace(req.Name),
+		Price:           req.Price,
+		Currency:        req.Currency,
+		BillingPeriod:   cycle.Period,
+		BillingInterval: cycle.Interval,
+	}
+	if plan.Currency == "" {
+		plan.Currency = models.DefaultCurrency
+	}
+	return plan
+}
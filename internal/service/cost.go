@@ -0,0 +1,415 @@
+package service
+
+import (
+	"fmt"
+	"go-dev/internal/models"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// subscriptionSpan возвращает месяц начала подписки и месяц окончания (nil — бессрочная)
+func subscriptionSpan(sub *models.Subscription) (start models.Month, end *models.Month, err error) {
+	start, err = models.ParseMonth(sub.StartDate)
+	if err != nil {
+		return 0, nil, fmt.Errorf("subscription %d: %w", sub.ID, err)
+	}
+	if sub.EndDate != nil {
+		e, err := models.ParseMonth(*sub.EndDate)
+		if err != nil {
+			return 0, nil, fmt.Errorf("subscription %d: %w", sub.ID, err)
+		}
+		end = &e
+	}
+	return start, end, nil
+}
+
+// activeMonths возвращает первый и последний месяцы, в которых подписка активна
+// внутри периода [from, to]. Бессрочная подписка считается активной до конца периода.
+// ok = false, если подписка с периодом не пересекается.
+func activeMonths(sub *models.Subscription, from, to models.Month) (first, last models.Month, ok bool, err error) {
+	start, end, err := subscriptionSpan(sub)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	first, last = start, to
+	if end != nil && *end < last {
+		last = *end
+	}
+	if first < from {
+		first = from
+	}
+
+	return first, last, first <= last, nil
+}
+
+// charge — сумма, списанная по подписке в конкретном месяце
+type charge struct {
+	Month  models.Month
+	Amount int
+	Shares map[uuid.UUID]int // доли плательщиков; заполняет costCalculator
+}
+
+// monthlyCharges раскладывает оплату подписки в периоде [from, to] по месяцам.
+// Возвращает по одной записи на каждый месяц, в котором подписка активна (месяцы паузы
+// пропускаются); Amount равен нулю в месяцы между списаниями (например, у годовой подписки).
+func monthlyCharges(sub *models.Subscription, from, to models.Month) ([]charge, error) {
+	first, last, ok, err := activeMonths(sub, from, to)
+	if err != nil || !ok {
+		return nil, err
+	}
+	anchor, err := sub.BillingAnchor()
+	if err != nil {
+		return nil, fmt.Errorf("subscription %d: %w", sub.ID, err)
+	}
+
+	charges := make([]charge, first.MonthsUntil(last))
+	for i := range charges {
+		charges[i].Month = first.AddMonths(i)
+	}
+	for _, date := range chargeDates(sub.Cycle(), anchor, sub.ChargeDay(), first, last) {
+		month := models.MonthOf(date)
+		charges[month-first].Amount += sub.PriceAt(month)
+	}
+
+	// Месяцы паузы не оплачиваются и не считаются активными
+	if len(sub.Pauses) > 0 {
+		active := charges[:0]
+		for _, ch := range charges {
+			if !sub.IsPausedAt(ch.Month) {
+				active = append(active, ch)
+			}
+		}
+		charges = active
+	}
+	return charges, nil
+}
+
+// costCalculator считает списания подписок в валюте отчета
+type costCalculator struct {
+	currency  string
+	converter *currencyConverter
+	userID    *uuid.UUID // если задан, учитывается только доля этого пользователя
+}
+
+// newCostCalculator определяет валюту отчета и загружает курсы, если они нужны.
+// Без явной валюты отчет строится в валюте подписок; если валюты различаются,
+// валюту нужно указать явно.
+func (s *SubscriptionService) newCostCalculator(subscriptions []*models.Subscription, to models.Month, currency string, userID *uuid.UUID) (*costCalculator, error) {
+	explicit := currency != ""
+	needsRates := false
+	for _, sub := range subscriptions {
+		if currency == "" {
+			currency = sub.Currency
+		}
+		if sub.Currency != currency {
+			if !explicit {
+				return nil, fmt.Errorf("%w: currency is required when subscriptions use different currencies", ErrValidation)
+			}
+			needsRates = true
+		}
+	}
+
+	calc := &costCalculator{currency: currency, userID: userID}
+	if calc.currency == "" {
+		calc.currency = models.DefaultCurrency
+	}
+
+	if needsRates {
+		rates, err := s.rates.ListUpTo(to)
+		if err != nil {
+			return nil, err
+		}
+		calc.converter = newCurrencyConverter(rates)
+	}
+
+	return calc, nil
+}
+
+// charges возвращает помесячные списания подписки в периоде [from, to] в валюте отчета,
+// разделенные между плательщиками совместной подписки. Каждая доля пересчитывается
+// отдельно, поэтому Amount всегда равен сумме долей.
+func (c *costCalculator) charges(sub *models.Subscription, from, to models.Month) ([]charge, error) {
+	charges, err := monthlyCharges(sub, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range charges {
+		ch := &charges[i]
+		shares := sub.SplitCharge(ch.Amount)
+		if c.userID != nil {
+			shares = map[uuid.UUID]int{*c.userID: shares[*c.userID]}
+		}
+
+		ch.Amount = 0
+		for userID, share := range shares {
+			if share, err = c.convert(share, sub.Currency, ch.Month); err != nil {
+				return nil, err
+			}
+			shares[userID] = share
+			ch.Amount += share
+		}
+		ch.Shares = shares
+	}
+	return charges, nil
+}
+
+// convert пересчитывает сумму из валюты currency в валюту отчета по курсу месяца m
+func (c *costCalculator) convert(amount int, currency string, m models.Month) (int, error) {
+	if currency == c.currency {
+		return amount, nil
+	}
+	return c.converter.convert(amount, currency, c.currency, m)
+}
+
+// chargeDates возвращает даты списаний по циклу cycle, начиная с anchor,
+// попадающие в месяцы [from, to]. Помесячные циклы списываются в день day
+// каждого расчетного месяца, в коротких месяцах — в последний день.
+func chargeDates(cycle models.BillingCycle, anchor time.Time, day int, from, to models.Month) []time.Time {
+	var dates []time.Time
+
+	if cycle.Period == models.BillingWeek {
+		stepDays := 7 * cycle.Interval
+		date := anchor
+		if fromTime := from.Time(); date.Before(fromTime) {
+			days := int(fromTime.Sub(date).Hours() / 24)
+			skip := (days + stepDays - 1) / stepDays
+			date = date.AddDate(0, 0, skip*stepDays)
+		}
+		for ; models.MonthOf(date) <= to; date = date.AddDate(0, 0, stepDays) {
+			if models.MonthOf(date) >= from {
+				dates = append(dates, date)
+			}
+		}
+		return dates
+	}
+
+	step := cycle.Months()
+	anchorMonth := models.MonthOf(anchor)
+	skip := 0
+	if anchorMonth < from {
+		skip = (int(from-anchorMonth) + step - 1) / step
+	}
+	for k := skip; ; k++ {
+		month := anchorMonth.AddMonths(k * step)
+		if month > to {
+			break
+		}
+		dates = append(dates, month.Day(day))
+	}
+	return dates
+}
+
+// groupKey — значения измерений группировки; неиспользуемые измерения остаются нулевыми
+type groupKey struct {
+	serviceName string
+	userID      uuid.UUID
+	month       models.Month
+}
+
+// costGroups накапливает промежуточные итоги по измерениям groupBy
+type costGroups struct {
+	groupBy []models.GroupBy
+	totals  map[groupKey]int
+}
+
+func newCostGroups(groupBy []models.GroupBy) *costGroups {
+	return &costGroups{groupBy: groupBy, totals: make(map[groupKey]int)}
+}
+
+// add учитывает списание; при группировке по пользователю сумма делится
+// между плательщиками совместной подписки
+func (g *costGroups) add(sub *models.Subscription, ch charge) {
+	if len(g.groupBy) == 0 {
+		return
+	}
+
+	var key groupKey
+	byUser := false
+	for _, dim := range g.groupBy {
+		switch dim {
+		case models.GroupByServiceName:
+			key.serviceName = sub.CanonicalServiceName()
+		case models.GroupByUserID:
+			byUser = true
+		case models.GroupByMonth:
+			key.month = ch.Month
+		}
+	}
+
+	if !byUser {
+		if ch.Amount != 0 {
+			g.totals[key] += ch.Amount
+		}
+		return
+	}
+	for userID, share := range ch.Shares {
+		if share != 0 {
+			key.userID = userID
+			g.totals[key] += share
+		}
+	}
+}
+
+// result возвращает группы в стабильном порядке: по месяцу, сервису и пользователю
+func (g *costGroups) result() []models.CostGroup {
+	if len(g.groupBy) == 0 {
+		return nil
+	}
+
+	keys := make([]groupKey, 0, len(g.totals))
+	for key := range g.totals {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.month != b.month {
+			return a.month < b.month
+		}
+		if a.serviceName != b.serviceName {
+			return a.serviceName < b.serviceName
+		}
+		return a.userID.String() < b.userID.String()
+	})
+
+	groups := make([]models.CostGroup, 0, len(keys))
+	for _, key := range keys {
+		group := models.CostGroup{TotalCost: g.totals[key]}
+		for _, dim := range g.groupBy {
+			switch dim {
+			case models.GroupByServiceName:
+				serviceName := key.serviceName
+				group.ServiceName = &serviceName
+			case models.GroupByUserID:
+				userID := key.userID
+				group.UserID = &userID
+			case models.GroupByMonth:
+				month := key.month
+				group.Month = &month
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// validateDates проверяет формат дат подписки и что окончание не раньше начала
+func validateDates(startDate string, endDate *string) error {
+	start, err := models.ParseMonth(startDate)
+	if err != nil {
+		return fmt.Errorf("%w: start_date: %v", ErrValidation, err)
+	}
+	if endDate == nil {
+		return nil
+	}
+	end, err := models.ParseMonth(*endDate)
+	if err != nil {
+		return fmt.Errorf("%w: end_date: %v", ErrValidation, err)
+	}
+	if end < start {
+		return fmt.Errorf("%w: end_date must not be before start_date", ErrValidation)
+	}
+	return nil
+}
+
+// withDerivedPrices заполняет вычисляемые поля подписок: среднемесячную и годовую цену.
+// Текущая цена берется из истории цен, чтобы запланированное повышение
+// отражалось с месяца вступления в силу.
+func withDerivedPrices(subscriptions ...*models.Subscription) {
+	now := models.MonthOf(time.Now())
+	for _, sub := range subscriptions {
+		sub.Price = sub.BasePriceAt(now)
+		cycle := sub.Cycle()
+		sub.MonthlyEquivalent = cycle.MonthlyEquivalent(sub.Price)
+		sub.AnnualizedPrice = cycle.Annualize(sub.Price)
+	}
+}
+
+// buildPhases раскладывает фазы подписки подряд, начиная с месяца startDate
+func buildPhases(startDate string, reqs []models.PhaseRequest) ([]models.SubscriptionPhase, error) {
+	start, err := models.ParseMonth(startDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: start_date: %v", ErrValidation, err)
+	}
+
+	phases := make([]models.SubscriptionPhase, 0, len(reqs))
+	seenIntro := false
+	for _, req := range reqs {
+		price := req.Price
+		switch req.Type {
+		case models.PhaseTrial:
+			if seenIntro {
+				return nil, fmt.Errorf("%w: trial phase must precede intro phases", ErrValidation)
+			}
+			price = 0
+		case models.PhaseIntro:
+			seenIntro = true
+		}
+
+		end := start.AddMonths(req.Months - 1)
+		phases = append(phases, models.SubscriptionPhase{
+			Type:      req.Type,
+			Price:     price,
+			StartDate: start.String(),
+			EndDate:   end.String(),
+		})
+		start = end.AddMonths(1)
+	}
+	return phases, nil
+}
+
+// newPriceChange готовит запись истории цен. Без явной даты цена действует с текущего месяца;
+// phaseRequests восстанавливает запрос фаз по сохраненным фазам: виды, длительности
+// и цены, чтобы перестроить фазы от нового месяца начала подписки
+func phaseRequests(phases []models.SubscriptionPhase) ([]models.PhaseRequest, error) {
+	reqs := make([]models.PhaseRequest, 0, len(phases))
+	for _, phase := range phases {
+		start, err := models.ParseMonth(phase.StartDate)
+		if err != nil {
+			return nil, err
+		}
+		end, err := models.ParseMonth(phase.EndDate)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, models.PhaseRequest{Type: phase.Type, Months: start.MonthsUntil(end), Price: phase.Price})
+	}
+	return reqs, nil
+}
+
+// даты раньше начала подписки сдвигаются на месяц начала.
+func newPriceChange(subscriptionID, price int, effectiveDate *string, startDate string) (*models.PriceChange, error) {
+	start, err := models.ParseMonth(startDate)
+	if err != nil {
+		return nil, fmt.Errorf("%w: start_date: %v", ErrValidation, err)
+	}
+
+	effective := models.MonthOf(time.Now())
+	if effectiveDate != nil {
+		if effective, err = models.ParseMonth(*effectiveDate); err != nil {
+			return nil, fmt.Errorf("%w: price_effective_date: %v", ErrValidation, err)
+		}
+	}
+	if effective < start {
+		effective = start
+	}
+
+	return &models.PriceChange{
+		SubscriptionID: subscriptionID,
+		Price:          price,
+		EffectiveDate:  effective.String(),
+	}, nil
+}
+
+// priceDelta возвращает изменение цены в абсолютных единицах и процентах
+func priceDelta(from, to int) (int, float64) {
+	change := to - from
+	if from == 0 {
+		return change, 0
+	}
+	return change, math.Round(float64(change)/float64(from)*10000) / 100
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"go-dev/internal/models"
+	"testing"
+	"time"
+)
+
+func mustMonth(t *testing.T, s string) models.Month {
+	t.Helper()
+	m, err := models.ParseMonth(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func mustDate(t *testing.T, s string) time.Time {
+	t.Helper()
+	date, err := time.Parse(models.DateLayout, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return date
+}
+
+func formatDates(dates []time.Time) []string {
+	formatted := make([]string, len(dates))
+	for i, date := range dates {
+		formatted[i] = date.Format(models.DateLayout)
+	}
+	return formatted
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestChargeDates(t *testing.T) {
+	monthly := models.BillingCycle{Period: models.BillingMonth, Interval: 1}
+	tests := []struct {
+		name     string
+		cycle    models.BillingCycle
+		anchor   string
+		day      int
+		from, to string
+		want     []string
+	}{
+		{
+			name:   "monthly on the 31st starting in February",
+			cycle:  monthly,
+			anchor: "2025-02-28", day: 31,
+			from: "02-2025", to: "06-2025",
+			want: []string{"2025-02-28", "2025-03-31", "2025-04-30", "2025-05-31", "2025-06-30"},
+		},
+		{
+			name:   "monthly on the 29th across a leap February",
+			cycle:  monthly,
+			anchor: "2024-01-29", day: 29,
+			from: "01-2024", to: "03-2024",
+			want: []string{"2024-01-29", "2024-02-29", "2024-03-29"},
+		},
+		{
+			name:   "monthly on the 29th across a common February",
+			cycle:  monthly,
+			anchor: "2025-01-29", day: 29,
+			from: "01-2025", to: "03-2025",
+			want: []string{"2025-01-29", "2025-02-28", "2025-03-29"},
+		},
+		{
+			name:   "monthly on the 30th from a later month",
+			cycle:  monthly,
+			anchor: "2024-01-30", day: 30,
+			from: "05-2024", to: "06-2024",
+			want: []string{"2024-05-30", "2024-06-30"},
+		},
+		{
+			name:   "period starting before the first charge",
+			cycle:  monthly,
+			anchor: "2025-03-10", day: 10,
+			from: "01-2025", to: "04-2025",
+			want: []string{"2025-03-10", "2025-04-10"},
+		},
+		{
+			name:   "every two months on the 31st",
+			cycle:  models.BillingCycle{Period: models.BillingMonth, Interval: 2},
+			anchor: "2024-04-30", day: 31,
+			from: "04-2024", to: "10-2024",
+			want: []string{"2024-04-30", "2024-06-30", "2024-08-31", "2024-10-31"},
+		},
+		{
+			name:   "quarterly on the 31st",
+			cycle:  models.BillingCycle{Period: models.BillingQuarter, Interval: 1},
+			anchor: "2024-11-30", day: 31,
+			from: "11-2024", to: "08-2025",
+			want: []string{"2024-11-30", "2025-02-28", "2025-05-31", "2025-08-31"},
+		},
+		{
+			name:   "quarterly from the middle of a quarter",
+			cycle:  models.BillingCycle{Period: models.BillingQuarter, Interval: 1},
+			anchor: "2024-01-15", day: 15,
+			from: "03-2024", to: "09-2024",
+			want: []string{"2024-04-15", "2024-07-15"},
+		},
+		{
+			name:   "yearly on leap day",
+			cycle:  models.BillingCycle{Period: models.BillingYear, Interval: 1},
+			anchor: "2024-02-29", day: 29,
+			from: "01-2024", to: "12-2026",
+			want: []string{"2024-02-29", "2025-02-28", "2026-02-28"},
+		},
+		{
+			name:   "weekly from a later month",
+			cycle:  models.BillingCycle{Period: models.BillingWeek, Interval: 1},
+			anchor: "2025-01-30", day: 30,
+			from: "02-2025", to: "02-2025",
+			want: []string{"2025-02-06", "2025-02-13", "2025-02-20", "2025-02-27"},
+		},
+		{
+			name:   "every two weeks",
+			cycle:  models.BillingCycle{Period: models.BillingWeek, Interval: 2},
+			anchor: "2025-01-01", day: 1,
+			from: "01-2025", to: "01-2025",
+			want: []string{"2025-01-01", "2025-01-15", "2025-01-29"},
+		},
+		{
+			name:   "period before the first charge",
+			cycle:  monthly,
+			anchor: "2025-03-10", day: 10,
+			from: "01-2025", to: "02-2025",
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := formatDates(chargeDates(tt.cycle, mustDate(t, tt.anchor), tt.day, mustMonth(t, tt.from), mustMonth(t, tt.to)))
+			if !equalStrings(got, tt.want) {
+				t.Errorf("chargeDates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBilledCharges(t *testing.T) {
+	strPtr := func(s string) *string { return &s }
+	type billed struct {
+		date   string
+		amount int
+	}
+	tests := []struct {
+		name     string
+		sub      models.Subscription
+		from, to string
+		want     []billed
+	}{
+		{
+			name: "trial, intro price and pause",
+			sub: models.Subscription{
+				StartDate:  "01-2025",
+				BillingDay: 31,
+				Phases: []models.SubscriptionPhase{
+					{Type: models.PhaseTrial, Price: 0, StartDate: "01-2025", EndDate: "01-2025"},
+					{Type: models.PhaseIntro, Price: 500, StartDate: "02-2025", EndDate: "03-2025"},
+				},
+				Pauses: []models.SubscriptionPause{{StartDate: "04-2025", EndDate: strPtr("04-2025")}},
+			},
+			from: "01-2025", to: "06-2025",
+			want: []billed{{"2025-02-28", 500}, {"2025-03-31", 500}, {"2025-05-31", 1000}, {"2025-06-30", 1000}},
+		},
+		{
+			name: "quarterly with a price change and an end date",
+			sub: models.Subscription{
+				StartDate:     "11-2024",
+				EndDate:       strPtr("08-2025"),
+				BillingPeriod: models.BillingQuarter,
+				BillingDay:    30,
+				Prices: []models.PriceChange{
+					{Price: 1000, EffectiveDate: "11-2024"},
+					{Price: 1200, EffectiveDate: "05-2025"},
+				},
+			},
+			from: "01-2024", to: "12-2025",
+			want: []billed{{"2024-11-30", 1000}, {"2025-02-28", 1000}, {"2025-05-30", 1200}, {"2025-08-30", 1200}},
+		},
+		{
+			name: "yearly on leap day with an open pause",
+			sub: models.Subscription{
+				StartDate:     "02-2024",
+				BillingPeriod: models.BillingYear,
+				BillingDay:    29,
+				Pauses:        []models.SubscriptionPause{{StartDate: "02-2026"}},
+			},
+			from: "01-2024", to: "12-2027",
+			want: []billed{{"2024-02-29", 1000}, {"2025-02-28", 1000}},
+		},
+		{
+			name: "weekly with a paused month",
+			sub: models.Subscription{
+				StartDate:     "02-2025",
+				BillingPeriod: models.BillingWeek,
+				BillingDay:    3,
+				Pauses:        []models.SubscriptionPause{{StartDate: "03-2025", EndDate: strPtr("03-2025")}},
+			},
+			from: "02-2025", to: "04-2025",
+			want: []billed{
+				{"2025-02-03", 1000}, {"2025-02-10", 1000}, {"2025-02-17", 1000}, {"2025-02-24", 1000},
+				{"2025-04-07", 1000}, {"2025-04-14", 1000}, {"2025-04-21", 1000}, {"2025-04-28", 1000},
+			},
+		},
+		{
+			name: "period after the end date",
+			sub:  models.Subscription{StartDate: "01-2024", EndDate: strPtr("06-2024"), BillingDay: 1},
+			from: "07-2024", to: "12-2024",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := tt.sub
+			sub.Price = 1000
+			sub.Currency = "USD"
+
+			charges, err := billedCharges(&sub, mustMonth(t, tt.from), mustMonth(t, tt.to))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]billed, len(charges))
+			for i, ch := range charges {
+				if ch.Month != models.MonthOf(ch.Date) {
+					t.Errorf("charge %s has month %s", ch.Date.Format(models.DateLayout), ch.Month)
+				}
+				got[i] = billed{ch.Date.Format(models.DateLayout), ch.Amount}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("billedCharges() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("billedCharges()[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"fmt"
+	"go-dev/internal/models"
+	"math"
+	"sort"
+)
+
+type currencyPair struct {
+	base, quote string
+}
+
+type monthlyRate struct {
+	month models.Month
+	rate  float64
+}
+
+// currencyConverter пересчитывает суммы по курсу месяца списания.
+// Если курса за месяц нет, используется последний известный курс за предыдущие месяцы;
+// пары без прямого курса пересчитываются через обратный курс или через третью валюту.
+type currencyConverter struct {
+	rates      map[currencyPair][]monthlyRate // отсортированы по месяцу
+	currencies []string                       // все валюты с курсами, по алфавиту
+}
+
+func newCurrencyConverter(rates []models.ExchangeRate) *currencyConverter {
+	c := &currencyConverter{rates: make(map[currencyPair][]monthlyRate)}
+	seen := make(map[string]bool)
+	for _, rate := range rates {
+		pair := currencyPair{base: rate.Base, quote: rate.Quote}
+		c.rates[pair] = append(c.rates[pair], monthlyRate{month: rate.Month, rate: rate.Rate})
+		for _, currency := range []string{rate.Base, rate.Quote} {
+			if !seen[currency] {
+				seen[currency] = true
+				c.currencies = append(c.currencies, currency)
+			}
+		}
+	}
+	for _, list := range c.rates {
+		sort.Slice(list, func(i, j int) bool { return list[i].month < list[j].month })
+	}
+	sort.Strings(c.currencies)
+	return c
+}
+
+// convert пересчитывает сумму в минимальных единицах из валюты from в валюту to
+func (c *currencyConverter) convert(amount int, from, to string, month models.Month) (int, error) {
+	if from == to || amount == 0 {
+		return amount, nil
+	}
+	rate, ok := c.rate(from, to, month)
+	if !ok {
+		return 0, fmt.Errorf("%w: no exchange rate %s/%s for %s", ErrValidation, from, to, month)
+	}
+	return int(math.Round(float64(amount) * rate)), nil
+}
+
+func (c *currencyConverter) rate(from, to string, month models.Month) (float64, bool) {
+	if rate, ok := c.directRate(from, to, month); ok {
+		return rate, true
+	}
+	// Кросс-курс через третью валюту, например RUB -> EUR -> USD
+	for _, pivot := range c.currencies {
+		if pivot == from || pivot == to {
+			continue
+		}
+		first, ok := c.directRate(from, pivot, month)
+		if !ok {
+			continue
+		}
+		if second, ok := c.directRate(pivot, to, month); ok {
+			return first * second, true
+		}
+	}
+	return 0, false
+}
+
+// directRate ищет прямой или обратный курс пары
+func (c *currencyConverter) directRate(from, to string, month models.Month) (float64, bool) {
+	if rate, ok := c.latest(currencyPair{base: from, quote: to}, month); ok {
+		return rate, true
+	}
+	if rate, ok := c.latest(currencyPair{base: to, quote: from}, month); ok {
+		return 1 / rate, true
+	}
+	return 0, false
+}
+
+// latest возвращает курс пары за месяц month или ближайший предыдущий
+func (c *currencyConverter) latest(pair currencyPair, month models.Month) (float64, bool) {
+	list := c.rates[pair]
+	i := sort.Search(len(list), func(i int) bool { return list[i].month > month })
+	if i == 0 {
+		return 0, false
+	}
+	return list[i-1].rate, true
+}
+
+// zeroDecimalCurrencies — валюты без дробных единиц по ISO 4217
+var zeroDecimalCurrencies = map[string]bool{
+	"BIF": true, "CLP": true, "DJF": true, "GNF": true, "ISK": true, "JPY": true,
+	"KMF": true, "KRW": true, "PYG": true, "RWF": true, "UGX": true, "UYI": true,
+	"VND": true, "VUV": true, "XAF": true, "XOF": true, "XPF": true,
+}
+
+// formatAmount форматирует сумму в сотых долях с точностью валюты:
+// 99900 RUB -> "999.00 RUB", 150000 JPY -> "1500 JPY"
+func formatAmount(amount int, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	if zeroDecimalCurrencies[currency] {
+		return fmt.Sprintf("%s%d %s", sign, (amount+50)/100, currency)
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, amount/100, amount%100, currency)
+}
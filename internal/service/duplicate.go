@@ -0,0 +1,201 @@
+package service
+
+import (
+	"go-dev/internal/models"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// duplicateSimilarity — наименьшее сходство названий сервисов, при котором
+// подписки считаются подписками на один сервис
+const duplicateSimilarity = 0.8
+
+// FindDuplicates ищет вероятные дубликаты среди подписок пользователя: подписки
+// на один сервис (по каталогу или похожему названию) с пересекающимися датами.
+// Также отмечаются такие же подписки участников общих с пользователем подписок.
+func (s *SubscriptionService) FindDuplicates(userID uuid.UUID) (*models.DuplicatesResponse, error) {
+	if err := s.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	owned, family, err := s.duplicateCandidates(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := []models.DuplicateMatch{}
+	for i, sub := range owned {
+		for _, other := range owned[i+1:] {
+			if match, ok := matchDuplicate(sub, other, models.DuplicateSameUser); ok {
+				duplicates = append(duplicates, match)
+			}
+		}
+		for _, other := range family {
+			if match, ok := matchDuplicate(sub, other, models.DuplicateFamilyMember); ok {
+				duplicates = append(duplicates, match)
+			}
+		}
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Subscription.ID < duplicates[j].Subscription.ID
+	})
+
+	return &models.DuplicatesResponse{UserID: userID, Duplicates: duplicates}, nil
+}
+
+// duplicatesOf возвращает вероятные дубликаты подписки среди подписок ее владельца
+// и участников общих с ним подписок
+func (s *SubscriptionService) duplicatesOf(sub *models.Subscription) ([]models.DuplicateMatch, error) {
+	owned, family, err := s.duplicateCandidates(sub.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	var duplicates []models.DuplicateMatch
+	for _, other := range owned {
+		if other.ID == sub.ID {
+			continue
+		}
+		if match, ok := matchDuplicate(sub, other, models.DuplicateSameUser); ok {
+			duplicates = append(duplicates, match)
+		}
+	}
+	for _, other := range family {
+		if match, ok := matchDuplicate(sub, other, models.DuplicateFamilyMember); ok {
+			duplicates = append(duplicates, match)
+		}
+	}
+	return duplicates, nil
+}
+
+// duplicateCandidates возвращает подписки, которыми владеет пользователь, и подписки,
+// которыми владеют участники общих с ним подписок
+func (s *SubscriptionService) duplicateCandidates(userID uuid.UUID) (owned, family []*models.Subscription, err error) {
+	subscriptions, err := s.repo.List(models.SubscriptionFilter{UserID: &userID})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	related := make(map[uuid.UUID]bool)
+	for _, sub := range subscriptions {
+		if sub.UserID == userID {
+			owned = append(owned, sub)
+		}
+		related[sub.UserID] = true
+		for _, member := range sub.Members {
+			related[member.UserID] = true
+		}
+	}
+	delete(related, userID)
+	if len(related) == 0 {
+		return owned, nil, nil
+	}
+
+	// Подписки всех связанных пользователей загружаются одним запросом
+	relatedIDs := make([]uuid.UUID, 0, len(related))
+	for relatedID := range related {
+		relatedIDs = append(relatedIDs, relatedID)
+	}
+	if family, err = s.repo.List(models.SubscriptionFilter{OwnerIDs: relatedIDs}); err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(family, func(i, j int) bool { return family[i].ID < family[j].ID })
+	return owned, family, nil
+}
+
+// matchDuplicate сравнивает две подписки: сервис должен совпадать, а даты — пересекаться
+func matchDuplicate(sub, other *models.Subscription, kind models.DuplicateKind) (models.DuplicateMatch, bool) {
+	similarity := serviceSimilarity(sub, other)
+	if similarity < duplicateSimilarity {
+		return models.DuplicateMatch{}, false
+	}
+
+	start, end, err := subscriptionSpan(sub)
+	if err != nil {
+		return models.DuplicateMatch{}, false
+	}
+	otherStart, otherEnd, err := subscriptionSpan(other)
+	if err != nil {
+		return models.DuplicateMatch{}, false
+	}
+	if otherStart > start {
+		start = otherStart
+	}
+	if end == nil || (otherEnd != nil && *otherEnd < *end) {
+		end = otherEnd
+	}
+	if end != nil && *end < start {
+		return models.DuplicateMatch{}, false
+	}
+
+	match := models.DuplicateMatch{
+		Kind:         kind,
+		Subscription: models.NewSubscriptionRef(sub),
+		Duplicate:    models.NewSubscriptionRef(other),
+		Similarity:   similarity,
+		OverlapStart: start.String(),
+	}
+	if end != nil {
+		overlapEnd := end.String()
+		match.OverlapEnd = &overlapEnd
+	}
+	return match, true
+}
+
+// serviceSimilarity оценивает сходство сервисов двух подписок от 0 до 1. Подписки на
+// один сервис каталога совпадают полностью; название, которое является началом другого
+// по целым словам ("spotify" и "spotify family"), считается тем же сервисом с другим тарифом.
+func serviceSimilarity(a, b *models.Subscription) float64 {
+	if a.CatalogServiceID != nil && b.CatalogServiceID != nil && *a.CatalogServiceID == *b.CatalogServiceID {
+		return 1
+	}
+
+	x := normalizeServiceName(a.CanonicalServiceName())
+	y := normalizeServiceName(b.CanonicalServiceName())
+	if x == "" || y == "" {
+		return 0
+	}
+	if x == y {
+		return 1
+	}
+	if strings.HasPrefix(y, x+" ") || strings.HasPrefix(x, y+" ") {
+		return 0.9
+	}
+
+	rx, ry := []rune(x), []rune(y)
+	longest := math.Max(float64(len(rx)), float64(len(ry)))
+	return math.Round((1-float64(levenshtein(rx, ry))/longest)*100) / 100
+}
+
+// normalizeServiceName приводит название к нижнему регистру и заменяет знаки
+// препинания и повторные пробелы одним пробелом: "Spotify-Premium " -> "spotify premium"
+func normalizeServiceName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// levenshtein возвращает редакционное расстояние между строками
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,14 @@
+package service
+
+import "errors"
+
+// Ошибки сервисов, по которым обработчики выбирают HTTP-статус.
+// Конкретные ошибки оборачивают их через fmt.Errorf("%w: ...").
+var (
+	// ErrValidation — некорректные входные данные
+	ErrValidation = errors.New("validation error")
+	// ErrNotFound — запрошенная сущность не найдена
+	ErrNotFound = errors.New("not found")
+	// ErrConflict — операция противоречит текущему состоянию данных
+	ErrConflict = errors.New("conflict")
+)
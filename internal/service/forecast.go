@@ -0,0 +1,73 @@
+package service
+
+import (
+	"fmt"
+	"go-dev/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxForecastMonths — наибольший горизонт прогноза расходов
+const maxForecastMonths = 36
+
+// GetForecast прогнозирует расходы на months месяцев, начиная с текущего.
+// Учитываются даты окончания, расчетные периоды, запланированные изменения цен,
+// окончание пробных периодов и известные паузы. Каждый месяц разбивается по сервисам.
+func (s *SubscriptionService) GetForecast(userID *uuid.UUID, serviceName *string, months int, currency string) (*models.ForecastResponse, error) {
+	if months < 1 || months > maxForecastMonths {
+		return nil, fmt.Errorf("%w: months must be between 1 and %d", ErrValidation, maxForecastMonths)
+	}
+	startPeriod := models.MonthOf(time.Now())
+	endPeriod := startPeriod.AddMonths(months - 1)
+
+	subscriptions, err := s.repo.ListForPeriod(userID, serviceName, nil, startPeriod, endPeriod)
+	if err != nil {
+		return nil, err
+	}
+	calc, err := s.newCostCalculator(subscriptions, endPeriod, currency, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byService := make([]map[string]int, months)
+	for i := range byService {
+		byService[i] = make(map[string]int)
+	}
+	for _, sub := range subscriptions {
+		charges, err := calc.charges(sub, startPeriod, endPeriod)
+		if err != nil {
+			return nil, err
+		}
+		for _, ch := range charges {
+			if ch.Amount != 0 {
+				byService[ch.Month-startPeriod][sub.CanonicalServiceName()] += ch.Amount
+			}
+		}
+	}
+
+	result := &models.ForecastResponse{
+		Currency: calc.currency,
+		Period:   formatPeriod(startPeriod, endPeriod),
+		Filters:  costFilters(userID, serviceName),
+		Months:   make([]models.MonthlyForecast, months),
+	}
+	for i, totals := range byService {
+		month := models.MonthlyForecast{
+			Month:    startPeriod.AddMonths(i),
+			Services: make([]models.ServiceCost, 0, len(totals)),
+		}
+		for name, cost := range totals {
+			month.Services = append(month.Services, models.ServiceCost{ServiceName: name, Cost: cost})
+			month.Cost += cost
+		}
+		sort.Slice(month.Services, func(a, b int) bool {
+			return month.Services[a].ServiceName < month.Services[b].ServiceName
+		})
+		result.Months[i] = month
+		result.TotalCost += month.Cost
+	}
+
+	return result, nil
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go-dev/internal/models"
+	"go-dev/internal/repository"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InsightOptions — пороги анализа расходов
+type InsightOptions struct {
+	PriceJumpPercent      float64 // рост цены больше этого процента считается резким
+	HighCostFactor        float64 // новая подписка во столько раз дороже средней считается дорогой
+	SpendDeviationPercent float64 // допустимое отклонение расходов месяца от среднего, в процентах
+	TrailingMonths        int     // число предыдущих месяцев для среднего
+}
+
+// DefaultInsightOptions возвращает пороги анализа по умолчанию
+func DefaultInsightOptions() InsightOptions {
+	return InsightOptions{
+		PriceJumpPercent:      20,
+		HighCostFactor:        3,
+		SpendDeviationPercent: 50,
+		TrailingMonths:        6,
+	}
+}
+
+type InsightService struct {
+	subscriptions *SubscriptionService
+	repo          *repository.InsightRepository
+}
+
+func NewInsightService(subscriptions *SubscriptionService, repo *repository.InsightRepository) *InsightService {
+	return &InsightService{subscriptions: subscriptions, repo: repo}
+}
+
+// minTrailingMonths — сколько месяцев с расходами нужно, чтобы среднее имело смысл
+const minTrailingMonths = 3
+
+// ListInsights возвращает находки анализа расходов пользователя, новые первыми
+func (s *InsightService) ListInsights(userID uuid.UUID, severity *models.InsightSeverity, limit, offset int) ([]models.Insight, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByUser(userID, severity, limit, offset)
+}
+
+// AnalyzeAllSpending анализирует расходы всех плательщиков. Запускается периодически;
+// возвращает число сохраненных находок.
+func (s *InsightService) AnalyzeAllSpending(opts InsightOptions) (int, error) {
+	userIDs, err := s.subscriptions.repo.ListPayerIDs()
+	if err != nil {
+		return 0, err
+	}
+	return s.AnalyzeSpending(opts, userIDs...)
+}
+
+// AnalyzeSpending ищет необычные события в расходах пользователей: резкий рост цены,
+// новую подписку, заметно дороже остальных, и расходы текущего месяца, сильно
+// отличающиеся от среднего за предыдущие месяцы. Возвращает число сохраненных находок.
+// Ошибка анализа одного пользователя не прерывает анализ остальных: ошибки собираются
+// и возвращаются вместе.
+func (s *InsightService) AnalyzeSpending(opts InsightOptions, userIDs ...uuid.UUID) (int, error) {
+	now := models.MonthOf(time.Now())
+	saved := 0
+	var errs []error
+	for _, userID := range userIDs {
+		n, err := s.saveUserInsights(opts, userID, now)
+		saved += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %s: %w", userID, err))
+		}
+	}
+	return saved, errors.Join(errs...)
+}
+
+// saveUserInsights анализирует расходы пользователя и сохраняет находки;
+// возвращает число сохраненных
+func (s *InsightService) saveUserInsights(opts InsightOptions, userID uuid.UUID, now models.Month) (int, error) {
+	insights, err := s.analyzeUser(opts, userID, now)
+	if err != nil {
+		return 0, err
+	}
+	for i := range insights {
+		if err := s.repo.Upsert(&insights[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(insights), nil
+}
+
+func (s *InsightService) analyzeUser(opts InsightOptions, userID uuid.UUID, now models.Month) ([]models.Insight, error) {
+	from := now.AddMonths(-opts.TrailingMonths)
+	subscriptions, err := s.subscriptions.repo.ListForPeriod(&userID, nil, nil, from, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var insights []models.Insight
+	for _, sub := range subscriptions {
+		insights = append(insights, priceJumps(opts, userID, sub, from)...)
+		if insight, ok := newHighCost(opts, userID, sub, subscriptions, now); ok {
+			insights = append(insights, insight)
+		}
+	}
+
+	deviation, ok, err := s.spendDeviation(opts, userID, subscriptions, from, now)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		insights = append(insights, deviation)
+	}
+	return insights, nil
+}
+
+// priceJumps находит повышения цены больше порога, вступившие в силу с месяца from,
+// включая запланированные
+func priceJumps(opts InsightOptions, userID uuid.UUID, sub *models.Subscription, from models.Month) []models.Insight {
+	var insights []models.Insight
+	for i := 1; i < len(sub.Prices); i++ {
+		prev, change := sub.Prices[i-1], sub.Prices[i]
+		effective, err := models.ParseMonth(change.EffectiveDate)
+		if err != nil || effective < from {
+			continue
+		}
+		_, percent := priceDelta(prev.Price, change.Price)
+		if percent <= opts.PriceJumpPercent {
+			continue
+		}
+
+		insights = append(insights, models.Insight{
+			UserID:         userID,
+			Kind:           models.InsightPriceJump,
+			Severity:       severityAbove(percent, opts.PriceJumpPercent),
+			SubscriptionID: &sub.ID,
+			Month:          change.EffectiveDate,
+			Explanation: fmt.Sprintf("%s price rises from %s to %s (+%.2f%%) starting %s, above the %.0f%% threshold",
+				sub.CanonicalServiceName(), formatAmount(prev.Price, sub.Currency), formatAmount(change.Price, sub.Currency),
+				percent, change.EffectiveDate, opts.PriceJumpPercent),
+		})
+	}
+	return insights
+}
+
+// newHighCost отмечает подписку, начатую в текущем или прошлом месяце, среднемесячная цена
+// которой во много раз выше средней по остальным подпискам пользователя в той же валюте
+func newHighCost(opts InsightOptions, userID uuid.UUID, sub *models.Subscription, subscriptions []*models.Subscription, now models.Month) (models.Insight, bool) {
+	start, err := models.ParseMonth(sub.StartDate)
+	if err != nil || start < now.AddMonths(-1) || start > now {
+		return models.Insight{}, false
+	}
+
+	monthly := sub.Cycle().MonthlyEquivalent(sub.BasePriceAt(now))
+	total, count := 0, 0
+	for _, other := range subscriptions {
+		if other.ID == sub.ID || other.Currency != sub.Currency {
+			continue
+		}
+		total += other.Cycle().MonthlyEquivalent(other.BasePriceAt(now))
+		count++
+	}
+	if count < 2 || total == 0 {
+		return models.Insight{}, false
+	}
+
+	average := float64(total) / float64(count)
+	factor := float64(monthly) / average
+	if factor < opts.HighCostFactor {
+		return models.Insight{}, false
+	}
+
+	return models.Insight{
+		UserID:         userID,
+		Kind:           models.InsightNewHighCost,
+		Severity:       severityAbove(factor, opts.HighCostFactor),
+		SubscriptionID: &sub.ID,
+		Month:          start.String(),
+		Explanation: fmt.Sprintf("New subscription %s costs %s a month, %.1f times the average of your other %d subscriptions (%s)",
+			sub.CanonicalServiceName(), formatAmount(monthly, sub.Currency), factor, count,
+			formatAmount(int(math.Round(average)), sub.Currency)),
+	}, true
+}
+
+// spendDeviation сравнивает расходы пользователя в текущем месяце со средним за предыдущие
+// месяцы, начиная с первого месяца с расходами. Подписки в разных валютах пересчитываются
+// в валюту по умолчанию; если курсов нет, сравнение пропускается.
+func (s *InsightService) spendDeviation(opts InsightOptions, userID uuid.UUID, subscriptions []*models.Subscription, from, now models.Month) (models.Insight, bool, error) {
+	calc, err := s.subscriptions.newCostCalculator(subscriptions, now, "", &userID)
+	if errors.Is(err, ErrValidation) {
+		calc, err = s.subscriptions.newCostCalculator(subscriptions, now, models.DefaultCurrency, &userID)
+	}
+	if err != nil {
+		return models.Insight{}, false, err
+	}
+
+	spend := make([]int, from.MonthsUntil(now))
+	for _, sub := range subscriptions {
+		charges, err := calc.charges(sub, from, now)
+		if errors.Is(err, ErrValidation) {
+			return models.Insight{}, false, nil
+		}
+		if err != nil {
+			return models.Insight{}, false, err
+		}
+		for _, ch := range charges {
+			spend[ch.Month-from] += ch.Amount
+		}
+	}
+
+	trailing := spend[:len(spend)-1]
+	for len(trailing) > 0 && trailing[0] == 0 {
+		trailing = trailing[1:]
+	}
+	if len(trailing) < minTrailingMonths {
+		return models.Insight{}, false, nil
+	}
+	total := 0
+	for _, amount := range trailing {
+		total += amount
+	}
+	if total == 0 {
+		return models.Insight{}, false, nil
+	}
+
+	average := float64(total) / float64(len(trailing))
+	current := spend[len(spend)-1]
+	deviation := math.Round((float64(current)-average)/average*10000) / 100
+	if math.Abs(deviation) <= opts.SpendDeviationPercent {
+		return models.Insight{}, false, nil
+	}
+
+	severity := models.SeverityInfo
+	direction := "below"
+	if deviation > 0 {
+		severity = severityAbove(deviation, opts.SpendDeviationPercent)
+		direction = "above"
+	}
+	return models.Insight{
+		UserID:   userID,
+		Kind:     models.InsightSpendDeviation,
+		Severity: severity,
+		Month:    now.String(),
+		Explanation: fmt.Sprintf("Spend for %s is %s, %.2f%% %s your %d-month average of %s",
+			now, formatAmount(current, calc.currency), math.Abs(deviation), direction, len(trailing),
+			formatAmount(int(math.Round(average)), calc.currency)),
+	}, true, nil
+}
+
+// severityAbove возвращает warning для значения выше порога и critical — для значения
+// выше удвоенного порога
+func severityAbove(value, threshold float64) models.InsightSeverity {
+	if value >= 2*threshold {
+		return models.SeverityCritical
+	}
+	return models.SeverityWarning
+}
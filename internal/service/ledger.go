@@ -0,0 +1,146 @@
+package service
+
+import (
+	"fmt"
+	"go-dev/internal/models"
+	"go-dev/internal/repository"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// ledgerRoots — корневые счета, которые принимают и beancount, и hledger
+var ledgerRoots = []string{"Assets", "Liabilities", "Equity", "Income", "Expenses"}
+
+type LedgerService struct {
+	subscriptions *SubscriptionService
+	repo          *repository.LedgerRepository
+}
+
+func NewLedgerService(subscriptions *SubscriptionService, repo *repository.LedgerRepository) *LedgerService {
+	return &LedgerService{subscriptions: subscriptions, repo: repo}
+}
+
+// GetLedgerAccounts возвращает счета журнала пользователя
+func (s *LedgerService) GetLedgerAccounts(userID uuid.UUID) (*models.LedgerAccounts, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAccounts(userID)
+}
+
+// UpdateLedgerAccounts меняет счета журнала; переданные категории заменяют все прежние
+func (s *LedgerService) UpdateLedgerAccounts(userID uuid.UUID, req *models.UpdateLedgerAccountsRequest) (*models.LedgerAccounts, error) {
+	accounts, err := s.GetLedgerAccounts(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ExpenseAccount != nil {
+		if accounts.ExpenseAccount, err = validateAccount(*req.ExpenseAccount); err != nil {
+			return nil, err
+		}
+	}
+	if req.PaymentAccount != nil {
+		if accounts.PaymentAccount, err = validateAccount(*req.PaymentAccount); err != nil {
+			return nil, err
+		}
+	}
+	if req.Categories != nil {
+		accounts.Categories = make(map[string]string, len(*req.Categories))
+		for category, account := range *req.Categories {
+			category = strings.ToLower(strings.TrimSpace(category))
+			if category == "" {
+				return nil, fmt.Errorf("%w: category must not be empty", ErrValidation)
+			}
+			if accounts.Categories[category], err = validateAccount(account); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.repo.SaveAccounts(accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// GetLedgerTransactions раскладывает подписки пользователя в периоде [from, to] на
+// датированные списания по цене и расчетному периоду. Месяцы паузы и бесплатный пробный
+// период пропускаются; в совместных подписках учитывается доля пользователя. Суммы
+// остаются в валюте подписки — журналы ведут несколько валют сами.
+func (s *LedgerService) GetLedgerTransactions(userID uuid.UUID, from, to models.Month) ([]models.LedgerTransaction, *models.LedgerAccounts, error) {
+	accounts, err := s.GetLedgerAccounts(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	subscriptions, err := s.subscriptions.repo.ListForPeriod(&userID, nil, nil, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transactions := []models.LedgerTransaction{}
+	for _, sub := range subscriptions {
+		billed, err := billedCharges(sub, from, to)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		category := sub.EffectiveCategory()
+		for _, ch := range billed {
+			share := sub.SplitCharge(ch.Amount)[userID]
+			if share == 0 {
+				continue
+			}
+			transactions = append(transactions, models.LedgerTransaction{
+				Date:           ch.Date,
+				SubscriptionID: sub.ID,
+				ServiceName:    sub.CanonicalServiceName(),
+				Category:       category,
+				Account:        accounts.AccountFor(category),
+				Amount:         share,
+				Currency:       sub.Currency,
+			})
+		}
+	}
+	sort.SliceStable(transactions, func(i, j int) bool {
+		if !transactions[i].Date.Equal(transactions[j].Date) {
+			return transactions[i].Date.Before(transactions[j].Date)
+		}
+		return transactions[i].SubscriptionID < transactions[j].SubscriptionID
+	})
+
+	return transactions, accounts, nil
+}
+
+// validateAccount проверяет имя счета журнала: компоненты через двоеточие, первый —
+// один из корневых счетов, каждый начинается с заглавной буквы или цифры и состоит
+// из букв, цифр и дефисов
+func validateAccount(account string) (string, error) {
+	account = strings.TrimSpace(account)
+	components := strings.Split(account, ":")
+	if len(components) < 2 {
+		return "", fmt.Errorf("%w: account %q must have at least two components, e.g. Expenses:Subscriptions", ErrValidation, account)
+	}
+	root := false
+	for _, name := range ledgerRoots {
+		root = root || components[0] == name
+	}
+	if !root {
+		return "", fmt.Errorf("%w: account %q must start with one of %s", ErrValidation, account, strings.Join(ledgerRoots, ", "))
+	}
+	for _, component := range components {
+		runes := []rune(component)
+		if len(runes) == 0 || !(unicode.IsUpper(runes[0]) || unicode.IsDigit(runes[0])) {
+			return "", fmt.Errorf("%w: account %q: each component must start with a capital letter or digit", ErrValidation, account)
+		}
+		for _, r := range runes {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' {
+				return "", fmt.Errorf("%w: account %q: only letters, digits and dashes are allowed", ErrValidation, account)
+			}
+		}
+	}
+	return account, nil
+}
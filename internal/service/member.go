@@ -0,0 +1,125 @@
+package service
+
+import (
+	"fmt"
+	"go-dev/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ListMembers возвращает участников совместной подписки и их суммы с одного списания
+// по текущей цене; остаток оплачивает владелец
+func (s *SubscriptionService) ListMembers(id int) (*models.MembersResponse, error) {
+	sub, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	price := sub.BasePriceAt(models.MonthOf(time.Now()))
+	shares := sub.SplitCharge(price)
+
+	result := &models.MembersResponse{
+		SubscriptionID: sub.ID,
+		Price:          price,
+		Currency:       sub.Currency,
+		OwnerID:        sub.UserID,
+		OwnerAmount:    shares[sub.UserID],
+		Members:        make([]models.MemberShare, 0, len(sub.Members)),
+	}
+	for _, member := range sub.Members {
+		result.Members = append(result.Members, models.MemberShare{
+			SubscriptionMember: member,
+			Amount:             shares[member.UserID],
+		})
+	}
+	return result, nil
+}
+
+// AddMember добавляет участника совместной подписки. Доли участников вместе
+// не могут превышать полную цену; остаток оплачивает владелец.
+func (s *SubscriptionService) AddMember(id int, req *models.AddMemberRequest) (*models.SubscriptionMember, error) {
+	if (req.SharePercent == nil) == (req.ShareAmount == nil) {
+		return nil, fmt.Errorf("%w: exactly one of share_percent and share_amount is required", ErrValidation)
+	}
+
+	sub, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserID == sub.UserID {
+		return nil, fmt.Errorf("%w: owner pays the remainder and cannot be added as a member", ErrValidation)
+	}
+	for _, member := range sub.Members {
+		if member.UserID == req.UserID {
+			return nil, fmt.Errorf("%w: user %s is already a member", ErrConflict, req.UserID)
+		}
+	}
+	exists, err := s.repo.UserExists(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: user %s not found", ErrValidation, req.UserID)
+	}
+
+	member := models.SubscriptionMember{
+		SubscriptionID: id,
+		UserID:         req.UserID,
+		SharePercent:   req.SharePercent,
+		ShareAmount:    req.ShareAmount,
+	}
+	if req.SharePercent != nil {
+		member.ShareType = models.SharePercent
+	} else {
+		member.ShareType = models.ShareFixed
+	}
+
+	if err := validateShares(sub, append(sub.Members, member)); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.AddMember(&member); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (s *SubscriptionService) RemoveMember(id int, userID uuid.UUID) error {
+	if _, err := s.GetByID(id); err != nil {
+		return err
+	}
+	removed, err := s.repo.RemoveMember(id, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return fmt.Errorf("%w: member not found", ErrNotFound)
+	}
+	return nil
+}
+
+// validateShares проверяет, что доли участников помещаются в полную цену подписки
+func validateShares(sub *models.Subscription, members []models.SubscriptionMember) error {
+	price := sub.BasePriceAt(models.MonthOf(time.Now()))
+
+	fixed, percent := 0, 0.0
+	for _, member := range members {
+		switch member.ShareType {
+		case models.ShareFixed:
+			fixed += *member.ShareAmount
+		case models.SharePercent:
+			percent += *member.SharePercent
+		}
+	}
+
+	// Допуск на погрешность сложения дробных процентов (33.33 + 33.33 + 33.34)
+	const epsilon = 1e-6
+	if percent > 100+epsilon {
+		return fmt.Errorf("%w: member shares add up to %.2f%%, more than the full price", ErrValidation, percent)
+	}
+	if total := float64(fixed) + float64(price)*percent/100; total > float64(price)+epsilon {
+		return fmt.Errorf("%w: member shares add up to %.0f, more than the full price %d", ErrValidation, total, price)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"fmt"
+	"go-dev/internal/models"
+	"time"
+)
+
+// Pause приостанавливает подписку с месяца req.StartDate (по умолчанию — с текущего).
+// Подписка сохраняет идентичность и историю; месяцы паузы не оплачиваются.
+func (s *SubscriptionService) Pause(id int, req *models.PauseRequest) (*models.Subscription, error) {
+	sub, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	start, err := monthOrNow(req.StartDate, "start_date")
+	if err != nil {
+		return nil, err
+	}
+	return s.pause(sub, start, req.Reason)
+}
+
+// Resume завершает открытую паузу: месяц req.ResumeDate (по умолчанию — текущий)
+// снова оплачивается. Если пауза еще не началась, она отменяется.
+func (s *SubscriptionService) Resume(id int, req *models.ResumeRequest) (*models.Subscription, error) {
+	sub, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	resume, err := monthOrNow(req.ResumeDate, "resume_date")
+	if err != nil {
+		return nil, err
+	}
+	return s.resume(sub, resume, req.Reason)
+}
+
+func (s *SubscriptionService) pause(sub *models.Subscription, start models.Month, reason *string) (*models.Subscription, error) {
+	switch sub.Status {
+	case models.StatusTrialing, models.StatusActive, models.StatusPaused:
+	default:
+		return nil, fmt.Errorf("%w: %s subscription cannot be paused", ErrConflict, sub.Status)
+	}
+	if sub.OpenPause() != nil {
+		return nil, fmt.Errorf("%w: subscription is already paused", ErrConflict)
+	}
+
+	first, end, err := subscriptionSpan(sub)
+	if err != nil {
+		return nil, err
+	}
+	if start < first {
+		start = first
+	}
+	if end != nil && start > *end {
+		return nil, fmt.Errorf("%w: subscription ends in %s, before the pause", ErrValidation, *end)
+	}
+	if sub.IsPausedAt(start) {
+		return nil, fmt.Errorf("%w: subscription is already paused in %s", ErrConflict, start)
+	}
+
+	pause := &models.SubscriptionPause{SubscriptionID: sub.ID, StartDate: start.String()}
+	if err := s.repo.AddPause(pause); err != nil {
+		return nil, err
+	}
+	return s.refreshStatus(sub.ID, reason)
+}
+
+func (s *SubscriptionService) resume(sub *models.Subscription, resume models.Month, reason *string) (*models.Subscription, error) {
+	pause := sub.OpenPause()
+	if pause == nil {
+		return nil, fmt.Errorf("%w: subscription is not paused", ErrConflict)
+	}
+	pauseStart, err := models.ParseMonth(pause.StartDate)
+	if err != nil {
+		return nil, err
+	}
+
+	if resume <= pauseStart {
+		err = s.repo.DeletePause(pause.ID)
+	} else {
+		err = s.repo.ClosePause(pause.ID, resume.AddMonths(-1).String())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.refreshStatus(sub.ID, reason)
+}
+
+// refreshStatus перечитывает подписку и приводит ее статус к расписанию.
+// Пауза или возобновление с будущего месяца меняют статус, когда месяц наступит.
+func (s *SubscriptionService) refreshStatus(id int, reason *string) (*models.Subscription, error) {
+	sub, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.syncStatus(sub, reason); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// monthOrNow разбирает необязательный месяц MM-YYYY; по умолчанию — текущий месяц
+func monthOrNow(value *string, field string) (models.Month, error) {
+	if value == nil {
+		return models.MonthOf(time.Now()), nil
+	}
+	m, err := models.ParseMonth(*value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %v", ErrValidation, field, err)
+	}
+	return m, nil
+}
@@ -0,0 +1,250 @@
+package service
+
+import (
+	"fmt"
+	"go-dev/internal/models"
+	"go-dev/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentService struct {
+	subscriptions *SubscriptionService
+	repo          *repository.PaymentRepository
+}
+
+func NewPaymentService(subscriptions *SubscriptionService, repo *repository.PaymentRepository) *PaymentService {
+	return &PaymentService{subscriptions: subscriptions, repo: repo}
+}
+
+// RecordPayment записывает фактический платеж по подписке. Плательщиком может быть
+// владелец или участник подписки; платеж с уже записанным у плательщика
+// external_reference отклоняется.
+func (s *PaymentService) RecordPayment(id int, req *models.CreatePaymentRequest) (*models.Payment, error) {
+	sub, err := s.subscriptions.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	paidDate, err := parsePaidDate(req.PaidDate)
+	if err != nil {
+		return nil, err
+	}
+
+	payer := sub.UserID
+	if req.UserID != nil {
+		payer = *req.UserID
+		if !isPayer(sub, payer) {
+			return nil, fmt.Errorf("%w: user %s is neither the owner nor a member of the subscription", ErrValidation, payer)
+		}
+	}
+
+	currency := req.Currency
+	if currency == "" {
+		currency = sub.Currency
+	}
+
+	payment := newPayment(payer, req, paidDate, currency)
+	payment.SubscriptionID = &sub.ID
+	if err := s.createPayment(payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// RecordUnlinkedPayment записывает списание пользователя, не сопоставленное с подпиской,
+// например из банковской выписки. Такие списания участвуют в сверке.
+func (s *PaymentService) RecordUnlinkedPayment(userID uuid.UUID, req *models.CreatePaymentRequest) (*models.Payment, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	paidDate, err := parsePaidDate(req.PaidDate)
+	if err != nil {
+		return nil, err
+	}
+	if req.Currency == "" {
+		return nil, fmt.Errorf("%w: currency is required for a payment without subscription", ErrValidation)
+	}
+
+	payment := newPayment(userID, req, paidDate, req.Currency)
+	if err := s.createPayment(payment); err != nil {
+		return nil, err
+	}
+	return payment, nil
+}
+
+// ListSubscriptionPayments возвращает платежи по подписке, новые первыми
+func (s *PaymentService) ListSubscriptionPayments(id, limit, offset int) ([]models.Payment, error) {
+	if _, err := s.subscriptions.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.List(models.PaymentFilter{SubscriptionIDs: []int{id}, Limit: limit, Offset: offset})
+}
+
+// ListUserPayments возвращает платежи, внесенные пользователем, новые первыми
+func (s *PaymentService) ListUserPayments(userID uuid.UUID, limit, offset int) ([]models.Payment, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.List(models.PaymentFilter{UserID: &userID, Limit: limit, Offset: offset})
+}
+
+func (s *PaymentService) DeletePayment(id, paymentID int) error {
+	payment, err := s.repo.GetByID(paymentID)
+	if err != nil {
+		return err
+	}
+	if payment == nil || payment.SubscriptionID == nil || *payment.SubscriptionID != id {
+		return fmt.Errorf("payment %w", ErrNotFound)
+	}
+	deleted, err := s.repo.Delete(paymentID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("payment %w", ErrNotFound)
+	}
+	return nil
+}
+
+// GetExpectedVsPaid сравнивает по месяцам периода [startPeriod, endPeriod] ожидаемые списания
+// (как в GetTotalCost) с записанными платежами по тем же подпискам. С фильтром по
+// пользователю учитываются его доля и только его платежи. Платежи в других валютах
+// пересчитываются по курсу месяца оплаты.
+func (s *PaymentService) GetExpectedVsPaid(userID *uuid.UUID, serviceName *string, tags []string, startPeriod, endPeriod models.Month, currency string) (*models.ExpectedVsPaidResponse, error) {
+	subscriptions, err := s.subscriptions.repo.ListForPeriod(userID, serviceName, tags, startPeriod, endPeriod)
+	if err != nil {
+		return nil, err
+	}
+	calc, err := s.subscriptions.newCostCalculator(subscriptions, endPeriod, currency, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	months := make([]models.MonthlyPayments, startPeriod.MonthsUntil(endPeriod))
+	for i := range months {
+		months[i].Month = startPeriod.AddMonths(i)
+	}
+
+	ids := make([]int, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		ids = append(ids, sub.ID)
+		charges, err := calc.charges(sub, startPeriod, endPeriod)
+		if err != nil {
+			return nil, err
+		}
+		for _, ch := range charges {
+			months[ch.Month-startPeriod].Expected += ch.Amount
+		}
+	}
+
+	if len(ids) > 0 {
+		from, to := startPeriod.Time(), endPeriod.AddMonths(1).Time()
+		payments, err := s.repo.List(models.PaymentFilter{SubscriptionIDs: ids, UserID: userID, From: &from, To: &to})
+		if err != nil {
+			return nil, err
+		}
+		if err := s.loadRatesFor(calc, payments, endPeriod); err != nil {
+			return nil, err
+		}
+		for _, payment := range payments {
+			paidDate, err := time.Parse(models.DateLayout, payment.PaidDate)
+			if err != nil {
+				return nil, fmt.Errorf("payment %d: %w", payment.ID, err)
+			}
+			month := models.MonthOf(paidDate)
+			amount, err := calc.convert(payment.Amount, payment.Currency, month)
+			if err != nil {
+				return nil, err
+			}
+			months[month-startPeriod].Paid += amount
+		}
+	}
+
+	result := &models.ExpectedVsPaidResponse{
+		Currency: calc.currency,
+		Period:   formatPeriod(startPeriod, endPeriod),
+		Filters:  costFilters(userID, serviceName),
+		Months:   months,
+	}
+	if len(tags) > 0 {
+		result.Filters["tags"] = strings.Join(tags, ",")
+	}
+	for i := range months {
+		months[i].Difference = months[i].Paid - months[i].Expected
+		result.Expected += months[i].Expected
+		result.Paid += months[i].Paid
+	}
+	result.Difference = result.Paid - result.Expected
+	return result, nil
+}
+
+// loadRatesFor загружает курсы валют, если платежи внесены не в валюте отчета,
+// а калькулятору курсы для подписок не понадобились
+func (s *PaymentService) loadRatesFor(calc *costCalculator, payments []models.Payment, to models.Month) error {
+	if calc.converter != nil {
+		return nil
+	}
+	for _, payment := range payments {
+		if payment.Currency != calc.currency {
+			rates, err := s.subscriptions.rates.ListUpTo(to)
+			if err != nil {
+				return err
+			}
+			calc.converter = newCurrencyConverter(rates)
+			return nil
+		}
+	}
+	return nil
+}
+
+// parsePaidDate разбирает дату платежа в формате YYYY-MM-DD; дата не может быть в будущем
+func parsePaidDate(s string) (time.Time, error) {
+	paidDate, err := time.Parse(models.DateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid paid_date, expected YYYY-MM-DD", ErrValidation)
+	}
+	if paidDate.After(time.Now()) {
+		return time.Time{}, fmt.Errorf("%w: paid_date must not be in the future", ErrValidation)
+	}
+	return paidDate, nil
+}
+
+func newPayment(payer uuid.UUID, req *models.CreatePaymentRequest, paidDate time.Time, currency string) *models.Payment {
+	return &models.Payment{
+		UserID:            payer,
+		Amount:            req.Amount,
+		Currency:          currency,
+		PaidDate:          paidDate.Format(models.DateLayout),
+		Method:            trimmedOrNil(req.Method),
+		ExternalReference: trimmedOrNil(req.ExternalReference),
+		Description:       trimmedOrNil(req.Description),
+	}
+}
+
+// createPayment сохраняет платеж; повтор external_reference — конфликт
+func (s *PaymentService) createPayment(payment *models.Payment) error {
+	created, err := s.repo.Create(payment)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return fmt.Errorf("%w: payment %s is already recorded", ErrConflict, *payment.ExternalReference)
+	}
+	return nil
+}
+
+// isPayer сообщает, является ли пользователь владельцем или участником подписки
+func isPayer(sub *models.Subscription, userID uuid.UUID) bool {
+	if sub.UserID == userID {
+		return true
+	}
+	for _, member := range sub.Members {
+		if member.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"go-dev/internal/models"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type ReceiptService struct {
+	subscriptions *SubscriptionService
+	payments      *PaymentService
+}
+
+func NewReceiptService(subscriptions *SubscriptionService, payments *PaymentService) *ReceiptService {
+	return &ReceiptService{subscriptions: subscriptions, payments: payments}
+}
+
+// ImportReceipt записывает оплату из чека. Чек сопоставляется с подписками пользователя
+// по названию сервиса, а если подходящей нет — с сервисом каталога, и по нему создается
+// подписка: тариф выбирается по цене и валюте чека, подписка начинается с месяца
+// оплаты и списывается в ее день. Повторно загруженный чек пропускается.
+func (s *ReceiptService) ImportReceipt(userID uuid.UUID, receipt *models.Receipt) (*models.ReceiptImportResult, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	result := &models.ReceiptImportResult{
+		Rule:        receipt.Rule,
+		Sender:      receipt.Sender,
+		ServiceName: receipt.ServiceName,
+		Amount:      receipt.Amount,
+		Currency:    receipt.Currency,
+		Date:        receipt.Date.Format(models.DateLayout),
+	}
+
+	reference := receiptReference(receipt)
+	exists, err := s.payments.repo.ExistsReference(userID, reference)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		result.Action = models.ReceiptDuplicate
+		return result, nil
+	}
+
+	subscriptions, err := s.subscriptions.repo.List(models.SubscriptionFilter{UserID: &userID})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Action = models.ReceiptPaymentRecorded
+	sub := matchReceipt(receipt, userID, subscriptions)
+	if sub == nil {
+		if sub, err = s.createFromReceipt(userID, receipt); err != nil {
+			return nil, err
+		}
+		result.Action = models.ReceiptSubscriptionCreated
+	}
+	result.Subscription = sub
+	result.ServiceName = sub.CanonicalServiceName()
+
+	description := strings.TrimSpace(receipt.Subject)
+	if description == "" {
+		description = receipt.ServiceName
+	}
+	method := "email_receipt"
+	result.Payment = &models.Payment{
+		SubscriptionID:    &sub.ID,
+		UserID:            userID,
+		Amount:            receipt.Amount,
+		Currency:          receipt.Currency,
+		PaidDate:          result.Date,
+		Method:            &method,
+		ExternalReference: &reference,
+		Description:       &description,
+	}
+	if err := s.payments.createPayment(result.Payment); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// createFromReceipt создает подписку по чеку через Create; сервис каталога с тем же
+// названием задает каноническое название и тариф, если цена и валюта тарифа совпадают с чеком
+func (s *ReceiptService) createFromReceipt(userID uuid.UUID, receipt *models.Receipt) (*models.Subscription, error) {
+	create := &models.CreateSubscriptionRequest{
+		ServiceName:     receipt.ServiceName,
+		Price:           receipt.Amount,
+		Currency:        receipt.Currency,
+		BillingPeriod:   receipt.BillingPeriod,
+		BillingInterval: 1,
+		BillingDay:      receipt.Date.Day(),
+		UserID:          userID,
+		StartDate:       models.MonthOf(receipt.Date).String(),
+	}
+
+	service, err := s.subscriptions.catalog.GetServiceByName(receipt.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	if service != nil {
+		create.ServiceName = service.Name
+		plans, err := s.subscriptions.catalog.ListPlans(service.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, plan := range plans {
+			if plan.Price == receipt.Amount && plan.Currency == receipt.Currency {
+				create.PlanID = &plan.ID
+				create.BillingPeriod = plan.BillingPeriod
+				create.BillingInterval = plan.BillingInterval
+				break
+			}
+		}
+	}
+
+	return s.subscriptions.Create(create)
+}
+
+// matchReceipt находит подписку пользователя на сервис из чека: название подписки
+// входит в название из чека целыми словами или наоборот. Подписка, действующая
+// в месяце оплаты, предпочтительнее закончившейся или еще не начавшейся.
+func matchReceipt(receipt *models.Receipt, userID uuid.UUID, subscriptions []*models.Subscription) *models.Subscription {
+	name := normalizeServiceName(receipt.ServiceName)
+	month := models.MonthOf(receipt.Date)
+
+	var match *models.Subscription
+	for _, sub := range subscriptions {
+		if sub.UserID != userID {
+			continue
+		}
+		subName := normalizeServiceName(sub.CanonicalServiceName())
+		if !containsWords(name, subName) && !containsWords(subName, name) {
+			continue
+		}
+		if _, _, active, err := activeMonths(sub, month, month); err == nil && active {
+			return sub
+		}
+		if match == nil {
+			match = sub
+		}
+	}
+	return match
+}
+
+// receiptReference — идентификатор платежа по чеку: Message-ID письма, а без него —
+// хэш отправителя, даты, суммы и валюты
+func receiptReference(receipt *models.Receipt) string {
+	if receipt.MessageID != "" {
+		return "receipt:" + receipt.MessageID
+	}
+	key := fmt.Sprintf("%s|%s|%d|%s", receipt.Sender, receipt.Date.UTC().Format("2006-01-02T15:04:05"),
+		receipt.Amount, receipt.Currency)
+	sum := sha1.Sum([]byte(key))
+	return "receipt:" + hex.EncodeToString(sum[:10])
+}
@@ -0,0 +1,398 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go-dev/internal/models"
+	"go-dev/internal/repository"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// reconcileGraceDays — сколько дней после даты списания ждать платежа, прежде чем
+	// считать списание пропущенным
+	reconcileGraceDays = 3
+	// reconcileTolerancePercent — допустимое расхождение суммы, если платеж пересчитан
+	// из другой валюты
+	reconcileTolerancePercent = 2.0
+	// recurringWindowMonths — в скольких последних месяцах искать повторы списания без подписки
+	recurringWindowMonths = 3
+	// recurringAmountPercent — насколько могут отличаться суммы повторяющегося списания
+	recurringAmountPercent = 10.0
+)
+
+type ReconciliationService struct {
+	subscriptions *SubscriptionService
+	payments      *PaymentService
+	repo          *repository.ReconciliationRepository
+}
+
+func NewReconciliationService(subscriptions *SubscriptionService, payments *PaymentService,
+	repo *repository.ReconciliationRepository) *ReconciliationService {
+	return &ReconciliationService{subscriptions: subscriptions, payments: payments, repo: repo}
+}
+
+// GetReconciliation возвращает сохраненный отчет сверки пользователя за месяц
+func (s *ReconciliationService) GetReconciliation(userID uuid.UUID, month models.Month) (*models.ReconciliationReport, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	items, err := s.repo.ListByMonth(userID, month.String())
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.ReconciliationReport{UserID: userID, Month: month.String(), Items: items}
+	for _, item := range items {
+		if item.Status == models.ReconciliationResolved {
+			report.Resolved++
+		} else {
+			report.Open++
+		}
+	}
+	return report, nil
+}
+
+// ReconcileMonth сверяет ожидаемые списания подписок пользователя за месяц с записанными
+// платежами, сохраняет найденные расхождения и возвращает отчет
+func (s *ReconciliationService) ReconcileMonth(userID uuid.UUID, month models.Month) (*models.ReconciliationReport, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	if month > models.MonthOf(time.Now()) {
+		return nil, fmt.Errorf("%w: cannot reconcile a future month", ErrValidation)
+	}
+	if err := s.reconcileUser(userID, month, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.GetReconciliation(userID, month)
+}
+
+// ReconcileAll сверяет текущий и прошлый месяцы всех пользователей с подписками или
+// платежами. Запускается периодически; возвращает число сверенных пользователей.
+// Ошибка сверки одного пользователя не прерывает сверку остальных: ошибки собираются
+// и возвращаются вместе.
+func (s *ReconciliationService) ReconcileAll() (int, error) {
+	payers, err := s.subscriptions.repo.ListPayerIDs()
+	if err != nil {
+		return 0, err
+	}
+	withPayments, err := s.payments.repo.ListUserIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	now := time.Now()
+	current := models.MonthOf(now)
+	reconciled := 0
+	var errs []error
+	for _, userID := range append(payers, withPayments...) {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		ok := true
+		for _, month := range []models.Month{current.AddMonths(-1), current} {
+			if err := s.reconcileUser(userID, month, now); err != nil {
+				errs = append(errs, fmt.Errorf("user %s, %s: %w", userID, month, err))
+				ok = false
+			}
+		}
+		if ok {
+			reconciled++
+		}
+	}
+	return reconciled, errors.Join(errs...)
+}
+
+// ResolveReconciliationItem отмечает расхождение разрешенным. Повторная сверка
+// не открывает его снова.
+func (s *ReconciliationService) ResolveReconciliationItem(userID uuid.UUID, itemID int, req *models.ResolveReconciliationItemRequest) (*models.ReconciliationItem, error) {
+	item, err := s.repo.GetByID(itemID)
+	if err != nil {
+		return nil, err
+	}
+	if item == nil || item.UserID != userID {
+		return nil, fmt.Errorf("reconciliation item %w", ErrNotFound)
+	}
+	if item.Status == models.ReconciliationResolved {
+		return nil, fmt.Errorf("%w: reconciliation item is already resolved", ErrConflict)
+	}
+
+	if err := s.repo.Resolve(itemID, trimmedOrNil(req.Note)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	return s.repo.GetByID(itemID)
+}
+
+// reconcileUser сверяет месяц month пользователя и сохраняет расхождения.
+// Ожидаемые списания берутся по подпискам, которыми пользователь владеет: владельцу
+// списывается полная цена, участники совместной подписки возмещают ему свои доли.
+func (s *ReconciliationService) reconcileUser(userID uuid.UUID, month models.Month, now time.Time) error {
+	subscriptions, err := s.subscriptions.repo.ListForPeriod(&userID, nil, nil, month, month)
+	if err != nil {
+		return err
+	}
+
+	// Платежи по подпискам пользователя от любого плательщика и все платежи самого пользователя
+	from, to := month.Time(), month.AddMonths(1).Time()
+	var owned []int
+	for _, sub := range subscriptions {
+		if sub.UserID == userID {
+			owned = append(owned, sub.ID)
+		}
+	}
+	byID := make(map[int]models.Payment)
+	if len(owned) > 0 {
+		payments, err := s.payments.repo.List(models.PaymentFilter{SubscriptionIDs: owned, From: &from, To: &to})
+		if err != nil {
+			return err
+		}
+		for _, payment := range payments {
+			byID[payment.ID] = payment
+		}
+	}
+	payments, err := s.payments.repo.List(models.PaymentFilter{UserID: &userID, From: &from, To: &to})
+	if err != nil {
+		return err
+	}
+	for _, payment := range payments {
+		byID[payment.ID] = payment
+	}
+
+	bySubscription := make(map[int][]models.Payment)
+	var unlinked []models.Payment
+	for _, payment := range byID {
+		if payment.SubscriptionID == nil {
+			unlinked = append(unlinked, payment)
+		} else {
+			bySubscription[*payment.SubscriptionID] = append(bySubscription[*payment.SubscriptionID], payment)
+		}
+	}
+
+	r := &reconciler{service: s.subscriptions, month: month, cutoff: now.AddDate(0, 0, -reconcileGraceDays)}
+	var items []models.ReconciliationItem
+	for _, sub := range subscriptions {
+		paid, ok := bySubscription[sub.ID]
+		delete(bySubscription, sub.ID)
+
+		charges, err := monthlyCharges(sub, month, month)
+		if err != nil {
+			return err
+		}
+		if len(charges) == 0 {
+			// Подписка на паузе: любое списание лишнее
+			items = append(items, cancelledCharges(sub, month, paid)...)
+			continue
+		}
+		if sub.UserID != userID {
+			// Участник возмещает долю владельцу, сверяется только подписка владельца
+			continue
+		}
+
+		item, found, err := r.checkSubscription(sub, charges[0].Amount, paid, ok)
+		if err != nil {
+			return err
+		}
+		if found {
+			items = append(items, item)
+		}
+	}
+
+	// Платежи по подпискам, которые в этом месяце не действуют
+	subscriptionIDs := make([]int, 0, len(bySubscription))
+	for id := range bySubscription {
+		subscriptionIDs = append(subscriptionIDs, id)
+	}
+	sort.Ints(subscriptionIDs)
+	for _, id := range subscriptionIDs {
+		sub, err := s.subscriptions.repo.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if sub == nil {
+			continue
+		}
+		items = append(items, cancelledCharges(sub, month, bySubscription[id])...)
+	}
+
+	recurring, err := s.unknownRecurring(userID, month, unlinked)
+	if err != nil {
+		return err
+	}
+	items = append(items, recurring...)
+
+	for i := range items {
+		items[i].UserID = userID
+		items[i].Month = month.String()
+	}
+	return s.repo.Replace(userID, month.String(), items)
+}
+
+// reconciler пересчитывает платежи в валюту подписки; курсы загружаются один раз
+// при первой необходимости
+type reconciler struct {
+	service   *SubscriptionService
+	month     models.Month
+	cutoff    time.Time // списания после этой даты еще могут не дойти
+	converter *currencyConverter
+}
+
+// checkSubscription сравнивает ожидаемое списание подписки за месяц с ее платежами
+func (r *reconciler) checkSubscription(sub *models.Subscription, expected int, paid []models.Payment, hasPayments bool) (models.ReconciliationItem, bool, error) {
+	item := models.ReconciliationItem{
+		SubscriptionID: &sub.ID,
+		Expected:       expected,
+		Currency:       sub.Currency,
+	}
+	name := sub.CanonicalServiceName()
+
+	if !hasPayments {
+		if expected == 0 || !r.isDue(sub) {
+			return item, false, nil
+		}
+		item.Kind = models.ReconcileMissingCharge
+		item.Description = fmt.Sprintf("Expected %s charge of %s in %s was not found",
+			name, formatAmount(expected, sub.Currency), r.month)
+		return item, true, nil
+	}
+
+	actual, converted := 0, false
+	for _, payment := range paid {
+		amount := payment.Amount
+		if payment.Currency != sub.Currency {
+			var err error
+			if amount, err = r.convert(payment.Amount, payment.Currency, sub.Currency); err != nil {
+				return item, false, err
+			}
+			converted = true
+		}
+		actual += amount
+	}
+	item.Actual = actual
+
+	if actual == expected {
+		return item, false, nil
+	}
+	if converted && expected > 0 &&
+		math.Abs(float64(actual-expected))/float64(expected)*100 <= reconcileTolerancePercent {
+		return item, false, nil
+	}
+	item.Kind = models.ReconcileAmountMismatch
+	item.Description = fmt.Sprintf("%s charged %s in %s, expected %s",
+		name, formatAmount(actual, sub.Currency), r.month, formatAmount(expected, sub.Currency))
+	return item, true, nil
+}
+
+// isDue сообщает, прошла ли с даты списания подписки в месяце отсрочка reconcileGraceDays
+func (r *reconciler) isDue(sub *models.Subscription) bool {
+	anchor, err := sub.BillingAnchor()
+	if err != nil {
+		return false
+	}
+	for _, date := range chargeDates(sub.Cycle(), anchor, sub.ChargeDay(), r.month, r.month) {
+		if !date.After(r.cutoff) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *reconciler) convert(amount int, from, to string) (int, error) {
+	if r.converter == nil {
+		rates, err := r.service.rates.ListUpTo(r.month)
+		if err != nil {
+			return 0, err
+		}
+		r.converter = newCurrencyConverter(rates)
+	}
+	return r.converter.convert(amount, from, to, r.month)
+}
+
+// cancelledCharges отмечает каждый платеж по подписке, которая в месяце month не действует
+func cancelledCharges(sub *models.Subscription, month models.Month, paid []models.Payment) []models.ReconciliationItem {
+	reason := string(sub.Status)
+	switch start, end, err := subscriptionSpan(sub); {
+	case err != nil:
+	case sub.IsPausedAt(month):
+		reason = "paused"
+	case end != nil && *end < month:
+		reason = fmt.Sprintf("ended in %s", end)
+	case start > month:
+		reason = fmt.Sprintf("starts in %s", start)
+	}
+
+	items := make([]models.ReconciliationItem, 0, len(paid))
+	for _, payment := range paid {
+		paymentID := payment.ID
+		items = append(items, models.ReconciliationItem{
+			Kind:           models.ReconcileCancelledCharge,
+			SubscriptionID: &sub.ID,
+			PaymentID:      &paymentID,
+			Actual:         payment.Amount,
+			Currency:       payment.Currency,
+			Description: fmt.Sprintf("%s charged %s on %s, but the subscription is %s",
+				sub.CanonicalServiceName(), formatAmount(payment.Amount, payment.Currency), payment.PaidDate, reason),
+		})
+	}
+	return items
+}
+
+// unknownRecurring отмечает платежи месяца без подписки, которые повторяются с похожей
+// суммой у того же получателя (см. merchantKey) хотя бы в одном из предыдущих
+// recurringWindowMonths-1 месяцев
+func (s *ReconciliationService) unknownRecurring(userID uuid.UUID, month models.Month, unlinked []models.Payment) ([]models.ReconciliationItem, error) {
+	if len(unlinked) == 0 {
+		return nil, nil
+	}
+
+	from, to := month.AddMonths(1-recurringWindowMonths).Time(), month.Time()
+	history, err := s.payments.repo.List(models.PaymentFilter{UserID: &userID, Unlinked: true, From: &from, To: &to})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(unlinked, func(i, j int) bool { return unlinked[i].ID < unlinked[j].ID })
+	var items []models.ReconciliationItem
+	for _, payment := range unlinked {
+		key := paymentMerchant(payment)
+		if key == "" {
+			continue
+		}
+		months := map[string]bool{}
+		for _, earlier := range history {
+			if paymentMerchant(earlier) == key && earlier.Currency == payment.Currency &&
+				similarAmount(earlier.Amount, payment.Amount) {
+				months[earlier.PaidDate[:7]] = true
+			}
+		}
+		if len(months) == 0 {
+			continue
+		}
+
+		paymentID := payment.ID
+		items = append(items, models.ReconciliationItem{
+			Kind:      models.ReconcileUnknownRecurring,
+			PaymentID: &paymentID,
+			Actual:    payment.Amount,
+			Currency:  payment.Currency,
+			Description: fmt.Sprintf("Recurring charge %q of %s on %s (also in %d earlier month(s)) does not match any subscription",
+				*payment.Description, formatAmount(payment.Amount, payment.Currency), payment.PaidDate, len(months)),
+		})
+	}
+	return items, nil
+}
+
+// similarAmount сообщает, отличаются ли суммы не больше чем на recurringAmountPercent
+func similarAmount(a, b int) bool {
+	if a == b {
+		return true
+	}
+	larger := math.Max(float64(a), float64(b))
+	return math.Abs(float64(a-b))/larger*100 <= recurringAmountPercent
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"go-dev/internal/models"
+	"go-dev/internal/repository"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// candidateHistoryDays — за сколько дней платежи без подписки участвуют в поиске кандидатов
+	candidateHistoryDays = 400
+	// minCandidateConfidence — минимальная доля списаний, совпавших по сумме и интервалу
+	minCandidateConfidence = 0.75
+)
+
+// cadence — диапазон интервалов между списаниями в днях, соответствующий расчетному периоду
+type cadence struct {
+	cycle      models.BillingCycle
+	minDays    int
+	maxDays    int
+	minCharges int
+}
+
+var cadences = []cadence{
+	{models.BillingCycle{Period: models.BillingWeek, Interval: 1}, 6, 8, 3},
+	{models.BillingCycle{Period: models.BillingWeek, Interval: 2}, 13, 15, 3},
+	{models.BillingCycle{Period: models.BillingMonth, Interval: 1}, 27, 33, 3},
+	{models.BillingCycle{Period: models.BillingQuarter, Interval: 1}, 85, 96, 3},
+	{models.BillingCycle{Period: models.BillingMonth, Interval: 6}, 175, 190, 2},
+	{models.BillingCycle{Period: models.BillingYear, Interval: 1}, 355, 375, 2},
+}
+
+type StatementService struct {
+	subscriptions *SubscriptionService
+	payments      *PaymentService
+	repo          *repository.CandidateRepository
+}
+
+func NewStatementService(subscriptions *SubscriptionService, payments *PaymentService,
+	repo *repository.CandidateRepository) *StatementService {
+	return &StatementService{subscriptions: subscriptions, payments: payments, repo: repo}
+}
+
+// ImportStatement сохраняет списания из банковской выписки как платежи пользователя.
+// Повторно загруженные списания пропускаются; списания, похожие на существующие подписки
+// пользователя, привязываются к ним. Затем среди платежей без подписки ищутся
+// повторяющиеся списания, которые предлагаются как новые подписки.
+func (s *StatementService) ImportStatement(userID uuid.UUID, transactions []models.StatementTransaction) (*models.StatementImportResult, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	subscriptions, err := s.subscriptions.repo.List(models.SubscriptionFilter{UserID: &userID})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.StatementImportResult{}
+	occurrences := make(map[string]int)
+	for _, tx := range transactions {
+		reference := tx.Reference
+		if reference == "" {
+			reference = syntheticReference(tx, occurrences)
+		}
+		description := tx.Description
+		payment := &models.Payment{
+			UserID:            userID,
+			Amount:            tx.Amount,
+			Currency:          tx.Currency,
+			PaidDate:          tx.Date.Format(models.DateLayout),
+			ExternalReference: &reference,
+			Description:       &description,
+		}
+		if sub := matchSubscription(tx, userID, subscriptions); sub != nil {
+			payment.SubscriptionID = &sub.ID
+		}
+
+		created, err := s.payments.repo.Create(payment)
+		if err != nil {
+			return nil, err
+		}
+		if !created {
+			result.Duplicates++
+			continue
+		}
+		result.Imported++
+		if payment.SubscriptionID != nil {
+			result.Linked++
+		}
+	}
+
+	if result.Candidates, err = s.DetectCandidates(userID); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DetectCandidates ищет среди платежей пользователя без подписки повторяющиеся списания
+// одного получателя с похожей суммой и регулярным интервалом и сохраняет их как
+// кандидатов в подписки. Возвращает кандидатов, ждущих решения пользователя.
+func (s *StatementService) DetectCandidates(userID uuid.UUID) ([]models.SubscriptionCandidate, error) {
+	now := time.Now()
+	from := now.AddDate(0, 0, -candidateHistoryDays)
+	payments, err := s.payments.repo.List(models.PaymentFilter{UserID: &userID, Unlinked: true, From: &from})
+	if err != nil {
+		return nil, err
+	}
+	subscriptions, err := s.subscriptions.repo.List(models.SubscriptionFilter{UserID: &userID})
+	if err != nil {
+		return nil, err
+	}
+	known := make([]string, 0, len(subscriptions))
+	for _, sub := range subscriptions {
+		known = append(known, normalizeServiceName(sub.CanonicalServiceName()))
+	}
+
+	type groupKey struct{ merchant, currency string }
+	groups := make(map[groupKey][]models.Payment)
+	for _, payment := range payments {
+		merchant := paymentMerchant(payment)
+		if merchant == "" || matchesAny(merchant, known) {
+			continue
+		}
+		key := groupKey{merchant, payment.Currency}
+		groups[key] = append(groups[key], payment)
+	}
+
+	for key, group := range groups {
+		candidate, ok := detectRecurring(group, now)
+		if !ok {
+			continue
+		}
+		candidate.UserID = userID
+		candidate.Merchant = key.merchant
+		candidate.ServiceName = merchantName(key.merchant)
+		candidate.Currency = key.currency
+		if _, err := s.repo.Upsert(&candidate); err != nil {
+			return nil, err
+		}
+	}
+
+	pending := models.CandidatePending
+	return s.repo.ListByUser(userID, &pending)
+}
+
+// ListCandidates возвращает предложенные подписки пользователя
+func (s *StatementService) ListCandidates(userID uuid.UUID, status *models.CandidateStatus) ([]models.SubscriptionCandidate, error) {
+	if err := s.subscriptions.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByUser(userID, status)
+}
+
+// ConfirmCandidate создает подписку по кандидату через Create и привязывает к ней
+// платежи этого получателя. Подписка начинается с месяца первого списания и
+// списывается в его день.
+func (s *StatementService) ConfirmCandidate(userID uuid.UUID, id int, req *models.ConfirmCandidateRequest) (*models.Subscription, error) {
+	candidate, err := s.getCandidate(userID, id)
+	if err != nil {
+		return nil, err
+	}
+	firstCharge, err := time.Parse(models.DateLayout, candidate.FirstCharge)
+	if err != nil {
+		return nil, fmt.Errorf("candidate %d: %w", candidate.ID, err)
+	}
+
+	create := &models.CreateSubscriptionRequest{
+		ServiceName:     candidate.ServiceName,
+		Price:           candidate.Amount,
+		Currency:        candidate.Currency,
+		BillingPeriod:   candidate.BillingPeriod,
+		BillingInterval: candidate.BillingInterval,
+		BillingDay:      firstCharge.Day(),
+		UserID:          userID,
+		StartDate:       models.MonthOf(firstCharge).String(),
+		Category:        req.Category,
+		Tags:            req.Tags,
+	}
+	if req.ServiceName != nil {
+		create.ServiceName = *req.ServiceName
+	}
+	if req.Price != nil {
+		create.Price = *req.Price
+	}
+
+	sub, err := s.subscriptions.Create(create)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.payments.repo.List(models.PaymentFilter{UserID: &userID, Unlinked: true})
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for _, payment := range payments {
+		if payment.Currency == candidate.Currency && paymentMerchant(payment) == candidate.Merchant {
+			ids = append(ids, payment.ID)
+		}
+	}
+	if len(ids) > 0 {
+		if err := s.payments.repo.Link(ids, sub.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := s.repo.SetStatus(candidate.ID, models.CandidateConfirmed, &sub.ID); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// DismissCandidate отклоняет кандидата; этот получатель больше не предлагается
+func (s *StatementService) DismissCandidate(userID uuid.UUID, id int) error {
+	candidate, err := s.getCandidate(userID, id)
+	if err != nil {
+		return err
+	}
+	updated, err := s.repo.SetStatus(candidate.ID, models.CandidateDismissed, nil)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("%w: candidate was already handled", ErrConflict)
+	}
+	return nil
+}
+
+// getCandidate возвращает ожидающего решения кандидата пользователя
+func (s *StatementService) getCandidate(userID uuid.UUID, id int) (*models.SubscriptionCandidate, error) {
+	candidate, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if candidate == nil || candidate.UserID != userID {
+		return nil, fmt.Errorf("candidate %w", ErrNotFound)
+	}
+	if candidate.Status != models.CandidatePending {
+		return nil, fmt.Errorf("%w: candidate is already %s", ErrConflict, candidate.Status)
+	}
+	return candidate, nil
+}
+
+// detectRecurring проверяет, образуют ли списания одного получателя регулярную подписку:
+// типичный интервал между списаниями соответствует одному из расчетных периодов, а суммы
+// и интервалы совпадают не меньше чем в minCandidateConfidence случаев. Получатель,
+// не списывавший больше двух периодов, считается прекратившим списания.
+func detectRecurring(payments []models.Payment, now time.Time) (models.SubscriptionCandidate, bool) {
+	if len(payments) < 2 {
+		return models.SubscriptionCandidate{}, false
+	}
+
+	dates := make([]time.Time, 0, len(payments))
+	amounts := make([]int, 0, len(payments))
+	sort.Slice(payments, func(i, j int) bool { return payments[i].PaidDate < payments[j].PaidDate })
+	for _, payment := range payments {
+		date, err := time.Parse(models.DateLayout, payment.PaidDate)
+		if err != nil {
+			return models.SubscriptionCandidate{}, false
+		}
+		dates = append(dates, date)
+		amounts = append(amounts, payment.Amount)
+	}
+
+	intervals := make([]int, 0, len(dates)-1)
+	for i := 1; i < len(dates); i++ {
+		intervals = append(intervals, int(dates[i].Sub(dates[i-1]).Hours()/24))
+	}
+	typical := median(intervals)
+
+	var matched *cadence
+	for i := range cadences {
+		if typical >= cadences[i].minDays && typical <= cadences[i].maxDays {
+			matched = &cadences[i]
+			break
+		}
+	}
+	if matched == nil || len(payments) < matched.minCharges {
+		return models.SubscriptionCandidate{}, false
+	}
+	last := dates[len(dates)-1]
+	if now.Sub(last).Hours()/24 > float64(2*matched.maxDays) {
+		return models.SubscriptionCandidate{}, false
+	}
+
+	regular := 0
+	for _, days := range intervals {
+		if days >= matched.minDays && days <= matched.maxDays {
+			regular++
+		}
+	}
+	typicalAmount := median(amounts)
+	similar := 0
+	for _, amount := range amounts {
+		if similarAmount(amount, typicalAmount) {
+			similar++
+		}
+	}
+	confidence := math.Min(float64(regular)/float64(len(intervals)), float64(similar)/float64(len(amounts)))
+	if confidence < minCandidateConfidence {
+		return models.SubscriptionCandidate{}, false
+	}
+
+	return models.SubscriptionCandidate{
+		Amount:          amounts[len(amounts)-1],
+		BillingPeriod:   matched.cycle.Period,
+		BillingInterval: matched.cycle.Interval,
+		FirstCharge:     dates[0].Format(models.DateLayout),
+		LastCharge:      last.Format(models.DateLayout),
+		Charges:         len(payments),
+		Confidence:      math.Round(confidence*100) / 100,
+	}, true
+}
+
+// matchSubscription находит подписку пользователя, которой соответствует списание:
+// название сервиса входит в описание, валюта совпадает и подписка действует в месяце списания
+func matchSubscription(tx models.StatementTransaction, userID uuid.UUID, subscriptions []*models.Subscription) *models.Subscription {
+	merchant := merchantKey(tx.Description)
+	month := models.MonthOf(tx.Date)
+	for _, sub := range subscriptions {
+		if sub.UserID != userID || sub.Currency != tx.Currency {
+			continue
+		}
+		if !containsWords(merchant, normalizeServiceName(sub.CanonicalServiceName())) {
+			continue
+		}
+		if _, _, active, err := activeMonths(sub, month, month); err == nil && active {
+			return sub
+		}
+	}
+	return nil
+}
+
+// merchantKey выделяет получателя из описания списания: слова без регистра, знаков
+// препинания и слов с цифрами (номеров операций, карт и телефонов)
+func merchantKey(description string) string {
+	var words []string
+	for _, word := range strings.Fields(normalizeServiceName(description)) {
+		if !strings.ContainsFunc(word, unicode.IsDigit) {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func paymentMerchant(payment models.Payment) string {
+	if payment.Description == nil {
+		return ""
+	}
+	return merchantKey(*payment.Description)
+}
+
+// merchantName делает из ключа получателя название сервиса: "netflix com" -> "Netflix Com"
+func merchantName(merchant string) string {
+	words := strings.Fields(merchant)
+	for i, word := range words {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+// containsWords сообщает, входит ли фраза part в text целыми словами
+func containsWords(text, part string) bool {
+	if part == "" {
+		return false
+	}
+	return strings.Contains(" "+text+" ", " "+part+" ")
+}
+
+func matchesAny(merchant string, names []string) bool {
+	for _, name := range names {
+		if containsWords(merchant, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// syntheticReference строит идентификатор списания без идентификатора операции из даты,
+// суммы, валюты и описания, чтобы повторная загрузка той же выписки не дублировала
+// платежи. Одинаковые списания в одной выписке различаются порядковым номером.
+func syntheticReference(tx models.StatementTransaction, occurrences map[string]int) string {
+	key := fmt.Sprintf("%s|%d|%s|%s", tx.Date.Format(models.DateLayout), tx.Amount, tx.Currency, tx.Description)
+	occurrences[key]++
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d", key, occurrences[key])))
+	return "statement:" + hex.EncodeToString(sum[:10])
+}
+
+// median возвращает медиану; для четного числа значений — меньшую из средних
+func median(values []int) int {
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	return sorted[(len(sorted)-1)/2]
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"go-dev/internal/models"
+	"testing"
+)
+
+func TestDetectRecurring(t *testing.T) {
+	payments := func(amount int, dates ...string) []models.Payment {
+		result := make([]models.Payment, len(dates))
+		for i, date := range dates {
+			result[i] = models.Payment{ID: i + 1, Amount: amount, Currency: "USD", PaidDate: date}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		payments []models.Payment
+		now      string
+		want     *models.SubscriptionCandidate
+	}{
+		{
+			name:     "monthly",
+			payments: payments(999, "2025-01-05", "2025-02-05", "2025-03-05", "2025-04-05", "2025-05-05"),
+			now:      "2025-06-01",
+			want: &models.SubscriptionCandidate{
+				Amount: 999, BillingPeriod: models.BillingMonth, BillingInterval: 1,
+				FirstCharge: "2025-01-05", LastCharge: "2025-05-05", Charges: 5, Confidence: 1,
+			},
+		},
+		{
+			name:     "monthly out of order",
+			payments: payments(999, "2025-03-05", "2025-01-05", "2025-02-05"),
+			now:      "2025-03-20",
+			want: &models.SubscriptionCandidate{
+				Amount: 999, BillingPeriod: models.BillingMonth, BillingInterval: 1,
+				FirstCharge: "2025-01-05", LastCharge: "2025-03-05", Charges: 3, Confidence: 1,
+			},
+		},
+		{
+			name:     "every two weeks",
+			payments: payments(500, "2025-05-01", "2025-05-15", "2025-05-29"),
+			now:      "2025-06-05",
+			want: &models.SubscriptionCandidate{
+				Amount: 500, BillingPeriod: models.BillingWeek, BillingInterval: 2,
+				FirstCharge: "2025-05-01", LastCharge: "2025-05-29", Charges: 3, Confidence: 1,
+			},
+		},
+		{
+			name:     "yearly needs two charges",
+			payments: payments(4999, "2023-06-01", "2024-06-01"),
+			now:      "2025-06-15",
+			want: &models.SubscriptionCandidate{
+				Amount: 4999, BillingPeriod: models.BillingYear, BillingInterval: 1,
+				FirstCharge: "2023-06-01", LastCharge: "2024-06-01", Charges: 2, Confidence: 1,
+			},
+		},
+		{
+			name:     "one irregular interval lowers confidence",
+			payments: payments(999, "2025-01-05", "2025-02-05", "2025-03-05", "2025-04-05", "2025-04-20"),
+			now:      "2025-05-01",
+			want: &models.SubscriptionCandidate{
+				Amount: 999, BillingPeriod: models.BillingMonth, BillingInterval: 1,
+				FirstCharge: "2025-01-05", LastCharge: "2025-04-20", Charges: 5, Confidence: 0.75,
+			},
+		},
+		{
+			name:     "single charge",
+			payments: payments(999, "2025-05-05"),
+			now:      "2025-05-10",
+		},
+		{
+			name:     "too few monthly charges",
+			payments: payments(999, "2025-04-05", "2025-05-05"),
+			now:      "2025-05-10",
+		},
+		{
+			name:     "stopped charging",
+			payments: payments(999, "2025-01-05", "2025-02-05", "2025-03-05"),
+			now:      "2025-06-15",
+		},
+		{
+			name:     "no matching cadence",
+			payments: payments(999, "2025-01-01", "2025-01-20", "2025-03-15", "2025-03-17"),
+			now:      "2025-03-20",
+		},
+		{
+			name: "amounts differ too often",
+			payments: []models.Payment{
+				{Amount: 999, PaidDate: "2025-01-05"},
+				{Amount: 999, PaidDate: "2025-02-05"},
+				{Amount: 2500, PaidDate: "2025-03-05"},
+				{Amount: 3000, PaidDate: "2025-04-05"},
+				{Amount: 999, PaidDate: "2025-05-05"},
+			},
+			now: "2025-05-10",
+		},
+		{
+			name:     "invalid date",
+			payments: payments(999, "2025-01-05", "05-02-2025", "2025-03-05"),
+			now:      "2025-03-10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := detectRecurring(tt.payments, mustDate(t, tt.now))
+			if tt.want == nil {
+				if ok {
+					t.Fatalf("detectRecurring() = %+v, want no candidate", got)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("detectRecurring() found no candidate, want %+v", *tt.want)
+			}
+			if got != *tt.want {
+				t.Errorf("detectRecurring() = %+v, want %+v", got, *tt.want)
+			}
+		})
+	}
+}
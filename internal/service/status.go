@@ -0,0 +1,147 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"go-dev/internal/models"
+	"time"
+)
+
+// ChangeStatus выполняет ручной переход подписки в статус req.Status:
+//   - paused — приостанавливает подписку с текущего месяца;
+//   - active — возобновляет приостановленную подписку или отзывает отмену в окне отмены;
+//   - pending_cancellation — отменяет подписку в конце оплаченного периода;
+//   - cancelled — отменяет подписку с окончанием текущим месяцем.
+//
+// trialing и expired выставляются автоматически по пробному периоду и end_date.
+func (s *SubscriptionService) ChangeStatus(id int, req *models.StatusChangeRequest) (*models.Subscription, error) {
+	sub, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	// Отзыв отмены проверяет окно отмены сам, в обход таблицы переходов
+	if req.Status == models.StatusActive &&
+		(sub.Status == models.StatusPendingCancellation || sub.Status == models.StatusCancelled) {
+		return s.undoCancel(sub)
+	}
+	if !sub.Status.CanTransitionTo(req.Status) {
+		return nil, fmt.Errorf("%w: cannot change status from %s to %s", ErrConflict, sub.Status, req.Status)
+	}
+	now := models.MonthOf(time.Now())
+
+	switch req.Status {
+	case models.StatusPaused:
+		return s.pause(sub, now, req.Reason)
+
+	case models.StatusActive:
+		if sub.Status == models.StatusPaused {
+			return s.resume(sub, now, req.Reason)
+		}
+
+	case models.StatusPendingCancellation, models.StatusCancelled:
+		mode := models.CancelAtPeriodEnd
+		if req.Status == models.StatusCancelled {
+			mode = models.CancelImmediate
+		}
+		result, err := s.cancel(sub, &models.CancelRequest{Mode: mode, Comment: req.Reason})
+		if err != nil {
+			return nil, err
+		}
+		return result.Subscription, nil
+	}
+
+	return nil, fmt.Errorf("%w: cannot change status from %s to %s manually", ErrConflict, sub.Status, req.Status)
+}
+
+// GetStatusHistory возвращает журнал переходов подписки между статусами
+func (s *SubscriptionService) GetStatusHistory(id int) ([]models.StatusTransition, error) {
+	if _, err := s.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTransitions(id)
+}
+
+// SyncStatuses переводит подписки в статус по расписанию: начало и конец пробного периода
+// и пауз, наступление end_date. Запускается периодически; возвращает число переходов.
+func (s *SubscriptionService) SyncStatuses() (int, error) {
+	subscriptions, err := s.repo.List(models.SubscriptionFilter{
+		Statuses: []models.SubscriptionStatus{
+			models.StatusTrialing, models.StatusActive, models.StatusPaused,
+			models.StatusPendingCancellation, models.StatusExpired,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	reason := "scheduled"
+	changed := 0
+	for _, sub := range subscriptions {
+		before := sub.Status
+		if err := s.syncStatus(sub, &reason); err != nil {
+			// Статус изменили параллельно — подписка будет проверена при следующем запуске
+			if errors.Is(err, ErrConflict) {
+				continue
+			}
+			return changed, err
+		}
+		if sub.Status != before {
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// syncStatus приводит статус подписки к расписанию на текущий месяц. Это единственный
+// путь для переходов по датам; ручные переходы меняют даты и паузы, а статус
+// затем сверяется здесь.
+func (s *SubscriptionService) syncStatus(sub *models.Subscription, reason *string) error {
+	to := scheduledStatus(sub, models.MonthOf(time.Now()))
+	if to == sub.Status || !sub.Status.CanScheduleTo(to) {
+		return nil
+	}
+	return s.setStatus(sub, to, reason)
+}
+
+// setStatus записывает переход, проверяя, что статус не изменился параллельно
+func (s *SubscriptionService) setStatus(sub *models.Subscription, to models.SubscriptionStatus, reason *string) error {
+	ok, err := s.repo.SetStatus(sub.ID, sub.Status, to, reason)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: subscription status was changed concurrently", ErrConflict)
+	}
+	sub.Status = to
+	return nil
+}
+
+// scheduledStatus возвращает статус, который подписка должна иметь в месяце m по своим
+// датам, паузам и пробному периоду. Отмена подписки сохраняется до end_date.
+func scheduledStatus(sub *models.Subscription, m models.Month) models.SubscriptionStatus {
+	if sub.Status == models.StatusCancelled {
+		return models.StatusCancelled
+	}
+	if _, end, err := subscriptionSpan(sub); err == nil && end != nil && m > *end {
+		if sub.Status == models.StatusPendingCancellation {
+			return models.StatusCancelled
+		}
+		return models.StatusExpired
+	}
+	if sub.Status == models.StatusPendingCancellation {
+		return models.StatusPendingCancellation
+	}
+	return liveStatus(sub, m)
+}
+
+// liveStatus возвращает статус незакончившейся подписки в месяце m
+func liveStatus(sub *models.Subscription, m models.Month) models.SubscriptionStatus {
+	switch {
+	case sub.IsPausedAt(m):
+		return models.StatusPaused
+	case sub.InTrialAt(m):
+		return models.StatusTrialing
+	default:
+		return models.StatusActive
+	}
+}
@@ -4,29 +4,134 @@ import (
 	"fmt"
 	"go-dev/internal/models"
 	"go-dev/internal/repository"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type SubscriptionService struct {
-	repo *repository.SubscriptionRepository
+	repo    *repository.SubscriptionRepository
+	rates   *repository.ExchangeRateRepository
+	catalog *repository.CatalogRepository
+
+	costObservers []func(*models.Subscription)
+}
+
+func NewSubscriptionService(repo *repository.SubscriptionRepository, rates *repository.ExchangeRateRepository,
+	catalog *repository.CatalogRepository) *SubscriptionService {
+	return &SubscriptionService{repo: repo, rates: rates, catalog: catalog}
 }
 
-func NewSubscriptionService(repo *repository.SubscriptionRepository) *SubscriptionService {
-	return &SubscriptionService{repo: repo}
+// OnCostChange подписывает fn на изменения расходов по подпискам: создание подписки
+// и изменение ее цены. fn получает подписку уже после изменения.
+func (s *SubscriptionService) OnCostChange(fn func(*models.Subscription)) {
+	s.costObservers = append(s.costObservers, fn)
 }
 
 func (s *SubscriptionService) Create(req *models.CreateSubscriptionRequest) (*models.Subscription, error) {
+	if err := s.applyPlanDefaults(req); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.ServiceName) == "" {
+		return nil, fmt.Errorf("%w: service_name or plan_id is required", ErrValidation)
+	}
+	if req.Price < 1 {
+		return nil, fmt.Errorf("%w: price or plan_id is required", ErrValidation)
+	}
+	if err := validateDates(req.StartDate, req.EndDate); err != nil {
+		return nil, err
+	}
+	phases, err := buildPhases(req.StartDate, req.Phases)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := models.NormalizeTags(req.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("%w: tags: %v", ErrValidation, err)
+	}
+
 	sub := &models.Subscription{
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		UserID:      req.UserID,
-		StartDate:   req.StartDate,
-		EndDate:     req.EndDate,
+		ServiceName:     req.ServiceName,
+		Price:           req.Price,
+		Currency:        req.Currency,
+		BillingPeriod:   req.BillingPeriod,
+		BillingInterval: req.BillingInterval,
+		BillingDay:      req.BillingDay,
+		UserID:          req.UserID,
+		PlanID:          req.PlanID,
+		Category:        trimmedOrNil(req.Category),
+		StartDate:       req.StartDate,
+		EndDate:         req.EndDate,
+		Tags:            tags,
+		Phases:          phases,
+	}
+	cycle := sub.Cycle()
+	sub.BillingPeriod, sub.BillingInterval = cycle.Period, cycle.Interval
+	if sub.BillingDay == 0 {
+		sub.BillingDay = 1
+	}
+	if sub.Currency == "" {
+		sub.Currency = models.DefaultCurrency
 	}
+	sub.Status = scheduledStatus(sub, models.MonthOf(time.Now()))
 
-	err := s.repo.Create(sub)
-	return sub, err
+	if err := s.repo.Create(sub); err != nil {
+		return nil, err
+	}
+
+	created, err := s.GetByID(sub.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.costChanged(created)
+	// Поиск дубликатов только предупреждает и не мешает созданию подписки
+	if duplicates, err := s.duplicatesOf(created); err == nil {
+		created.DuplicateWarnings = duplicates
+	}
+	return created, nil
+}
+
+// applyPlanDefaults заполняет незаданные поля запроса из тарифного плана каталога
+func (s *SubscriptionService) applyPlanDefaults(req *models.CreateSubscriptionRequest) error {
+	if req.PlanID == nil {
+		return nil
+	}
+
+	plan, err := s.getPlan(*req.PlanID)
+	if err != nil {
+		return err
+	}
+	if req.ServiceName == "" {
+		service, err := s.catalog.GetService(plan.ServiceID)
+		if err != nil {
+			return err
+		}
+		if service != nil {
+			req.ServiceName = service.Name
+		}
+	}
+	if req.Price == 0 {
+		req.Price = plan.Price
+	}
+	if req.Currency == "" {
+		req.Currency = plan.Currency
+	}
+	if req.BillingPeriod == "" && req.BillingInterval == 0 {
+		req.BillingPeriod, req.BillingInterval = plan.BillingPeriod, plan.BillingInterval
+	}
+	return nil
+}
+
+func (s *SubscriptionService) getPlan(id int) (*models.Plan, error) {
+	plan, err := s.catalog.GetPlan(id)
+	if err != nil {
+		return nil, err
+	}
+	if plan == nil {
+		return nil, fmt.Errorf("%w: plan %d not found", ErrValidation, id)
+	}
+	return plan, nil
 }
 
 func (s *SubscriptionService) GetByID(id int) (*models.Subscription, error) {
@@ -35,23 +140,124 @@ func (s *SubscriptionService) GetByID(id int) (*models.Subscription, error) {
 		return nil, err
 	}
 	if sub == nil {
-		return nil, fmt.Errorf("subscription not found")
+		return nil, fmt.Errorf("subscription %w", ErrNotFound)
 	}
+	withDerivedPrices(sub)
 	return sub, nil
 }
 
-func (s *SubscriptionService) List(userID *uuid.UUID, serviceName *string, limit, offset int) ([]*models.Subscription, error) {
-	return s.repo.List(userID, serviceName, limit, offset)
+func (s *SubscriptionService) List(filter models.SubscriptionFilter) ([]*models.Subscription, error) {
+	subscriptions, err := s.repo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	withDerivedPrices(subscriptions...)
+	return subscriptions, nil
+}
+
+// exportBatchSize — сколько подписок читается из базы за один запрос при обходе всех подписок
+const exportBatchSize = 500
+
+// EachSubscription вызывает fn для каждой подписки по фильтру без ограничения количества,
+// читая их из базы порциями по exportBatchSize. Limit и Offset фильтра не учитываются;
+// ошибка fn прерывает обход.
+func (s *SubscriptionService) EachSubscription(filter models.SubscriptionFilter, fn func(*models.Subscription) error) error {
+	filter.Limit = exportBatchSize
+	for filter.Offset = 0; ; filter.Offset += exportBatchSize {
+		subscriptions, err := s.List(filter)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subscriptions {
+			if err := fn(sub); err != nil {
+				return err
+			}
+		}
+		if len(subscriptions) < exportBatchSize {
+			return nil
+		}
+	}
 }
 
 func (s *SubscriptionService) Update(id int, req *models.UpdateSubscriptionRequest) error {
+	var phases []models.SubscriptionPhase
+	var priceChange *models.PriceChange
+	var existing *models.Subscription
+	rebuildPhases := req.Phases != nil
+	if req.StartDate != nil || req.EndDate != nil || req.Phases != nil || req.Price != nil {
+		// Проверяем даты вместе с текущими значениями подписки
+		var err error
+		if existing, err = s.GetByID(id); err != nil {
+			return err
+		}
+		startDate, endDate := existing.StartDate, existing.EndDate
+		if req.StartDate != nil {
+			startDate = *req.StartDate
+		}
+		if req.EndDate != nil {
+			endDate = req.EndDate
+		}
+		if err := validateDates(startDate, endDate); err != nil {
+			return err
+		}
+		if req.Phases != nil {
+			if phases, err = buildPhases(startDate, *req.Phases); err != nil {
+				return err
+			}
+		} else if startDate != existing.StartDate && len(existing.Phases) > 0 {
+			// Фазы идут от месяца начала подписки: при его переносе они сдвигаются
+			// вместе с ним, сохраняя длительности и цены
+			reqs, err := phaseRequests(existing.Phases)
+			if err != nil {
+				return fmt.Errorf("subscription %d: %w", id, err)
+			}
+			if phases, err = buildPhases(startDate, reqs); err != nil {
+				return err
+			}
+			rebuildPhases = true
+		}
+		if req.Price != nil {
+			if priceChange, err = newPriceChange(id, *req.Price, req.PriceEffectiveDate, startDate); err != nil {
+				return err
+			}
+		}
+	} else if req.PriceEffectiveDate != nil {
+		return fmt.Errorf("%w: price_effective_date requires price", ErrValidation)
+	}
+
+	var tags []string
+	if req.Tags != nil {
+		var err error
+		if tags, err = models.NormalizeTags(*req.Tags); err != nil {
+			return fmt.Errorf("%w: tags: %v", ErrValidation, err)
+		}
+	}
+
 	updates := make(map[string]interface{})
 
+	if req.PlanID != nil {
+		if _, err := s.getPlan(*req.PlanID); err != nil {
+			return err
+		}
+		updates["plan_id"] = *req.PlanID
+	}
 	if req.ServiceName != nil {
 		updates["service_name"] = *req.ServiceName
 	}
-	if req.Price != nil {
-		updates["price"] = *req.Price
+	if req.Currency != nil {
+		updates["currency"] = *req.Currency
+	}
+	if req.BillingPeriod != nil {
+		updates["billing_period"] = *req.BillingPeriod
+	}
+	if req.BillingInterval != nil {
+		updates["billing_interval"] = *req.BillingInterval
+	}
+	if req.Category != nil {
+		updates["category"] = trimmedOrNil(req.Category)
+	}
+	if req.BillingDay != nil {
+		updates["billing_day"] = *req.BillingDay
 	}
 	if req.StartDate != nil {
 		updates["start_date"] = *req.StartDate
@@ -60,30 +266,233 @@ func (s *SubscriptionService) Update(id int, req *models.UpdateSubscriptionReque
 		updates["end_date"] = *req.EndDate
 	}
 
-	return s.repo.Update(id, updates)
+	update := &repository.SubscriptionUpdate{Fields: updates, Price: priceChange}
+	if req.Tags != nil {
+		update.Tags = &tags
+	}
+	if rebuildPhases {
+		update.Phases = &phases
+	}
+	if len(updates) == 0 && update.Tags == nil && update.Phases == nil && update.Price == nil {
+		return fmt.Errorf("%w: no fields to update", ErrValidation)
+	}
+	// Цена не перезаписывается, а добавляется в историю с месяца вступления в силу
+	updated, err := s.repo.ApplyUpdate(id, update)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("subscription %w", ErrNotFound)
+	}
+
+	// Новые даты или пробный период могут изменить статус
+	if req.StartDate != nil || req.EndDate != nil || rebuildPhases {
+		reason := "subscription updated"
+		if _, err := s.refreshStatus(id, &reason); err != nil {
+			return err
+		}
+	}
+
+	// Бюджеты оцениваются по подписке уже после всех изменений
+	if priceChange != nil {
+		sub, err := s.GetByID(id)
+		if err != nil {
+			return err
+		}
+		s.costChanged(sub)
+	}
+	return nil
+}
+
+// GetPriceHistory возвращает историю цен подписки с изменением каждой цены
+// относительно предыдущей и общим ростом с первой цены
+func (s *SubscriptionService) GetPriceHistory(id int) (*models.PriceHistoryResponse, error) {
+	sub, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.PriceHistoryResponse{
+		SubscriptionID: sub.ID,
+		ServiceName:    sub.ServiceName,
+		Currency:       sub.Currency,
+		Prices:         make([]models.PriceHistoryEntry, 0, len(sub.Prices)),
+	}
+	for i, change := range sub.Prices {
+		entry := models.PriceHistoryEntry{EffectiveDate: change.EffectiveDate, Price: change.Price}
+		if i > 0 {
+			entry.Change, entry.ChangePercent = priceDelta(sub.Prices[i-1].Price, change.Price)
+		}
+		result.Prices = append(result.Prices, entry)
+	}
+	if n := len(sub.Prices); n > 1 {
+		result.TotalIncrease, result.TotalIncreasePercent = priceDelta(sub.Prices[0].Price, sub.Prices[n-1].Price)
+	}
+
+	return result, nil
+}
+
+// ListTrialEnding возвращает подписки, пробный период которых заканчивается в месяцах [from, to]
+func (s *SubscriptionService) ListTrialEnding(userID *uuid.UUID, from, to models.Month) ([]*models.Subscription, error) {
+	subscriptions, err := s.repo.ListTrialEnding(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	withDerivedPrices(subscriptions...)
+	return subscriptions, nil
 }
 
 func (s *SubscriptionService) Delete(id int) error {
-	return s.repo.Delete(id)
+	deleted, err := s.repo.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("subscription %w", ErrNotFound)
+	}
+	return nil
 }
 
-func (s *SubscriptionService) GetTotalCost(userID *uuid.UUID, serviceName *string, startPeriod, endPeriod string) (*models.TotalCostResponse, error) {
-	totalCost, err := s.repo.GetTotalCost(userID, serviceName, startPeriod, endPeriod)
+// GetTotalCost считает сумму, уплаченную за период [startPeriod, endPeriod]:
+// складываются все списания подписок по их расчетным периодам, попавшие в период.
+// Если заданы tags, учитываются только подписки, отмеченные всеми тегами.
+// Если задан groupBy, дополнительно возвращаются промежуточные итоги по группам,
+// посчитанные по тому же набору подписок, что и общий итог.
+// Суммы пересчитываются в currency по курсу месяца каждого списания.
+func (s *SubscriptionService) GetTotalCost(userID *uuid.UUID, serviceName *string, tags []string, startPeriod, endPeriod models.Month, groupBy []models.GroupBy, currency string) (*models.TotalCostResponse, error) {
+	subscriptions, err := s.repo.ListForPeriod(userID, serviceName, tags, startPeriod, endPeriod)
+	if err != nil {
+		return nil, err
+	}
+	calc, err := s.newCostCalculator(subscriptions, endPeriod, currency, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	filters := make(map[string]string)
-	if userID != nil {
-		filters["user_id"] = userID.String()
+	totalCost := 0
+	groups := newCostGroups(groupBy)
+	for _, sub := range subscriptions {
+		charges, err := calc.charges(sub, startPeriod, endPeriod)
+		if err != nil {
+			return nil, err
+		}
+		for _, ch := range charges {
+			totalCost += ch.Amount
+			groups.add(sub, ch)
+		}
 	}
-	if serviceName != nil {
-		filters["service_name"] = *serviceName
+
+	filters := costFilters(userID, serviceName)
+	if len(tags) > 0 {
+		filters["tags"] = strings.Join(tags, ",")
 	}
 
 	return &models.TotalCostResponse{
 		TotalCost: totalCost,
-		Period:    fmt.Sprintf("%s to %s", startPeriod, endPeriod),
+		Currency:  calc.currency,
+		Period:    formatPeriod(startPeriod, endPeriod),
 		Filters:   filters,
+		GroupBy:   groupBy,
+		Groups:    groups.result(),
+	}, nil
+}
+
+// GetCostBreakdown раскладывает расходы за период [startPeriod, endPeriod] по месяцам.
+// Для каждого месяца возвращаются сумма, число активных подписок,
+// а также подписки, начавшиеся и закончившиеся в этом месяце.
+func (s *SubscriptionService) GetCostBreakdown(userID *uuid.UUID, serviceName *string, startPeriod, endPeriod models.Month, currency string) (*models.CostBreakdownResponse, error) {
+	subscriptions, err := s.repo.ListForPeriod(userID, serviceName, nil, startPeriod, endPeriod)
+	if err != nil {
+		return nil, err
+	}
+	calc, err := s.newCostCalculator(subscriptions, endPeriod, currency, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	months := make([]models.MonthlyCost, startPeriod.MonthsUntil(endPeriod))
+	for i := range months {
+		months[i] = models.MonthlyCost{
+			Month:   startPeriod.AddMonths(i),
+			Started: []models.SubscriptionRef{},
+			Ended:   []models.SubscriptionRef{},
+		}
+	}
+	bucket := func(m models.Month) *models.MonthlyCost {
+		if m < startPeriod || m > endPeriod {
+			return nil
+		}
+		return &months[int(m-startPeriod)]
+	}
+
+	totalCost := 0
+	for _, sub := range subscriptions {
+		charges, err := calc.charges(sub, startPeriod, endPeriod)
+		if err != nil {
+			return nil, err
+		}
+		for _, ch := range charges {
+			b := bucket(ch.Month)
+			b.Cost += ch.Amount
+			b.ActiveSubscriptions++
+			totalCost += ch.Amount
+		}
+
+		ref := models.NewSubscriptionRef(sub)
+		if start, err := models.ParseMonth(sub.StartDate); err == nil {
+			if b := bucket(start); b != nil {
+				b.Started = append(b.Started, ref)
+			}
+		}
+		if sub.EndDate != nil {
+			if end, err := models.ParseMonth(*sub.EndDate); err == nil {
+				if b := bucket(end); b != nil {
+					b.Ended = append(b.Ended, ref)
+				}
+			}
+		}
+	}
+
+	return &models.CostBreakdownResponse{
+		TotalCost: totalCost,
+		Currency:  calc.currency,
+		Period:    formatPeriod(startPeriod, endPeriod),
+		Filters:   costFilters(userID, serviceName),
+		Months:    months,
 	}, nil
 }
+
+// costChanged сообщает подписчикам OnCostChange об изменении расходов по подписке
+func (s *SubscriptionService) costChanged(sub *models.Subscription) {
+	for _, fn := range s.costObservers {
+		fn(sub)
+	}
+}
+
+// checkUserExists проверяет, что пользователь существует
+func (s *SubscriptionService) checkUserExists(userID uuid.UUID) error {
+	exists, err := s.repo.UserExists(userID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("user %w", ErrNotFound)
+	}
+	return nil
+}
+
+func formatPeriod(startPeriod, endPeriod models.Month) string {
+	return fmt.Sprintf("%s to %s", startPeriod, endPeriod)
+}
+
+// costFilters возвращает примененные фильтры для ответа отчетов
+func costFilters(userID *uuid.UUID, serviceName *string) map[string]string {
+	filters := make(map[string]string)
+	if userID != nil {
+		filters["user_id"] = userID.String()
+	}
+	if serviceName != nil {
+		filters["service_name"] = *serviceName
+	}
+	return filters
+}
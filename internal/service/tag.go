@@ -0,0 +1,64 @@
+package service
+
+import (
+	"go-dev/internal/models"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// ListTags возвращает теги подписок, активных в периоде [startPeriod, endPeriod],
+// с числом подписок и расходами за период. Подписка с несколькими тегами
+// учитывается в каждом из них, поэтому суммы по тегам могут пересекаться.
+func (s *SubscriptionService) ListTags(userID *uuid.UUID, startPeriod, endPeriod models.Month, currency string) (*models.TagsResponse, error) {
+	subscriptions, err := s.repo.ListForPeriod(userID, nil, nil, startPeriod, endPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	var tagged []*models.Subscription
+	for _, sub := range subscriptions {
+		if len(sub.Tags) > 0 {
+			tagged = append(tagged, sub)
+		}
+	}
+	calc, err := s.newCostCalculator(tagged, endPeriod, currency, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]*models.TagUsage)
+	for _, sub := range tagged {
+		charges, err := calc.charges(sub, startPeriod, endPeriod)
+		if err != nil {
+			return nil, err
+		}
+		cost := 0
+		for _, ch := range charges {
+			cost += ch.Amount
+		}
+
+		for _, tag := range sub.Tags {
+			u, ok := usage[tag]
+			if !ok {
+				u = &models.TagUsage{Tag: tag}
+				usage[tag] = u
+			}
+			u.Subscriptions++
+			u.TotalCost += cost
+		}
+	}
+
+	tags := make([]models.TagUsage, 0, len(usage))
+	for _, u := range usage {
+		tags = append(tags, *u)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Tag < tags[j].Tag })
+
+	return &models.TagsResponse{
+		Currency: calc.currency,
+		Period:   formatPeriod(startPeriod, endPeriod),
+		Filters:  costFilters(userID, nil),
+		Tags:     tags,
+	}, nil
+}
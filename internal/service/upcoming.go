@@ -0,0 +1,117 @@
+package service
+
+import (
+	"fmt"
+	"go-dev/internal/models"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxUpcomingDays — наибольшее окно календаря списаний
+const maxUpcomingDays = 366
+
+// GetUpcomingCharges проецирует подписки пользователя на days дней вперед, начиная с сегодня,
+// и возвращает ожидаемые списания по датам. Пропускаются отмененные и закончившиеся подписки,
+// месяцы паузы и бесплатный пробный период. Итог — сумма долей пользователя в валюте currency.
+func (s *SubscriptionService) GetUpcomingCharges(userID uuid.UUID, days int, currency string) (*models.UpcomingChargesResponse, error) {
+	if days < 1 || days > maxUpcomingDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrValidation, maxUpcomingDays)
+	}
+	if err := s.checkUserExists(userID); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	last := today.AddDate(0, 0, days-1)
+	from, to := models.MonthOf(today), models.MonthOf(last)
+
+	subscriptions, err := s.repo.ListForPeriod(&userID, nil, nil, from, to)
+	if err != nil {
+		return nil, err
+	}
+	calc, err := s.newCostCalculator(subscriptions, to, currency, &userID)
+	if err != nil {
+		return nil, err
+	}
+
+	charges := []models.UpcomingCharge{}
+	totalCost := 0
+	for _, sub := range subscriptions {
+		if sub.Status == models.StatusCancelled || sub.Status == models.StatusExpired {
+			continue
+		}
+		billed, err := billedCharges(sub, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ch := range billed {
+			if ch.Date.Before(today) || ch.Date.After(last) {
+				continue
+			}
+
+			share := sub.SplitCharge(ch.Amount)[userID]
+			converted, err := calc.convert(share, sub.Currency, ch.Month)
+			if err != nil {
+				return nil, err
+			}
+			totalCost += converted
+
+			charges = append(charges, models.UpcomingCharge{
+				Date:           ch.Date.Format(models.DateLayout),
+				SubscriptionID: sub.ID,
+				ServiceName:    sub.CanonicalServiceName(),
+				OwnerID:        sub.UserID,
+				Amount:         ch.Amount,
+				Share:          share,
+				Currency:       sub.Currency,
+			})
+		}
+	}
+	sort.SliceStable(charges, func(i, j int) bool { return charges[i].Date < charges[j].Date })
+
+	return &models.UpcomingChargesResponse{
+		UserID:    userID,
+		From:      today.Format(models.DateLayout),
+		To:        last.Format(models.DateLayout),
+		TotalCost: totalCost,
+		Currency:  calc.currency,
+		Charges:   charges,
+	}, nil
+}
+
+// billedCharge — оплачиваемое списание подписки
+type billedCharge struct {
+	Date   time.Time
+	Month  models.Month
+	Amount int // полная цена списания
+}
+
+// billedCharges возвращает списания подписки в месяцах [from, to] по ее расчетному
+// периоду без месяцев паузы и бесплатного пробного периода. На нем строятся и
+// календарь списаний, и календарная подписка.
+func billedCharges(sub *models.Subscription, from, to models.Month) ([]billedCharge, error) {
+	first, last, ok, err := activeMonths(sub, from, to)
+	if err != nil || !ok {
+		return nil, err
+	}
+	anchor, err := sub.BillingAnchor()
+	if err != nil {
+		return nil, fmt.Errorf("subscription %d: %w", sub.ID, err)
+	}
+
+	var charges []billedCharge
+	for _, date := range chargeDates(sub.Cycle(), anchor, sub.ChargeDay(), first, last) {
+		month := models.MonthOf(date)
+		if sub.IsPausedAt(month) {
+			continue
+		}
+		if amount := sub.PriceAt(month); amount > 0 {
+			charges = append(charges, billedCharge{Date: date, Month: month, Amount: amount})
+		}
+	}
+	return charges, nil
+}
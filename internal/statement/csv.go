@@ -0,0 +1,198 @@
+package statement
+
+import (
+	"encoding/csv"
+	"fmt"
+	"go-dev/internal/models"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ParseCSV разбирает CSV-выписку с заголовком по соответствию колонок mapping и
+// возвращает только списания; поступления и нулевые суммы пропускаются.
+func ParseCSV(r io.Reader, mapping models.StatementMapping) ([]models.StatementTransaction, error) {
+	mapping = mapping.WithDefaults()
+	delimiter, err := parseDelimiter(mapping.Delimiter)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\uFEFF") // BOM в начале файла
+		}
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	column := func(name string, required bool) (int, error) {
+		if name == "" {
+			return -1, nil
+		}
+		i, ok := columns[strings.ToLower(strings.TrimSpace(name))]
+		if !ok && required {
+			return -1, fmt.Errorf("CSV header is missing column %q", name)
+		}
+		if !ok {
+			return -1, nil
+		}
+		return i, nil
+	}
+	dateCol, err := column(mapping.DateColumn, true)
+	if err != nil {
+		return nil, err
+	}
+	descriptionCol, err := column(mapping.DescriptionColumn, true)
+	if err != nil {
+		return nil, err
+	}
+	amountCol, err := column(mapping.AmountColumn, true)
+	if err != nil {
+		return nil, err
+	}
+	currencyName := mapping.CurrencyColumn
+	if currencyName == "" {
+		currencyName = "currency"
+	}
+	currencyCol, err := column(currencyName, mapping.CurrencyColumn != "")
+	if err != nil {
+		return nil, err
+	}
+	referenceCol, err := column(mapping.ReferenceColumn, mapping.ReferenceColumn != "")
+	if err != nil {
+		return nil, err
+	}
+
+	layout := mapping.DateLayout()
+	var transactions []models.StatementTransaction
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if isBlank(record) {
+			continue
+		}
+		field := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		date, err := time.Parse(layout, field(dateCol))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid date %q, expected %s", line, field(dateCol), mapping.DateFormat)
+		}
+		amount, err := parseAmount(field(amountCol), mapping.DecimalComma)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if !mapping.DebitsPositive {
+			amount = -amount
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		currency := strings.ToUpper(field(currencyCol))
+		if currency == "" {
+			currency = mapping.Currency
+		}
+		if !isCurrencyCode(currency) {
+			return nil, fmt.Errorf("line %d: invalid currency %q", line, currency)
+		}
+		description := field(descriptionCol)
+		if description == "" {
+			return nil, fmt.Errorf("line %d: description is empty", line)
+		}
+
+		transactions = append(transactions, models.StatementTransaction{
+			Date:        date,
+			Description: description,
+			Amount:      amount,
+			Currency:    currency,
+			Reference:   field(referenceCol),
+		})
+	}
+
+	return transactions, nil
+}
+
+// parseAmount разбирает сумму вида -1 234.56, (1234.56) или -1.234,56 (decimalComma)
+// в копейки/центы; разделители разрядов и символы валют игнорируются
+func parseAmount(s string, decimalComma bool) (int, error) {
+	raw := s
+	negative := strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")")
+
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-' || r == '−':
+			negative = true
+		case r == ',' && decimalComma, r == '.' && !decimalComma:
+			b.WriteRune('.')
+		}
+	}
+
+	value, err := strconv.ParseFloat(b.String(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	amount := int(math.Round(value * 100))
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func parseDelimiter(s string) (rune, error) {
+	if strings.EqualFold(s, "tab") || s == `\t` {
+		return '\t', nil
+	}
+	if utf8.RuneCountInString(s) != 1 {
+		return 0, fmt.Errorf("invalid delimiter %q, expected a single character or tab", s)
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	return r, nil
+}
+
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}